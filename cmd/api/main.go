@@ -5,8 +5,12 @@ import (
 	_ "github.com/kkboranbay/task-service/docs"
 	"github.com/kkboranbay/task-service/internal/api"
 	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/health"
+	"github.com/kkboranbay/task-service/internal/metrics"
 	"github.com/kkboranbay/task-service/internal/repository/postgres"
+	"github.com/kkboranbay/task-service/internal/secrets"
 	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/kkboranbay/task-service/internal/tracing"
 	"github.com/kkboranbay/task-service/pkg/logger"
 	pg "github.com/kkboranbay/task-service/pkg/postgres"
 	"os"
@@ -32,7 +36,7 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-// @description Введите 'Bearer ' + ваш JWT токен
+// @description Введите 'Bearer ' + ваш JWT или персональный токен доступа
 
 // @tag.name Authentication
 // @tag.description Операции авторизации и аутентификации
@@ -40,6 +44,18 @@ import (
 // @tag.name Tasks
 // @tag.description Операции с задачами (CRUD)
 
+// @tag.name Calendar
+// @tag.description Подписка на задачи в формате iCalendar
+
+// @tag.name Tokens
+// @tag.description Персональные токены доступа для автоматизации
+
+// @tag.name TwoFactor
+// @tag.description Двухфакторная аутентификация по TOTP
+
+// @tag.name Admin
+// @tag.description Административные операции
+
 // @tag.name Health
 // @tag.description Проверка состояния сервиса
 
@@ -54,15 +70,87 @@ func main() {
 	log.Info().Msg("Запуск сервиса управления задачами")
 
 	ctx := context.Background()
-	db, err := pg.NewPool(ctx, cfg.Database)
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Ошибка настройки трассировки")
+	}
+	defer func() {
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error().Err(err).Msg("Ошибка выгрузки трассировок")
+		}
+	}()
+
+	secretStore := secrets.NewStore(cfg.SecretValues())
+	if provider := config.NewSecretProvider(cfg.Secrets); provider != nil {
+		refreshCtx, stopRefresh := context.WithCancel(ctx)
+		defer stopRefresh()
+		go secretStore.Run(refreshCtx, provider, cfg.Secrets.RefreshInterval, log)
+		log.Info().Dur("interval", cfg.Secrets.RefreshInterval).Msg("секреты обновляются из Vault")
+	}
+	cfg.Database.PasswordSource = func() string { return secretStore.Get("DB_PASSWORD") }
+
+	db, err := pg.NewPool(log.WithContext(ctx), cfg.Database)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Ошибка подключения к базе данных")
 	}
 	defer pg.Close(db)
 
-	taskRepo := postgres.NewTaskRepository(db)
+	migrator := pg.NewMigrator(db, log)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrator.Run(ctx, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal().Err(err).Msg("Ошибка выполнения миграций")
+		}
+		return
+	}
+	if cfg.Database.AutoMigrate {
+		if err := migrator.Up(ctx); err != nil {
+			log.Fatal().Err(err).Msg("Ошибка применения миграций при запуске")
+		}
+	}
+	// сервис не обслуживает запросы на схеме, которая отстает от кода
+	if err := migrator.CheckSchema(ctx); err != nil {
+		log.Fatal().Err(err).Msg("Схема базы данных не соответствует версии сервиса")
+	}
+
+	dbRouter, err := pg.NewRouter(ctx, db, cfg.Database, log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Ошибка подключения к репликам базы данных")
+	}
+	defer dbRouter.Close()
+	if len(cfg.Database.Replicas) > 0 {
+		replicaCtx, stopReplicaChecks := context.WithCancel(ctx)
+		defer stopReplicaChecks()
+		go dbRouter.Run(replicaCtx, cfg.Database.ReplicaCheckInterval)
+		log.Info().Int("replicas", len(cfg.Database.Replicas)).Int("healthy", dbRouter.HealthyReplicas()).Msg("чтения задач направляются на реплики")
+	}
+
+	appMetrics := metrics.New()
+	taskRepo := postgres.NewTaskRepository(db, appMetrics).WithReplicas(dbRouter).WithQueryTimeout(cfg.Database.QueryTimeout)
+	appMetrics.Registry().MustRegister(
+		metrics.NewPoolCollector(db),
+		metrics.NewOverdueCollector(taskRepo.CountOverdue, log),
+	)
 	taskService := service.NewTaskService(taskRepo, log)
-	server := api.NewServer(db, taskService, *cfg, log)
+	calendarTokenRepo := postgres.NewCalendarTokenRepository(db)
+	calendarService := service.NewCalendarService(calendarTokenRepo, taskRepo, log)
+	userService := service.NewUserService(postgres.NewUserRepository(db), log)
+	// admin создается миграцией без пароля; пароль задается один раз при первом запуске
+	if password := secretStore.Get("ADMIN_PASSWORD"); password != "" {
+		if err := userService.BootstrapAdmin(ctx, password); err != nil {
+			log.Fatal().Err(err).Msg("Ошибка установки пароля администратора")
+		}
+	}
+	auditService := service.NewAuditService(postgres.NewAuditRepository(db), log)
+	accessTokenService := service.NewAccessTokenService(postgres.NewAccessTokenRepository(db), log)
+	twoFactorService := service.NewTwoFactorService(postgres.NewTwoFactorRepository(db), cfg.Auth, log)
+	server, err := api.NewServer(db, taskService, calendarService, userService, auditService, accessTokenService, twoFactorService, secretStore, appMetrics, *cfg, log)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Ошибка инициализации сервера")
+	}
+	if len(cfg.Database.Replicas) > 0 {
+		server.RegisterHealthCheck(health.Check{Name: "replicas", Check: dbRouter.Check})
+	}
 	go func() {
 		if err := server.Run(); err != nil {
 			log.Fatal().Err(err).Msg("Ошибка запуска сервера")
@@ -70,6 +158,18 @@ func main() {
 	}()
 	log.Info().Msgf("Сервис запущен на порту %s", cfg.Server.Port)
 
+	watcher := config.NewWatcher(cfg, log)
+	watcher.Subscribe(func(cfg *config.Config) {
+		if err := logger.SetLevel(cfg.Logger.Level); err != nil {
+			log.Error().Err(err).Msg("Ошибка смены уровня логирования")
+		}
+		secretStore.Update(cfg.SecretValues())
+		server.ApplyConfig(cfg)
+	})
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	go watcher.Watch(watchCtx)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
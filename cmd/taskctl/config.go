@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// configCmd показывает итоговую конфигурацию после применения файла и окружения; секреты скрыты
+func (a *app) configCmd(args []string) error {
+	name, args, err := subcommand("config", args)
+	if err != nil {
+		return err
+	}
+	if name != "show" {
+		return fmt.Errorf("%w: неизвестная подкоманда config %q", errUsage, name)
+	}
+	if err := parseFlags(newFlagSet("config show"), args); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(a.cfg.Redacted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+	}
+	_, err = fmt.Fprintln(a.out, string(data))
+	return err
+}
@@ -0,0 +1,188 @@
+// Command taskctl административные операции task-service из командной строки: работает
+// с той же базой и конфигурацией, что и сервис, через его сервисный слой
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository/postgres"
+	"github.com/kkboranbay/task-service/internal/secrets"
+	"github.com/kkboranbay/task-service/internal/service"
+	pg "github.com/kkboranbay/task-service/pkg/postgres"
+	"github.com/rs/zerolog"
+	"io"
+	"os"
+	"os/signal"
+	"os/user"
+	"syscall"
+)
+
+const usage = `Использование: taskctl <команда> [аргументы]
+
+Команды:
+  users create -username NAME -password PASS [-role user|admin]
+  users list [-page N] [-size N]
+  tokens create -user ID -name NAME [-scopes a,b] [-ttl DURATION]
+  tokens jwt -user ID
+  tasks list -user ID [-status S] [-search Q] [-page N] [-size N]
+  tasks export -user ID [-format csv|json|ndjson|ics] [-status S] [-search Q] [-o FILE]
+  tasks purge -user ID -older-than DURATION [-status S] [-dry-run]
+  ` + pg.MigrateUsage + `
+  config show
+
+Конфигурация читается из тех же переменных окружения и файла config.yaml, что и у сервиса.
+Создание пользователей, выпуск токенов и очистка задач записываются в журнал аудита
+с именем оператора.
+`
+
+// errUsage неверные аргументы команды; завершает процесс с кодом 2
+var errUsage = errors.New("неверные аргументы")
+
+type app struct {
+	cfg     *config.Config
+	log     *zerolog.Logger
+	db      *pgxpool.Pool
+	secrets *secrets.Store
+	out     io.Writer
+}
+
+// audit записывает действие оператора в журнал аудита. ActorID у таких записей
+// model.SystemActorID, а оператор указывается в details
+func (a *app) audit(ctx context.Context, action model.AuditAction, targetUserID int64, details string) error {
+	audit := service.NewAuditService(postgres.NewAuditRepository(a.db), a.log)
+	return audit.Record(ctx, model.AuditEntry{
+		ActorID:      model.SystemActorID,
+		Action:       action,
+		TargetUserID: &targetUserID,
+		Details:      fmt.Sprintf("source=taskctl operator=%s %s", operator(), details),
+	})
+}
+
+// operator учетная запись ОС, от имени которой запущен taskctl, в виде user@host
+func operator() string {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	if host, err := os.Hostname(); err == nil {
+		return name + "@" + host
+	}
+	return name
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout)
+	stop()
+
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "taskctl: %v\n\n", err)
+		}
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "taskctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: не указана команда", errUsage)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+
+	// журнал уходит в stderr, чтобы не смешиваться с выводом команд, например экспортом
+	log := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).Level(zerolog.WarnLevel).With().Timestamp().Logger()
+	a := &app{cfg: cfg, log: &log, out: out}
+
+	// просмотр конфигурации не требует базы данных
+	if args[0] == "config" {
+		return a.configCmd(args[1:])
+	}
+
+	if err := a.connect(ctx); err != nil {
+		return err
+	}
+	defer pg.Close(a.db)
+
+	switch args[0] {
+	case "users":
+		return a.usersCmd(ctx, args[1:])
+	case "tokens":
+		return a.tokensCmd(ctx, args[1:])
+	case "tasks":
+		return a.tasksCmd(ctx, args[1:])
+	case "migrate":
+		return pg.NewMigrator(a.db, a.log).Run(ctx, args[1:], out)
+	default:
+		return fmt.Errorf("%w: неизвестная команда %q", errUsage, args[0])
+	}
+}
+
+// connect получает секреты так же, как сервис при запуске, и открывает пул соединений.
+// Хранилище секретов читается один раз: команды завершаются раньше очередной ротации
+func (a *app) connect(ctx context.Context) error {
+	a.secrets = secrets.NewStore(a.cfg.SecretValues())
+	if provider := config.NewSecretProvider(a.cfg.Secrets); provider != nil {
+		values, err := provider.Secrets(ctx)
+		if err != nil {
+			return fmt.Errorf("ошибка получения секретов из Vault: %w", err)
+		}
+		a.secrets.Update(values)
+	}
+	a.cfg.Database.PasswordSource = func() string { return a.secrets.Get("DB_PASSWORD") }
+
+	db, err := pg.NewPool(a.log.WithContext(ctx), a.cfg.Database)
+	if err != nil {
+		return err
+	}
+	a.db = db
+	return nil
+}
+
+// subcommand отделяет имя подкоманды от ее флагов
+func subcommand(group string, args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("%w: не указана подкоманда %s", errUsage, group)
+	}
+	return args[0], args[1:], nil
+}
+
+// newFlagSet набор флагов подкоманды; ошибки разбора возвращаются вызывающему, а не завершают процесс
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseFlags разбирает флаги и приводит ошибки разбора к errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: лишние аргументы %v", errUsage, fs.Args())
+	}
+	return nil
+}
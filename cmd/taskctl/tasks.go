@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/export"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository/postgres"
+	"github.com/kkboranbay/task-service/internal/service"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func (a *app) tasksCmd(ctx context.Context, args []string) error {
+	name, args, err := subcommand("tasks", args)
+	if err != nil {
+		return err
+	}
+
+	// метрики сервиса в утилите не собираются
+	tasks := service.NewTaskService(postgres.NewTaskRepository(a.db, nil), a.log)
+	switch name {
+	case "list":
+		return a.listTasks(ctx, tasks, args)
+	case "export":
+		return a.exportTasks(ctx, tasks, args)
+	case "purge":
+		return a.purgeTasks(ctx, tasks, args)
+	default:
+		return fmt.Errorf("%w: неизвестная подкоманда tasks %q", errUsage, name)
+	}
+}
+
+// taskFlags флаги выбора задач, общие для подкоманд tasks
+type taskFlags struct {
+	userID *int64
+	status *string
+	search *string
+}
+
+func newTaskFlags(fs *flag.FlagSet) taskFlags {
+	return taskFlags{
+		userID: fs.Int64("user", 0, "идентификатор пользователя"),
+		status: fs.String("status", "", "статус: pending, in_progress или completed"),
+		search: fs.String("search", "", "поиск по названию и описанию"),
+	}
+}
+
+func (f taskFlags) filter() (int64, model.TaskFilter, error) {
+	if *f.userID <= 0 {
+		return 0, model.TaskFilter{}, fmt.Errorf("%w: нужен -user", errUsage)
+	}
+
+	filter := model.TaskFilter{Search: *f.search}
+	if *f.status != "" {
+		status := model.TaskStatus(*f.status)
+		filter.Status = &status
+	}
+	return *f.userID, filter, nil
+}
+
+func (a *app) listTasks(ctx context.Context, tasks *service.TaskService, args []string) error {
+	fs := newFlagSet("tasks list")
+	selection := newTaskFlags(fs)
+	page := fs.Int("page", 1, "номер страницы")
+	size := fs.Int("size", 50, "размер страницы")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	userID, filter, err := selection.filter()
+	if err != nil {
+		return err
+	}
+
+	list, err := tasks.GetTaskList(ctx, userID, filter, *page, *size)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tDUE\tUPDATED\tTITLE")
+	for _, task := range list.Tasks {
+		due := "-"
+		if task.DueDate != nil {
+			due = task.DueDate.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", task.ID, task.Status, due, task.UpdatedAt.Format(time.RFC3339), task.Title)
+	}
+	fmt.Fprintf(w, "всего: %d\n", list.Total)
+	return w.Flush()
+}
+
+// exportTasks пишет задачи в файл или stdout в тех же форматах, что и GET /api/v1/tasks/export
+func (a *app) exportTasks(ctx context.Context, tasks *service.TaskService, args []string) error {
+	fs := newFlagSet("tasks export")
+	selection := newTaskFlags(fs)
+	format := fs.String("format", string(export.FormatCSV), "формат: csv, json, ndjson или ics")
+	output := fs.String("o", "", "файл для записи; по умолчанию stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	userID, filter, err := selection.filter()
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = a.out
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("ошибка создания файла экспорта: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer, err := export.NewWriter(export.Format(*format), out)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if err := writer.Begin(); err != nil {
+		return err
+	}
+	if err := tasks.ExportTasks(ctx, userID, filter, writer.Write); err != nil {
+		return err
+	}
+	return writer.End()
+}
+
+func (a *app) purgeTasks(ctx context.Context, tasks *service.TaskService, args []string) error {
+	fs := newFlagSet("tasks purge")
+	selection := newTaskFlags(fs)
+	olderThan := fs.Duration("older-than", 0, "удалить задачи, не изменявшиеся дольше этого срока")
+	dryRun := fs.Bool("dry-run", false, "только подсчитать задачи")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	userID, filter, err := selection.filter()
+	if err != nil {
+		return err
+	}
+	// без срока очистка удалила бы все задачи пользователя
+	if *olderThan <= 0 {
+		return fmt.Errorf("%w: нужен положительный -older-than", errUsage)
+	}
+
+	before := time.Now().Add(-*olderThan)
+	count, err := tasks.PurgeTasks(ctx, userID, filter, before, true)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		_, err = fmt.Fprintf(a.out, "будет удалено задач: %d\n", count)
+		return err
+	}
+
+	// как и выпуск токенов, удаление записывается в журнал аудита до выполнения
+	details := fmt.Sprintf("status=%s search=%q before=%s count=%d", *selection.status, filter.Search, before.Format(time.RFC3339), count)
+	if err := a.audit(ctx, model.AuditActionPurgeTasks, userID, details); err != nil {
+		return err
+	}
+
+	count, err = tasks.PurgeTasks(ctx, userID, filter, before, false)
+	if err != nil {
+		return fmt.Errorf("удалено задач до ошибки: %d: %w", count, err)
+	}
+	_, err = fmt.Fprintf(a.out, "удалено задач: %d\n", count)
+	return err
+}
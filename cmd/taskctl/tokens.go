@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/jwtkeys"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository/postgres"
+	"github.com/kkboranbay/task-service/internal/service"
+	"strings"
+	"time"
+)
+
+func (a *app) tokensCmd(ctx context.Context, args []string) error {
+	name, args, err := subcommand("tokens", args)
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "create":
+		return a.createAccessToken(ctx, args)
+	case "jwt":
+		return a.mintJWT(ctx, args)
+	default:
+		return fmt.Errorf("%w: неизвестная подкоманда tokens %q", errUsage, name)
+	}
+}
+
+// createAccessToken выпускает персональный токен; права ограничены ролью пользователя, как в API
+func (a *app) createAccessToken(ctx context.Context, args []string) error {
+	fs := newFlagSet("tokens create")
+	userID := fs.Int64("user", 0, "идентификатор пользователя")
+	name := fs.String("name", "", "название токена")
+	scopes := fs.String("scopes", model.ScopeTasksRead, "права через запятую")
+	ttl := fs.Duration("ttl", 0, "срок действия; 0 — бессрочный")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *userID <= 0 || *name == "" {
+		return fmt.Errorf("%w: нужны -user и -name", errUsage)
+	}
+
+	user, err := a.user(ctx, *userID)
+	if err != nil {
+		return err
+	}
+
+	req := model.CreateAccessTokenRequest{Name: *name, Scopes: strings.Split(*scopes, ",")}
+	if *ttl > 0 {
+		expiresAt := time.Now().Add(*ttl)
+		req.ExpiresAt = &expiresAt
+	}
+
+	tokens := service.NewAccessTokenService(postgres.NewAccessTokenRepository(a.db), a.log)
+	resp, err := tokens.CreateToken(ctx, user.ID, model.DefaultScopes(user.Role), req)
+	if err != nil {
+		return err
+	}
+	// токен выводится только после записи в журнал аудита
+	details := fmt.Sprintf("token_id=%d name=%q scopes=%s", resp.ID, resp.Name, strings.Join(resp.Scopes, ","))
+	if err := a.audit(ctx, model.AuditActionCreateToken, user.ID, details); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(a.out, resp.Token)
+	return err
+}
+
+// mintJWT подписывает токен входа теми же ключами и секретом, что и сервис
+func (a *app) mintJWT(ctx context.Context, args []string) error {
+	fs := newFlagSet("tokens jwt")
+	userID := fs.Int64("user", 0, "идентификатор пользователя")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *userID <= 0 {
+		return fmt.Errorf("%w: нужен -user", errUsage)
+	}
+
+	user, err := a.user(ctx, *userID)
+	if err != nil {
+		return err
+	}
+
+	jwt := middleware.NewJWTMiddleware(a.cfg.Auth, a.log).WithSecrets(a.secrets)
+	if a.cfg.Auth.SigningKeyFile != "" {
+		keys, err := jwtkeys.Load(a.cfg.Auth.SigningKeyFile, a.cfg.Auth.VerificationKeyFiles)
+		if err != nil {
+			return fmt.Errorf("ошибка загрузки ключей подписи JWT: %w", err)
+		}
+		jwt.WithKeySet(keys)
+	}
+
+	// как и при имперсонации в API, запись в журнал делается до выдачи токена
+	details := fmt.Sprintf("role=%s ttl=%s", user.Role, a.cfg.Auth.TokenExpireDelta)
+	if err := a.audit(ctx, model.AuditActionIssueJWT, user.ID, details); err != nil {
+		return err
+	}
+
+	token, err := jwt.GenerateToken(user.ID, user.Role)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи токена: %w", err)
+	}
+	_, err = fmt.Fprintln(a.out, token)
+	return err
+}
+
+func (a *app) user(ctx context.Context, id int64) (*model.User, error) {
+	return service.NewUserService(postgres.NewUserRepository(a.db), a.log).GetUser(ctx, id)
+}
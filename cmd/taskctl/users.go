@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository/postgres"
+	"github.com/kkboranbay/task-service/internal/service"
+	"text/tabwriter"
+	"time"
+)
+
+func (a *app) usersCmd(ctx context.Context, args []string) error {
+	name, args, err := subcommand("users", args)
+	if err != nil {
+		return err
+	}
+
+	users := service.NewUserService(postgres.NewUserRepository(a.db), a.log)
+	switch name {
+	case "create":
+		return a.createUser(ctx, users, args)
+	case "list":
+		return a.listUsers(ctx, users, args)
+	default:
+		return fmt.Errorf("%w: неизвестная подкоманда users %q", errUsage, name)
+	}
+}
+
+func (a *app) createUser(ctx context.Context, users *service.UserService, args []string) error {
+	fs := newFlagSet("users create")
+	username := fs.String("username", "", "имя пользователя")
+	password := fs.String("password", "", "пароль")
+	role := fs.String("role", string(model.RoleUser), "роль: user или admin")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	user, err := users.CreateUser(ctx, *username, *password, model.Role(*role))
+	if err != nil {
+		return err
+	}
+	details := fmt.Sprintf("username=%s role=%s", user.Username, user.Role)
+	if err := a.audit(ctx, model.AuditActionCreateUser, user.ID, details); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(a.out, "создан пользователь %s (id %d, роль %s)\n", user.Username, user.ID, user.Role)
+	return err
+}
+
+func (a *app) listUsers(ctx context.Context, users *service.UserService, args []string) error {
+	fs := newFlagSet("users list")
+	page := fs.Int("page", 1, "номер страницы")
+	size := fs.Int("size", 50, "размер страницы")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	list, err := users.ListUsers(ctx, *page, *size)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tCREATED")
+	for _, user := range list.Users {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", user.ID, user.Username, user.Role, user.CreatedAt.Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "всего: %d\n", list.Total)
+	return w.Flush()
+}
@@ -24,58 +24,56 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/tasks": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Возвращает JWKS с открытыми ключами, которыми подписываются токены. Во время ротации содержит и старый, и новый ключ",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Открытые ключи JWT",
+                "responses": {
+                    "200": {
+                        "description": "Набор открытых ключей",
+                        "schema": {
+                            "$ref": "#/definitions/jwtkeys.JWKS"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/2fa": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает список задач пользователя с поддержкой пагинации",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Включена ли 2FA, требует ли ее политика и сколько осталось кодов восстановления",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tasks"
-                ],
-                "summary": "Получить список задач",
-                "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Номер страницы",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Количество элементов на странице",
-                        "name": "page_size",
-                        "in": "query"
-                    }
+                    "TwoFactor"
                 ],
+                "summary": "Состояние двухфакторной аутентификации",
                 "responses": {
                     "200": {
-                        "description": "Список задач",
+                        "description": "Состояние 2FA",
                         "schema": {
-                            "$ref": "#/definitions/model.TaskListResponseSwagger"
+                            "$ref": "#/definitions/model.TwoFactorStatus"
                         }
                     },
-                    "400": {
-                        "description": "Некорректные параметры запроса",
+                    "401": {
+                        "description": "Не авторизован",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
                     },
-                    "401": {
-                        "description": "Не авторизован",
+                    "403": {
+                        "description": "Сессия имперсонации или персональный токен",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
@@ -87,14 +85,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/2fa/confirm": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Создает новую задачу для авторизованного пользователя",
+                "description": "Подтверждает настройку кодом из приложения и возвращает коды восстановления. Коды показываются один раз",
                 "consumes": [
                     "application/json"
                 ],
@@ -102,25 +102,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tasks"
+                    "TwoFactor"
                 ],
-                "summary": "Создать новую задачу",
+                "summary": "Включить 2FA",
                 "parameters": [
                     {
-                        "description": "Данные новой задачи",
-                        "name": "task",
+                        "description": "Код из приложения",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.CreateTaskRequestSwagger"
+                            "$ref": "#/definitions/model.TwoFactorCodeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Задача успешно создана",
+                    "200": {
+                        "description": "Коды восстановления",
                         "schema": {
-                            "$ref": "#/definitions/model.TaskSwagger"
+                            "$ref": "#/definitions/model.RecoveryCodesResponse"
                         }
                     },
                     "400": {
@@ -130,7 +130,19 @@ const docTemplate = `{
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверный код",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Настройка 2FA не начата",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
@@ -144,55 +156,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tasks/{id}": {
-            "get": {
+        "/api/v1/2fa/disable": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Возвращает задачу по её уникальному идентификатору",
+                "description": "Отключает 2FA после проверки кода из приложения или кода восстановления. Недоступно, если 2FA обязательна",
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "Tasks"
+                    "TwoFactor"
                 ],
-                "summary": "Получить задачу по ID",
+                "summary": "Отключить 2FA",
                 "parameters": [
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "ID задачи",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Код из приложения или код восстановления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TwoFactorCodeRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Данные задачи",
-                        "schema": {
-                            "$ref": "#/definitions/model.TaskSwagger"
-                        }
+                    "204": {
+                        "description": "2FA отключена"
                     },
                     "400": {
-                        "description": "Некорректный ID задачи",
+                        "description": "Некорректные данные запроса",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
                     },
                     "401": {
-                        "description": "Не авторизован",
+                        "description": "Неверный код",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "403": {
+                        "description": "2FA обязательна политикой",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
                     },
                     "404": {
-                        "description": "Задача не найдена",
+                        "description": "2FA не включена",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
@@ -204,54 +217,96 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/2fa/enroll": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Обновляет данные существующей задачи (частичное обновление)",
-                "consumes": [
+                "description": "Создает новый секрет TOTP и возвращает otpauth:// адрес для QR-кода. 2FA включается после подтверждения кодом",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "TwoFactor"
+                ],
+                "summary": "Начать настройку 2FA",
+                "responses": {
+                    "200": {
+                        "description": "Секрет и адрес для QR-кода",
+                        "schema": {
+                            "$ref": "#/definitions/model.TwoFactorEnrollment"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "403": {
+                        "description": "Сессия имперсонации или персональный токен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает действия администраторов, новые записи первыми",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tasks"
+                    "Admin"
                 ],
-                "summary": "Обновить задачу",
+                "summary": "Получить журнал аудита",
                 "parameters": [
                     {
                         "minimum": 1,
                         "type": "integer",
-                        "description": "ID задачи",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
-                        "description": "Обновляемые данные задачи",
-                        "name": "task",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.UpdateTaskRequestSwagger"
-                        }
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Обновленная задача",
-                        "schema": {
-                            "$ref": "#/definitions/model.TaskSwagger"
-                        }
-                    },
-                    "400": {
-                        "description": "Некорректные данные запроса",
+                        "description": "Журнал аудита",
                         "schema": {
-                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                            "$ref": "#/definitions/model.AuditListResponse"
                         }
                     },
                     "401": {
@@ -260,8 +315,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
                     },
-                    "404": {
-                        "description": "Задача не найдена",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
@@ -273,42 +328,99 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/admin/lockouts/{username}": {
             "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Удаляет задачу по её уникальному идентификатору",
-                "consumes": [
-                    "application/json"
+                "description": "Сбрасывает счетчик неудачных попыток входа пользователя и, если указан ip, адреса клиента",
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Снять блокировку входа",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя пользователя",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "IP адрес, с которого также нужно снять блокировку",
+                        "name": "ip",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Блокировка снята"
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Блокировка не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает пользователей сервиса с пагинацией",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tasks"
+                    "Admin"
                 ],
-                "summary": "Удалить задачу",
+                "summary": "Получить список пользователей",
                 "parameters": [
                     {
                         "minimum": 1,
                         "type": "integer",
-                        "description": "ID задачи",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Задача успешно удалена"
-                    },
-                    "400": {
-                        "description": "Некорректный ID задачи",
+                    "200": {
+                        "description": "Список пользователей",
                         "schema": {
-                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                            "$ref": "#/definitions/model.UserListResponse"
                         }
                     },
                     "401": {
@@ -317,8 +429,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
                     },
-                    "404": {
-                        "description": "Задача не найдена",
+                    "403": {
+                        "description": "Недостаточно прав",
                         "schema": {
                             "$ref": "#/definitions/model.ErrorResponseSwagger"
                         }
@@ -332,89 +444,1630 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/login": {
-            "post": {
-                "description": "Выполняет вход пользователя в систему и возвращает JWT токен",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Authentication"
-                ],
-                "summary": "Авторизация пользователя",
-                "parameters": [
-                    {
-                        "description": "Данные для входа",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.LoginRequestSwagger"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Успешная авторизация",
-                        "schema": {
-                            "$ref": "#/definitions/model.LoginResponseSwagger"
-                        }
-                    },
-                    "400": {
-                        "description": "Некорректные данные запроса",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponseSwagger"
-                        }
-                    },
-                    "401": {
-                        "description": "Неверные учетные данные",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponseSwagger"
-                        }
-                    },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponseSwagger"
-                        }
+        "/api/v1/admin/users/{id}/impersonate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выдает короткоживущий токен пользователя; действие и все запросы с токеном записываются в аудит",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Войти от имени пользователя",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Токен пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImpersonateResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает задачи любого пользователя; просмотр записывается в журнал аудита",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Получить задачи пользователя",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "in_progress",
+                            "completed"
+                        ],
+                        "type": "string",
+                        "description": "Фильтр по статусу",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поиск по заголовку и описанию",
+                        "name": "search",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список задач",
+                        "schema": {
+                            "$ref": "#/definitions/model.TaskListResponseSwagger"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "403": {
+                        "description": "Недостаточно прав",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/calendar/token": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает время выпуска действующего токена; сам токен повторно не показывается",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Получить сведения о подписке на календарь",
+                "responses": {
+                    "200": {
+                        "description": "Сведения о подписке",
+                        "schema": {
+                            "$ref": "#/definitions/model.CalendarTokenSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не настроена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает новый секретный токен подписки; предыдущая ссылка перестает работать",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Выпустить ссылку на календарь",
+                "responses": {
+                    "201": {
+                        "description": "Новая ссылка на подписку",
+                        "schema": {
+                            "$ref": "#/definitions/model.CalendarTokenResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет токен подписки; лента становится недоступной",
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Отозвать ссылку на календарь",
+                "responses": {
+                    "204": {
+                        "description": "Ссылка отозвана"
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Подписка не настроена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список задач пользователя с поддержкой пагинации",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Получить список задач",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "in_progress",
+                            "completed"
+                        ],
+                        "type": "string",
+                        "description": "Фильтр по статусу",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поиск по заголовку и описанию",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Срок выполнения раньше (RFC3339)",
+                        "name": "due_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Срок выполнения не раньше (RFC3339)",
+                        "name": "due_after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список задач",
+                        "schema": {
+                            "$ref": "#/definitions/model.TaskListResponseSwagger"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна (error_code db_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "504": {
+                        "description": "База данных не ответила вовремя (error_code db_timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает новую задачу для авторизованного пользователя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Создать новую задачу",
+                "parameters": [
+                    {
+                        "description": "Данные новой задачи",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateTaskRequestSwagger"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Задача успешно создана",
+                        "schema": {
+                            "$ref": "#/definitions/model.TaskSwagger"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна (error_code db_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "504": {
+                        "description": "База данных не ответила вовремя (error_code db_timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tasks/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Потоково выгружает задачи пользователя в CSV, JSON, NDJSON или iCalendar с учетом фильтров списка.\nSERVER_WRITE_TIMEOUT ограничивает паузу между задачами в выгрузке, а не ее общую длительность",
+                "produces": [
+                    "text/csv",
+                    "application/json",
+                    "application/x-ndjson",
+                    "text/calendar"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Экспортировать задачи",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "json",
+                            "ndjson",
+                            "ics"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Формат выгрузки",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "vtodo",
+                            "vevent"
+                        ],
+                        "type": "string",
+                        "default": "vtodo",
+                        "description": "Компонент iCalendar для формата ics",
+                        "name": "ics_component",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "in_progress",
+                            "completed"
+                        ],
+                        "type": "string",
+                        "description": "Фильтр по статусу",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поиск по заголовку и описанию",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Срок выполнения раньше (RFC3339)",
+                        "name": "due_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Срок выполнения не раньше (RFC3339)",
+                        "name": "due_after",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл с задачами",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный формат или фильтр",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна (error_code db_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "504": {
+                        "description": "База данных не ответила вовремя (error_code db_timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tasks/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Загружает задачи из CSV (колонки title, description, status, due_date) или NDJSON. Каждая строка проверяется по правилам создания задачи, некорректные строки попадают в отчет и не прерывают импорт",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Импортировать задачи",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Формат файла, по умолчанию определяется по Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Только проверить файл, не сохраняя задачи",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Содержимое файла",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчет об импорте",
+                        "schema": {
+                            "$ref": "#/definitions/model.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Неподдерживаемый формат или файл не удалось разобрать",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "413": {
+                        "description": "Файл слишком большой",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна (error_code db_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "504": {
+                        "description": "База данных не ответила вовремя (error_code db_timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tasks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает задачу по её уникальному идентификатору",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Получить задачу по ID",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Данные задачи",
+                        "schema": {
+                            "$ref": "#/definitions/model.TaskSwagger"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID задачи",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна (error_code db_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "504": {
+                        "description": "База данных не ответила вовремя (error_code db_timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет данные существующей задачи (частичное обновление)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Обновить задачу",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Обновляемые данные задачи",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdateTaskRequestSwagger"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная задача",
+                        "schema": {
+                            "$ref": "#/definitions/model.TaskSwagger"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна (error_code db_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "504": {
+                        "description": "База данных не ответила вовремя (error_code db_timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет задачу по её уникальному идентификатору",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Удалить задачу",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Задача успешно удалена"
+                    },
+                    "400": {
+                        "description": "Некорректный ID задачи",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "База данных недоступна (error_code db_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "504": {
+                        "description": "База данных не ответила вовремя (error_code db_timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает действующие токены пользователя без секретной части",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Получить персональные токены",
+                "responses": {
+                    "200": {
+                        "description": "Токены",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AccessToken"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "403": {
+                        "description": "Запрос по персональному токену",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выпускает долгоживущий токен для автоматизации. Токен показывается только в этом ответе и передается в Authorization: Bearer или X-API-Key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Создать персональный токен доступа",
+                "parameters": [
+                    {
+                        "description": "Название, права и срок действия",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateAccessTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданный токен",
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateAccessTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные или права",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "403": {
+                        "description": "Выпуск токена запрещен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "tags": [
+                    "Tokens"
+                ],
+                "summary": "Отозвать персональный токен",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "ID токена",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Токен отозван"
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Не авторизован",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "403": {
+                        "description": "Запрос по персональному токену",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "404": {
+                        "description": "Токен не найден",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/enroll": {
+            "post": {
+                "description": "Используется, когда вход вернул enrollment_required. Возвращает секрет и otpauth:// адрес для QR-кода; настройка завершается через /auth/2fa/verify",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Начать обязательную настройку 2FA",
+                "parameters": [
+                    {
+                        "description": "Токен второго шага входа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TwoFactorChallengeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Секрет и адрес для QR-кода",
+                        "schema": {
+                            "$ref": "#/definitions/model.TwoFactorEnrollment"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Недействительный токен второго шага",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/2fa/verify": {
+            "post": {
+                "description": "Принимает код из приложения или код восстановления и выдает JWT. Если вход завершает обязательную настройку 2FA, в ответе возвращаются коды восстановления",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Подтвердить вход кодом 2FA",
+                "parameters": [
+                    {
+                        "description": "Токен второго шага и код",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.TwoFactorVerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/model.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверный код или недействительный токен второго шага",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много неудачных попыток, см. заголовок Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Выполняет вход пользователя в систему и возвращает JWT токен",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Авторизация пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные для входа",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.LoginRequestSwagger"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная авторизация или model.TwoFactorChallengeResponse, если нужен второй фактор",
+                        "schema": {
+                            "$ref": "#/definitions/model.LoginResponseSwagger"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Неверные учетные данные",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много попыток входа, см. заголовок Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/callback": {
+            "get": {
+                "description": "Обменивает код авторизации на ID токен, при первом входе создает пользователя и возвращает JWT. Если у пользователя включена 2FA или задан AUTH_2FA_REQUIRED, вход завершается через /auth/2fa/verify",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Завершение входа через OIDC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Код авторизации",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Состояние, выданное при начале входа",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешная авторизация или model.TwoFactorChallengeResponse, если нужен второй фактор",
+                        "schema": {
+                            "$ref": "#/definitions/model.LoginResponseSwagger"
+                        }
+                    },
+                    "400": {
+                        "description": "Недействительное состояние входа",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "401": {
+                        "description": "Провайдер отклонил вход или ID токен недействителен",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/login": {
+            "get": {
+                "description": "Перенаправляет на страницу входа внешнего провайдера (authorization code flow с PKCE). Доступно, если задан OIDC_ISSUER_URL",
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Вход через OIDC провайдера",
+                "responses": {
+                    "302": {
+                        "description": "Перенаправление к провайдеру"
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar/{token}": {
+            "get": {
+                "description": "Публичная лента задач со сроком выполнения для подписки в Google Calendar, Outlook и других клиентах. Поддерживает условные запросы через If-None-Match",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Лента задач в формате iCalendar",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Секретный токен с расширением .ics",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag ранее полученной ленты",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Календарь задач",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Лента не изменилась"
+                    },
+                    "404": {
+                        "description": "Календарь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/model.ErrorResponseSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/livez": {
+            "get": {
+                "description": "Отвечает, пока процесс работает; зависимости не проверяются. Также доступна по адресу /health",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Проверка жизнеспособности",
+                "responses": {
+                    "200": {
+                        "description": "Процесс работает",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthReportSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Проверяет зависимости и возвращает отчет по каждой. Отказ некритичного компонента дает статус degraded с кодом 200. Во время остановки сервиса возвращает 503. Также доступна по адресу /readiness",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Проверка готовности",
+                "responses": {
+                    "200": {
+                        "description": "Сервис готов",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthReportSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "Критичный компонент недоступен или сервис останавливается",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthReportSwagger"
+                        }
+                    }
+                }
+            }
+        },
+        "/startupz": {
+            "get": {
+                "description": "Успешна, когда критичные зависимости были доступны хотя бы один раз; после этого зависимости не проверяются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Проверка запуска",
+                "responses": {
+                    "200": {
+                        "description": "Запуск завершен",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthReportSwagger"
+                        }
+                    },
+                    "503": {
+                        "description": "Зависимости еще недоступны",
+                        "schema": {
+                            "$ref": "#/definitions/model.HealthReportSwagger"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "jwtkeys.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "jwtkeys.JWKS": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jwtkeys.JWK"
+                    }
+                }
+            }
+        },
+        "model.AccessToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.AuditAction": {
+            "type": "string",
+            "enum": [
+                "impersonate",
+                "view_tasks",
+                "unlock_login",
+                "login_lockout",
+                "create_user",
+                "create_access_token",
+                "issue_jwt",
+                "purge_tasks"
+            ],
+            "x-enum-varnames": [
+                "AuditActionImpersonate",
+                "AuditActionViewTasks",
+                "AuditActionUnlockLogin",
+                "AuditActionLoginLockout",
+                "AuditActionCreateUser",
+                "AuditActionCreateToken",
+                "AuditActionIssueJWT",
+                "AuditActionPurgeTasks"
+            ]
+        },
+        "model.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/model.AuditAction"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "target_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.AuditListResponse": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.AuditEntry"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CalendarTokenResponseSwagger": {
+            "description": "Секретная ссылка на iCalendar-ленту задач",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Время выпуска токена\n@example \"2024-01-15T10:30:00Z\"",
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "token": {
+                    "description": "Секретный токен; показывается только при выпуске\n@example \"q3JvZ1c0dF9zZWNyZXRfdG9rZW5fZXhhbXBsZQ\"",
+                    "type": "string",
+                    "example": "q3JvZ1c0dF9zZWNyZXRfdG9rZW5fZXhhbXBsZQ"
+                },
+                "url": {
+                    "description": "Адрес ленты для подписки в календаре\n@example \"https://taskservice.com/calendar/q3JvZ1c0dF9zZWNyZXRfdG9rZW5fZXhhbXBsZQ.ics\"",
+                    "type": "string",
+                    "example": "https://taskservice.com/calendar/q3JvZ1c0dF9zZWNyZXRfdG9rZW5fZXhhbXBsZQ.ics"
+                }
+            }
+        },
+        "model.CalendarTokenSwagger": {
+            "description": "Время выпуска действующего токена календаря",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "Время выпуска токена\n@example \"2024-01-15T10:30:00Z\"",
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                }
+            }
+        },
+        "model.CreateAccessTokenRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
                     }
                 }
             }
         },
-        "/health": {
-            "get": {
-                "description": "Возвращает статус работоспособности API сервиса",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Health"
-                ],
-                "summary": "Проверка состояния сервиса",
-                "responses": {
-                    "200": {
-                        "description": "Сервис работает нормально",
-                        "schema": {
-                            "$ref": "#/definitions/model.HealthResponseSwagger"
-                        }
-                    },
-                    "500": {
-                        "description": "Сервис недоступен",
-                        "schema": {
-                            "$ref": "#/definitions/model.ErrorResponseSwagger"
-                        }
+        "model.CreateAccessTokenResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
+                },
+                "token": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
+        },
         "model.CreateTaskRequestSwagger": {
             "description": "Данные для создания новой задачи",
             "type": "object",
@@ -451,31 +2104,122 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Поле 'title' обязательно для заполнения"
                 },
+                "error_code": {
+                    "description": "Машиночитаемый код ошибки: db_timeout или db_unavailable\n@example \"db_timeout\"",
+                    "type": "string",
+                    "example": "db_timeout"
+                },
                 "message": {
                     "description": "Сообщение об ошибке\n@example \"Некорректные данные запроса\"",
                     "type": "string",
                     "example": "Некорректные данные запроса"
+                },
+                "request_id": {
+                    "description": "ID запроса из заголовка X-Request-ID для поиска в журнале сервера\n@example \"5f1c2a9e8b7d4c3a9e0f1b2c3d4e5f60\"",
+                    "type": "string",
+                    "example": "5f1c2a9e8b7d4c3a9e0f1b2c3d4e5f60"
                 }
             }
         },
-        "model.HealthResponseSwagger": {
-            "description": "Состояние сервиса",
+        "model.HealthComponentSwagger": {
+            "description": "Результат проверки одного компонента",
             "type": "object",
             "properties": {
+                "critical": {
+                    "description": "Отказ критичного компонента делает сервис неготовым\n@example true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "duration_ms": {
+                    "description": "Длительность проверки в миллисекундах\n@example 3",
+                    "type": "integer",
+                    "example": 3
+                },
+                "error": {
+                    "description": "Текст ошибки проверки\n@example \"таймаут проверки: context deadline exceeded\"",
+                    "type": "string",
+                    "example": "таймаут проверки: context deadline exceeded"
+                },
+                "name": {
+                    "description": "Имя компонента\n@example \"database\"",
+                    "type": "string",
+                    "example": "database"
+                },
                 "status": {
-                    "description": "Статус сервиса\n@example \"ok\"",
+                    "description": "Статус компонента: ok или unavailable\n@example \"ok\"",
                     "type": "string",
                     "example": "ok"
+                }
+            }
+        },
+        "model.HealthReportSwagger": {
+            "description": "Итоговый статус сервиса и состояние каждой зависимости",
+            "type": "object",
+            "properties": {
+                "components": {
+                    "description": "Результаты проверок зависимостей",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.HealthComponentSwagger"
+                    }
                 },
-                "timestamp": {
-                    "description": "Временная метка\n@example \"2024-01-15T10:30:00Z\"",
+                "reason": {
+                    "description": "Причина недоступности, например остановка сервиса\n@example \"сервис останавливается\"",
                     "type": "string",
-                    "example": "2024-01-15T10:30:00Z"
+                    "example": "сервис останавливается"
                 },
-                "version": {
-                    "description": "Версия API\n@example \"1.0.0\"",
+                "status": {
+                    "description": "Статус сервиса: ok, degraded или unavailable\n@example \"ok\"",
                     "type": "string",
-                    "example": "1.0.0"
+                    "example": "ok"
+                }
+            }
+        },
+        "model.ImpersonateResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ImportReport": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportRowError"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
                 }
             }
         },
@@ -499,6 +2243,24 @@ const docTemplate = `{
                 }
             }
         },
+        "model.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "description": "RecoveryCodes выдаются, если вход завершил обязательную регистрацию 2FA",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "model.LoginResponseSwagger": {
             "description": "Ответ с JWT токеном",
             "type": "object",
@@ -515,6 +2277,28 @@ const docTemplate = `{
                 }
             }
         },
+        "model.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "model.Role": {
+            "type": "string",
+            "enum": [
+                "admin",
+                "user"
+            ],
+            "x-enum-varnames": [
+                "RoleAdmin",
+                "RoleUser"
+            ]
+        },
         "model.TaskListResponseSwagger": {
             "description": "Список задач с пагинацией",
             "type": "object",
@@ -607,6 +2391,69 @@ const docTemplate = `{
                 }
             }
         },
+        "model.TwoFactorChallengeRequest": {
+            "type": "object",
+            "required": [
+                "challenge_token"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TwoFactorCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TwoFactorEnrollment": {
+            "type": "object",
+            "properties": {
+                "provisioning_uri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TwoFactorStatus": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "recovery_codes_left": {
+                    "type": "integer"
+                },
+                "required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "model.TwoFactorVerifyRequest": {
+            "type": "object",
+            "required": [
+                "challenge_token",
+                "code"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "Code код из приложения или один из кодов восстановления",
+                    "type": "string"
+                }
+            }
+        },
         "model.UpdateTaskRequestSwagger": {
             "description": "Данные для обновления существующей задачи",
             "type": "object",
@@ -640,11 +2487,42 @@ const docTemplate = `{
                     "example": "Изучить Go (обновлено)"
                 }
             }
+        },
+        "model.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "$ref": "#/definitions/model.Role"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.UserListResponse": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.User"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
         "BearerAuth": {
-            "description": "Введите 'Bearer ' + ваш JWT токен",
+            "description": "Введите 'Bearer ' + ваш JWT или персональный токен доступа",
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
@@ -659,6 +2537,22 @@ const docTemplate = `{
             "description": "Операции с задачами (CRUD)",
             "name": "Tasks"
         },
+        {
+            "description": "Подписка на задачи в формате iCalendar",
+            "name": "Calendar"
+        },
+        {
+            "description": "Персональные токены доступа для автоматизации",
+            "name": "Tokens"
+        },
+        {
+            "description": "Двухфакторная аутентификация по TOTP",
+            "name": "TwoFactor"
+        },
+        {
+            "description": "Административные операции",
+            "name": "Admin"
+        },
         {
             "description": "Проверка состояния сервиса",
             "name": "Health"
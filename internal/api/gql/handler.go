@@ -0,0 +1,81 @@
+package gql
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"net/http"
+	"slices"
+)
+
+type Handler struct {
+	schema      graphql.Schema
+	taskService *service.TaskService
+	log         *zerolog.Logger
+}
+
+func NewHandler(taskService *service.TaskService, log *zerolog.Logger) (*Handler, error) {
+	schema, err := newSchema(taskService, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		schema:      schema,
+		taskService: taskService,
+		log:         log,
+	}, nil
+}
+
+func (h *Handler) Register(router *gin.RouterGroup) {
+	router.POST("", h.Query)
+}
+
+type Request struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) Query(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	var req Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error().Err(err).Msg("ошибка разбора GraphQL запроса")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные данные запроса",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	scopes, _ := c.Get("scopes")
+	grantedScopes, _ := scopes.([]string)
+
+	ctx := context.WithValue(c.Request.Context(), userIDKey{}, userID)
+	ctx = context.WithValue(ctx, canWriteKey{}, slices.Contains(grantedScopes, model.ScopeTasksWrite))
+	ctx = withLoader(ctx, newTaskLoader(ctx, h.taskService, userID.(int64)))
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,155 @@
+package gql
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/kkboranbay/task-service/internal/testutils"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type GraphQLHandlerTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.MockTaskRepository
+	router   *gin.Engine
+}
+
+type graphQLResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (suite *GraphQLHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *GraphQLHandlerTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.MockTaskRepository)
+	logger := zerolog.Nop()
+	taskService := service.NewTaskService(suite.mockRepo, &logger)
+
+	handler, err := NewHandler(taskService, &logger)
+	require.NoError(suite.T(), err)
+
+	suite.router = gin.New()
+	suite.router.Use(func(c *gin.Context) {
+		c.Set("user_id", int64(1))
+		c.Set("scopes", model.DefaultScopes(model.RoleUser))
+		c.Next()
+	})
+	handler.Register(suite.router.Group("/graphql"))
+}
+
+func (suite *GraphQLHandlerTestSuite) do(query string, variables map[string]interface{}) graphQLResponse {
+	body, _ := json.Marshal(Request{Query: query, Variables: variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	suite.router.ServeHTTP(w, req)
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var resp graphQLResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func (suite *GraphQLHandlerTestSuite) TestTaskQueriesAreBatched() {
+	suite.mockRepo.On("GetByIDs", mock.Anything, []int64{1, 2, 3}, int64(1)).
+		Return([]model.Task{
+			*testutils.TaskFixture(func(t *model.Task) { t.ID = 1; t.Title = "first" }),
+			*testutils.TaskFixture(func(t *model.Task) { t.ID = 2; t.Title = "second" }),
+		}, nil).Once()
+
+	resp := suite.do(`{
+		a: task(id: "1") { id title status }
+		b: task(id: "2") { title }
+		missing: task(id: "3") { title }
+		byIds: tasksByIds(ids: ["2", "1"]) { id }
+	}`, nil)
+
+	assert.Empty(suite.T(), resp.Errors)
+	assert.Equal(suite.T(), "first", resp.Data["a"].(map[string]interface{})["title"])
+	assert.Equal(suite.T(), "PENDING", resp.Data["a"].(map[string]interface{})["status"])
+	assert.Equal(suite.T(), "second", resp.Data["b"].(map[string]interface{})["title"])
+	assert.Nil(suite.T(), resp.Data["missing"])
+	assert.Len(suite.T(), resp.Data["byIds"], 2)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *GraphQLHandlerTestSuite) TestTasksWithFilter() {
+	completed := model.TaskStatusCompleted
+	suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{Status: &completed, Search: "go"}, 5, 5).
+		Return(&model.TaskListResponse{
+			Total: 6,
+			Tasks: []model.Task{*testutils.TaskFixture(func(t *model.Task) { t.Status = completed })},
+		}, nil).Once()
+
+	resp := suite.do(`query($page: Int) {
+		tasks(filter: {status: COMPLETED, search: "go"}, page: $page, pageSize: 5) {
+			total page pageSize tasks { id status }
+		}
+	}`, map[string]interface{}{"page": 2})
+
+	assert.Empty(suite.T(), resp.Errors)
+	tasks := resp.Data["tasks"].(map[string]interface{})
+	assert.Equal(suite.T(), float64(6), tasks["total"])
+	assert.Equal(suite.T(), float64(2), tasks["page"])
+	assert.Len(suite.T(), tasks["tasks"], 1)
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *GraphQLHandlerTestSuite) TestTasksReportsNormalizedPage() {
+	suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 100, 0).
+		Return(&model.TaskListResponse{Tasks: []model.Task{}}, nil).Once()
+
+	resp := suite.do(`{ tasks(page: 0, pageSize: 1000) { page pageSize } }`, nil)
+
+	assert.Empty(suite.T(), resp.Errors)
+	tasks := resp.Data["tasks"].(map[string]interface{})
+	assert.Equal(suite.T(), float64(1), tasks["page"])
+	assert.Equal(suite.T(), float64(100), tasks["pageSize"])
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *GraphQLHandlerTestSuite) TestMutations() {
+	created := testutils.TaskFixture(func(t *model.Task) { t.ID = 7; t.Title = "new task" })
+	suite.mockRepo.On("Create", mock.Anything, int64(1), model.CreateTaskRequest{
+		Title:  "new task",
+		Status: model.TaskStatusPending,
+	}).Return(created, nil).Once()
+	suite.mockRepo.On("Delete", mock.Anything, int64(7), int64(1)).Return(nil).Once()
+
+	resp := suite.do(`mutation {
+		createTask(input: {title: "new task"}) { id title status }
+		deleteTask(id: "7")
+	}`, nil)
+
+	assert.Empty(suite.T(), resp.Errors)
+	assert.Equal(suite.T(), "7", resp.Data["createTask"].(map[string]interface{})["id"])
+	assert.Equal(suite.T(), true, resp.Data["deleteTask"])
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *GraphQLHandlerTestSuite) TestInvalidID() {
+	resp := suite.do(`{ task(id: "abc") { id } }`, nil)
+
+	require.Len(suite.T(), resp.Errors, 1)
+	assert.Equal(suite.T(), errInvalidID.Error(), resp.Errors[0].Message)
+}
+
+func TestGraphQLHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(GraphQLHandlerTestSuite))
+}
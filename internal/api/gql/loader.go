@@ -0,0 +1,119 @@
+package gql
+
+import (
+	"context"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"slices"
+	"sync"
+)
+
+type loaderKey struct{}
+
+// taskLoader откладывает загрузку задач по ID до разрешения thunk'ов и загружает
+// все накопленные ID одним запросом, чтобы избежать N+1 при нескольких task(id) в запросе
+type taskLoader struct {
+	ctx         context.Context
+	taskService *service.TaskService
+	userID      int64
+
+	mu      sync.Mutex
+	pending []int64
+	cache   map[int64]*model.Task
+	errs    map[int64]error
+}
+
+func newTaskLoader(ctx context.Context, taskService *service.TaskService, userID int64) *taskLoader {
+	return &taskLoader{
+		ctx:         ctx,
+		taskService: taskService,
+		userID:      userID,
+		cache:       make(map[int64]*model.Task),
+		errs:        make(map[int64]error),
+	}
+}
+
+func withLoader(ctx context.Context, loader *taskLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+func loaderFromContext(ctx context.Context) *taskLoader {
+	return ctx.Value(loaderKey{}).(*taskLoader)
+}
+
+// Load ставит ID в очередь и возвращает thunk, который graphql-go вызовет после обхода текущего уровня
+func (l *taskLoader) Load(id int64) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.cache[id]; !ok && l.errs[id] == nil && !l.isPending(id) {
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.flush()
+
+		if err := l.errs[id]; err != nil {
+			return nil, err
+		}
+		task := l.cache[id]
+		if task == nil {
+			return nil, nil
+		}
+		return task, nil
+	}
+}
+
+// Prime кладет уже загруженную задачу в кэш, например после мутации или выборки списка
+func (l *taskLoader) Prime(task *model.Task) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cache[task.ID] = task
+	delete(l.errs, task.ID)
+}
+
+func (l *taskLoader) Clear(id int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cache[id] = nil
+}
+
+func (l *taskLoader) isPending(id int64) bool {
+	for _, pendingID := range l.pending {
+		if pendingID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// flush загружает все ожидающие ID; вызывается под мьютексом
+func (l *taskLoader) flush() {
+	if len(l.pending) == 0 {
+		return
+	}
+
+	// graphql-go обходит поля в произвольном порядке, сортировка делает запрос детерминированным
+	ids := l.pending
+	l.pending = nil
+	slices.Sort(ids)
+
+	tasks, err := l.taskService.GetTasksByIDs(l.ctx, ids, l.userID)
+	if err != nil {
+		for _, id := range ids {
+			l.errs[id] = errTasksUnavailable
+		}
+		return
+	}
+
+	for _, id := range ids {
+		l.cache[id] = nil
+	}
+	for i := range tasks {
+		l.cache[tasks[i].ID] = &tasks[i]
+	}
+}
@@ -0,0 +1,363 @@
+package gql
+
+import (
+	"errors"
+	"github.com/graphql-go/graphql"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"strconv"
+	"time"
+)
+
+var (
+	errUnauthorized     = errors.New("unauthorized")
+	errInvalidID        = errors.New("некорректный ID задачи")
+	errTasksUnavailable = errors.New("не удалось получить задачи")
+	errForbidden        = errors.New("недостаточно прав")
+)
+
+type userIDKey struct{}
+
+// canWriteKey признак наличия у токена права tasks:write, без которого мутации запрещены
+type canWriteKey struct{}
+
+var taskStatusEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "TaskStatus",
+	Values: graphql.EnumValueConfigMap{
+		"PENDING":     &graphql.EnumValueConfig{Value: model.TaskStatusPending},
+		"IN_PROGRESS": &graphql.EnumValueConfig{Value: model.TaskStatusInProgress},
+		"COMPLETED":   &graphql.EnumValueConfig{Value: model.TaskStatusCompleted},
+	},
+})
+
+var taskType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Task",
+	Fields: graphql.Fields{
+		"id":          taskField(graphql.NewNonNull(graphql.ID), func(t *model.Task) interface{} { return t.ID }),
+		"title":       taskField(graphql.NewNonNull(graphql.String), func(t *model.Task) interface{} { return t.Title }),
+		"description": taskField(graphql.NewNonNull(graphql.String), func(t *model.Task) interface{} { return t.Description }),
+		"status":      taskField(graphql.NewNonNull(taskStatusEnum), func(t *model.Task) interface{} { return t.Status }),
+		"userId":      taskField(graphql.NewNonNull(graphql.ID), func(t *model.Task) interface{} { return t.UserID }),
+		"dueDate":     taskField(graphql.DateTime, func(t *model.Task) interface{} { return t.DueDate }),
+		"createdAt":   taskField(graphql.NewNonNull(graphql.DateTime), func(t *model.Task) interface{} { return t.CreatedAt }),
+		"updatedAt":   taskField(graphql.NewNonNull(graphql.DateTime), func(t *model.Task) interface{} { return t.UpdatedAt }),
+	},
+})
+
+var taskConnectionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TaskConnection",
+	Fields: graphql.Fields{
+		"total":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"page":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"pageSize": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"tasks":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taskType)))},
+	},
+})
+
+var taskFilterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TaskFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"status":    &graphql.InputObjectFieldConfig{Type: taskStatusEnum},
+		"search":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"dueBefore": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		"dueAfter":  &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+	},
+})
+
+var createTaskInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateTaskInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"status":      &graphql.InputObjectFieldConfig{Type: taskStatusEnum, DefaultValue: model.TaskStatusPending},
+		"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+	},
+})
+
+var updateTaskInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UpdateTaskInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"title":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"status":      &graphql.InputObjectFieldConfig{Type: taskStatusEnum},
+		"dueDate":     &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+	},
+})
+
+func taskField(fieldType graphql.Output, get func(t *model.Task) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			task, ok := p.Source.(*model.Task)
+			if !ok {
+				return nil, nil
+			}
+			return get(task), nil
+		},
+	}
+}
+
+type resolver struct {
+	taskService *service.TaskService
+	log         *zerolog.Logger
+}
+
+func newSchema(taskService *service.TaskService, log *zerolog.Logger) (graphql.Schema, error) {
+	r := &resolver{taskService: taskService, log: log}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"task": &graphql.Field{
+				Type: taskType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.task,
+			},
+			"tasksByIds": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(taskType)),
+				Args: graphql.FieldConfigArgument{
+					"ids": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID)))},
+				},
+				Resolve: r.tasksByIDs,
+			},
+			"tasks": &graphql.Field{
+				Type: graphql.NewNonNull(taskConnectionType),
+				Args: graphql.FieldConfigArgument{
+					"filter":   &graphql.ArgumentConfig{Type: taskFilterInput},
+					"page":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					"pageSize": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+				},
+				Resolve: r.tasks,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createTask": &graphql.Field{
+				Type: graphql.NewNonNull(taskType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createTaskInput)},
+				},
+				Resolve: r.createTask,
+			},
+			"updateTask": &graphql.Field{
+				Type: graphql.NewNonNull(taskType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateTaskInput)},
+				},
+				Resolve: r.updateTask,
+			},
+			"deleteTask": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.deleteTask,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+func (r *resolver) task(p graphql.ResolveParams) (interface{}, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	return loaderFromContext(p.Context).Load(id), nil
+}
+
+func (r *resolver) tasksByIDs(p graphql.ResolveParams) (interface{}, error) {
+	rawIDs, _ := p.Args["ids"].([]interface{})
+	loader := loaderFromContext(p.Context)
+
+	thunks := make([]func() (interface{}, error), 0, len(rawIDs))
+	for _, rawID := range rawIDs {
+		id, err := parseID(rawID)
+		if err != nil {
+			return nil, err
+		}
+		thunks = append(thunks, loader.Load(id))
+	}
+
+	return func() (interface{}, error) {
+		tasks := make([]interface{}, 0, len(thunks))
+		for _, thunk := range thunks {
+			task, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks, nil
+	}, nil
+}
+
+func (r *resolver) tasks(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := userIDFromContext(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter model.TaskFilter
+	if rawFilter, ok := p.Args["filter"].(map[string]interface{}); ok {
+		if status, ok := rawFilter["status"].(model.TaskStatus); ok {
+			filter.Status = &status
+		}
+		filter.Search, _ = rawFilter["search"].(string)
+		filter.DueBefore = timeArg(rawFilter["dueBefore"])
+		filter.DueAfter = timeArg(rawFilter["dueAfter"])
+	}
+
+	page, _ := p.Args["page"].(int)
+	pageSize, _ := p.Args["pageSize"].(int)
+	// в ответе те же значения, по которым сервис выбрал страницу
+	page, pageSize = service.NormalizePage(page, pageSize)
+
+	resp, err := r.taskService.GetTaskList(p.Context, userID, filter, page, pageSize)
+	if err != nil {
+		r.log.Error().Err(err).Msg("ошибка получения списка задач")
+		return nil, errors.New("не удалось получить список задач")
+	}
+
+	loader := loaderFromContext(p.Context)
+	tasks := make([]*model.Task, 0, len(resp.Tasks))
+	for i := range resp.Tasks {
+		loader.Prime(&resp.Tasks[i])
+		tasks = append(tasks, &resp.Tasks[i])
+	}
+
+	return map[string]interface{}{
+		"total":    resp.Total,
+		"page":     page,
+		"pageSize": pageSize,
+		"tasks":    tasks,
+	}, nil
+}
+
+func (r *resolver) createTask(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := writerIDFromContext(p)
+	if err != nil {
+		return nil, err
+	}
+
+	input, _ := p.Args["input"].(map[string]interface{})
+	req := model.CreateTaskRequest{
+		DueDate: timeArg(input["dueDate"]),
+	}
+	req.Title, _ = input["title"].(string)
+	req.Description, _ = input["description"].(string)
+	req.Status, _ = input["status"].(model.TaskStatus)
+
+	task, err := r.taskService.CreateTask(p.Context, userID, req)
+	if err != nil {
+		r.log.Error().Err(err).Msg("ошибка создания задачи")
+		return nil, errors.New("не удалось создать задачу")
+	}
+
+	loaderFromContext(p.Context).Prime(task)
+	return task, nil
+}
+
+func (r *resolver) updateTask(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := writerIDFromContext(p)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	input, _ := p.Args["input"].(map[string]interface{})
+	var req model.UpdateTaskRequest
+	if title, ok := input["title"].(string); ok {
+		req.Title = &title
+	}
+	if description, ok := input["description"].(string); ok {
+		req.Description = &description
+	}
+	if status, ok := input["status"].(model.TaskStatus); ok {
+		req.Status = &status
+	}
+	req.DueDate = timeArg(input["dueDate"])
+
+	task, err := r.taskService.UpdateTask(p.Context, id, userID, req)
+	if err != nil {
+		r.log.Error().Err(err).Int64("id", id).Msg("ошибка обновления задачи")
+		return nil, errors.New("задача не найдена или не удалось обновить")
+	}
+
+	loaderFromContext(p.Context).Prime(task)
+	return task, nil
+}
+
+func (r *resolver) deleteTask(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := writerIDFromContext(p)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.taskService.DeleteTask(p.Context, id, userID); err != nil {
+		r.log.Error().Err(err).Int64("id", id).Msg("ошибка удаления задачи")
+		return nil, errors.New("задача не найдена или не удалось удалить")
+	}
+
+	loaderFromContext(p.Context).Clear(id)
+	return true, nil
+}
+
+// writerIDFromContext возвращает пользователя для мутаций, проверяя право tasks:write
+func writerIDFromContext(p graphql.ResolveParams) (int64, error) {
+	if canWrite, _ := p.Context.Value(canWriteKey{}).(bool); !canWrite {
+		return 0, errForbidden
+	}
+	return userIDFromContext(p)
+}
+
+func userIDFromContext(p graphql.ResolveParams) (int64, error) {
+	userID, ok := p.Context.Value(userIDKey{}).(int64)
+	if !ok {
+		return 0, errUnauthorized
+	}
+	return userID, nil
+}
+
+func parseID(raw interface{}) (int64, error) {
+	s, ok := raw.(string)
+	if !ok {
+		return 0, errInvalidID
+	}
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errInvalidID
+	}
+	return id, nil
+}
+
+func timeArg(raw interface{}) *time.Time {
+	switch t := raw.(type) {
+	case time.Time:
+		return &t
+	case *time.Time:
+		return t
+	}
+	return nil
+}
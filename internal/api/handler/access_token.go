@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strconv"
+)
+
+type AccessTokenHandler struct {
+	accessTokenService *service.AccessTokenService
+	log                *zerolog.Logger
+}
+
+func NewAccessTokenHandler(accessTokenService *service.AccessTokenService, log *zerolog.Logger) *AccessTokenHandler {
+	return &AccessTokenHandler{
+		accessTokenService: accessTokenService,
+		log:                log,
+	}
+}
+
+func (h *AccessTokenHandler) Register(router *gin.RouterGroup) {
+	tokens := router.Group("/tokens", h.rejectAccessToken)
+	{
+		tokens.POST("", h.Create)
+		tokens.GET("", h.List)
+		tokens.DELETE("/:id", h.Revoke)
+	}
+}
+
+// rejectAccessToken запрещает управлять токенами по персональному токену: иначе утекший токен
+// с ограниченными правами и сроком мог бы выпустить себе бессрочную замену и отозвать остальные
+func (h *AccessTokenHandler) rejectAccessToken(c *gin.Context) {
+	if _, accessToken := c.Get("access_token_id"); accessToken {
+		c.AbortWithStatusJSON(http.StatusForbidden, model.ErrorResponse{
+			Code:      http.StatusForbidden,
+			Message:   "токенами можно управлять только в сессии, открытой входом пользователя",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+	c.Next()
+}
+
+// Create выпускает персональный токен; права токена не могут превышать права текущей сессии
+func (h *AccessTokenHandler) Create(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+
+	if _, impersonated := c.Get("impersonator_id"); impersonated {
+		c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Code:      http.StatusForbidden,
+			Message:   "нельзя выпускать токены от имени другого пользователя",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	var req model.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error().Err(err).Msg("ошибка разбора JSON")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные данные запроса",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	token, err := h.accessTokenService.CreateToken(c.Request.Context(), userID, c.GetStringSlice("scopes"), req)
+	if err != nil {
+		if errors.Is(err, service.ErrScopeNotAllowed) || errors.Is(err, service.ErrInvalidExpiration) {
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Message:   err.Error(),
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error().Err(err).Msg("ошибка создания токена доступа")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось создать токен доступа",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *AccessTokenHandler) List(c *gin.Context) {
+	tokens, err := h.accessTokenService.ListTokens(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка получения токенов доступа")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить токены доступа",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AccessTokenHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректный ID токена",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	if err := h.accessTokenService.RevokeToken(c.Request.Context(), id, c.GetInt64("user_id")); err != nil {
+		if errors.Is(err, service.ErrAccessTokenNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Code:      http.StatusNotFound,
+				Message:   "токен доступа не найден",
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error().Err(err).Msg("ошибка отзыва токена доступа")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось отозвать токен доступа",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,46 @@
+package handler
+
+// Swagger аннотации для AccessToken хендлеров
+
+// CreateAccessToken выпускает персональный токен
+// @Summary Создать персональный токен доступа
+// @Description Выпускает долгоживущий токен для автоматизации. Токен показывается только в этом ответе и передается в Authorization: Bearer или X-API-Key
+// @Tags Tokens
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param token body model.CreateAccessTokenRequest true "Название, права и срок действия"
+// @Success 201 {object} model.CreateAccessTokenResponse "Созданный токен"
+// @Failure 400 {object} model.ErrorResponseSwagger "Некорректные данные или права"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Выпуск токена запрещен"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/tokens [post]
+func (h *AccessTokenHandler) CreateAccessTokenDoc() {}
+
+// ListAccessTokens список токенов
+// @Summary Получить персональные токены
+// @Description Возвращает действующие токены пользователя без секретной части
+// @Tags Tokens
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} model.AccessToken "Токены"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Запрос по персональному токену"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/tokens [get]
+func (h *AccessTokenHandler) ListAccessTokensDoc() {}
+
+// RevokeAccessToken отзывает токен
+// @Summary Отозвать персональный токен
+// @Tags Tokens
+// @Security BearerAuth
+// @Param id path int true "ID токена" minimum(1)
+// @Success 204 "Токен отозван"
+// @Failure 400 {object} model.ErrorResponseSwagger "Некорректный ID"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Запрос по персональному токену"
+// @Failure 404 {object} model.ErrorResponseSwagger "Токен не найден"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/tokens/{id} [delete]
+func (h *AccessTokenHandler) RevokeAccessTokenDoc() {}
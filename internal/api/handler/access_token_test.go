@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type AccessTokenHandlerTestSuite struct {
+	suite.Suite
+	mockRepo *mocks.MockAccessTokenRepository
+	jwt      *middleware.JWTMiddleware
+	router   *gin.Engine
+}
+
+func (suite *AccessTokenHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *AccessTokenHandlerTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.MockAccessTokenRepository)
+	logger := zerolog.Nop()
+	accessTokenService := service.NewAccessTokenService(suite.mockRepo, &logger)
+
+	suite.jwt = middleware.NewJWTMiddleware(config.AuthConfig{
+		JWTSecret:        "test-secret",
+		TokenExpireDelta: time.Hour,
+	}, &logger).WithAccessTokens(accessTokenService)
+
+	suite.router = gin.New()
+	api := suite.router.Group("/api/v1", suite.jwt.AuthRequired())
+	NewAccessTokenHandler(accessTokenService, &logger).Register(api)
+	api.GET("/whoami", middleware.RequireScope(model.ScopeTasksRead), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt64("user_id"), "scopes": c.GetStringSlice("scopes")})
+	})
+	api.POST("/write", middleware.RequireScope(model.ScopeTasksWrite), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+}
+
+func (suite *AccessTokenHandlerTestSuite) jwtRequest(method, path string, body interface{}) *httptest.ResponseRecorder {
+	token, err := suite.jwt.GenerateToken(1, model.RoleUser)
+	require.NoError(suite.T(), err)
+
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *AccessTokenHandlerTestSuite) TestCreateToken() {
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "read_only_token",
+			requestBody: model.CreateAccessTokenRequest{
+				Name:   "ci",
+				Scopes: []string{model.ScopeTasksRead},
+			},
+			setupMock: func() {
+				suite.mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(t model.AccessToken) bool {
+					return t.UserID == 1 && t.Name == "ci" && len(t.Scopes) == 1
+				}), mock.AnythingOfType("string")).
+					Return(&model.AccessToken{ID: 5, Name: "ci", Scopes: []string{model.ScopeTasksRead}}, nil).Once()
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "scope_above_session",
+			requestBody: model.CreateAccessTokenRequest{
+				Name:   "ci",
+				Scopes: []string{model.ScopeAdmin},
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing_scopes",
+			requestBody:    map[string]interface{}{"name": "ci"},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			tt.setupMock()
+
+			w := suite.jwtRequest(http.MethodPost, "/api/v1/tokens", tt.requestBody)
+
+			require.Equal(suite.T(), tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var resp model.CreateAccessTokenResponse
+				require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.True(suite.T(), strings.HasPrefix(resp.Token, model.AccessTokenPrefix))
+				assert.Equal(suite.T(), int64(5), resp.ID)
+			}
+			suite.mockRepo.AssertExpectations(suite.T())
+		})
+	}
+}
+
+func (suite *AccessTokenHandlerTestSuite) TestAuthenticateWithToken() {
+	const secret = model.AccessTokenPrefix + "secret"
+	expired := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name           string
+		header         string
+		path           string
+		method         string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name:   "api_key_header",
+			header: "X-API-Key",
+			path:   "/api/v1/whoami",
+			method: http.MethodGet,
+			setupMock: func() {
+				suite.mockRepo.On("GetByHash", mock.Anything, mock.AnythingOfType("string")).
+					Return(&model.AccessToken{ID: 5, UserID: 7, Role: model.RoleUser, Scopes: []string{model.ScopeTasksRead}}, nil).Once()
+				suite.mockRepo.On("TouchLastUsed", mock.Anything, int64(5), mock.Anything).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "bearer_header",
+			header: "Authorization",
+			path:   "/api/v1/whoami",
+			method: http.MethodGet,
+			setupMock: func() {
+				recent := time.Now()
+				suite.mockRepo.On("GetByHash", mock.Anything, mock.AnythingOfType("string")).
+					Return(&model.AccessToken{ID: 5, UserID: 7, Role: model.RoleUser, Scopes: []string{model.ScopeTasksRead}, LastUsedAt: &recent}, nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "scope_missing",
+			header: "X-API-Key",
+			path:   "/api/v1/write",
+			method: http.MethodPost,
+			setupMock: func() {
+				suite.mockRepo.On("GetByHash", mock.Anything, mock.AnythingOfType("string")).
+					Return(&model.AccessToken{ID: 5, UserID: 7, Role: model.RoleUser, Scopes: []string{model.ScopeTasksRead}}, nil).Once()
+				suite.mockRepo.On("TouchLastUsed", mock.Anything, int64(5), mock.Anything).Return(nil).Once()
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "expired",
+			header: "X-API-Key",
+			path:   "/api/v1/whoami",
+			method: http.MethodGet,
+			setupMock: func() {
+				suite.mockRepo.On("GetByHash", mock.Anything, mock.AnythingOfType("string")).
+					Return(&model.AccessToken{ID: 5, UserID: 7, Scopes: []string{model.ScopeTasksRead}, ExpiresAt: &expired}, nil).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "revoked",
+			header: "X-API-Key",
+			path:   "/api/v1/whoami",
+			method: http.MethodGet,
+			setupMock: func() {
+				suite.mockRepo.On("GetByHash", mock.Anything, mock.AnythingOfType("string")).
+					Return(nil, repository.ErrNotFound).Once()
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			tt.setupMock()
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header == "Authorization" {
+				req.Header.Set("Authorization", "Bearer "+secret)
+			} else {
+				req.Header.Set(tt.header, secret)
+			}
+			w := httptest.NewRecorder()
+			suite.router.ServeHTTP(w, req)
+
+			assert.Equal(suite.T(), tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(suite.T(), w.Body.String(), `"user_id":7`)
+			}
+			suite.mockRepo.AssertExpectations(suite.T())
+		})
+	}
+}
+
+func (suite *AccessTokenHandlerTestSuite) TestAccessTokenCannotManageTokens() {
+	const secret = model.AccessTokenPrefix + "secret"
+	expiresAt := time.Now().Add(time.Hour)
+
+	requests := []struct {
+		method string
+		path   string
+		body   interface{}
+	}{
+		{method: http.MethodPost, path: "/api/v1/tokens", body: model.CreateAccessTokenRequest{Name: "forever", Scopes: []string{model.ScopeTasksRead}}},
+		{method: http.MethodGet, path: "/api/v1/tokens"},
+		{method: http.MethodDelete, path: "/api/v1/tokens/6"},
+	}
+
+	for _, r := range requests {
+		suite.Run(r.method, func() {
+			suite.SetupTest()
+			suite.mockRepo.On("GetByHash", mock.Anything, mock.AnythingOfType("string")).
+				Return(&model.AccessToken{ID: 5, UserID: 7, Role: model.RoleUser, Scopes: []string{model.ScopeTasksRead}, ExpiresAt: &expiresAt}, nil).Once()
+			suite.mockRepo.On("TouchLastUsed", mock.Anything, int64(5), mock.Anything).Return(nil).Once()
+
+			var data []byte
+			if r.body != nil {
+				data, _ = json.Marshal(r.body)
+			}
+			req := httptest.NewRequest(r.method, r.path, bytes.NewReader(data))
+			req.Header.Set("X-API-Key", secret)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			suite.router.ServeHTTP(w, req)
+
+			assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+			suite.mockRepo.AssertNotCalled(suite.T(), "Create", mock.Anything, mock.Anything, mock.Anything)
+			suite.mockRepo.AssertNotCalled(suite.T(), "Revoke", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAccessTokenHandlerSuite(t *testing.T) {
+	suite.Run(t, new(AccessTokenHandlerTestSuite))
+}
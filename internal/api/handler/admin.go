@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strconv"
+)
+
+type AdminHandler struct {
+	userService   *service.UserService
+	taskService   *service.TaskService
+	auditService  *service.AuditService
+	loginGuard    *service.LoginGuard
+	jwtMiddleware *middleware.JWTMiddleware
+	log           *zerolog.Logger
+}
+
+func NewAdminHandler(
+	userService *service.UserService,
+	taskService *service.TaskService,
+	auditService *service.AuditService,
+	loginGuard *service.LoginGuard,
+	jwtMiddleware *middleware.JWTMiddleware,
+	log *zerolog.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		userService:   userService,
+		taskService:   taskService,
+		auditService:  auditService,
+		loginGuard:    loginGuard,
+		jwtMiddleware: jwtMiddleware,
+		log:           log,
+	}
+}
+
+func (h *AdminHandler) Register(router *gin.RouterGroup) {
+	admin := router.Group("/admin", middleware.RequireRole(model.RoleAdmin), middleware.RequireScope(model.ScopeAdmin))
+	{
+		admin.GET("/users", h.ListUsers)
+		admin.GET("/users/:id/tasks", h.ListUserTasks)
+		admin.POST("/users/:id/impersonate", h.Impersonate)
+		admin.GET("/audit", h.ListAudit)
+		admin.DELETE("/lockouts/:username", h.Unlock)
+	}
+}
+
+func parsePage(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10
+	}
+
+	return page, pageSize
+}
+
+// parseTargetUser читает ID пользователя из пути и проверяет, что он существует; при ошибке ответ уже отправлен
+func (h *AdminHandler) parseTargetUser(c *gin.Context) (*model.User, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректный ID пользователя",
+			RequestID: c.GetString("request_id"),
+		})
+		return nil, false
+	}
+
+	user, err := h.userService.GetUser(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Code:      http.StatusNotFound,
+				Message:   "пользователь не найден",
+				RequestID: c.GetString("request_id"),
+			})
+			return nil, false
+		}
+		h.log.Error().Err(err).Int64("user_id", id).Msg("ошибка получения пользователя")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить пользователя",
+			RequestID: c.GetString("request_id"),
+		})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// audit записывает действие в журнал; если запись не удалась, отвечает 500 и возвращает false
+func (h *AdminHandler) audit(c *gin.Context, action model.AuditAction, targetUserID *int64, details string) bool {
+	err := h.auditService.Record(c.Request.Context(), model.AuditEntry{
+		ActorID:      c.GetInt64("user_id"),
+		Action:       action,
+		TargetUserID: targetUserID,
+		Details:      details,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось записать действие в журнал аудита",
+			RequestID: c.GetString("request_id"),
+		})
+		return false
+	}
+	return true
+}
+
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, pageSize := parsePage(c)
+
+	users, err := h.userService.ListUsers(c.Request.Context(), page, pageSize)
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка получения списка пользователей")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить список пользователей",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// ListUserTasks возвращает задачи любого пользователя с теми же фильтрами, что и список задач
+func (h *AdminHandler) ListUserTasks(c *gin.Context) {
+	user, ok := h.parseTargetUser(c)
+	if !ok {
+		return
+	}
+
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные параметры фильтра",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	if !h.audit(c, model.AuditActionViewTasks, &user.ID, "") {
+		return
+	}
+
+	page, pageSize := parsePage(c)
+	tasks, err := h.taskService.GetTaskList(c.Request.Context(), user.ID, filter, page, pageSize)
+	if err != nil {
+		h.log.Error().Err(err).Int64("user_id", user.ID).Msg("ошибка получения списка задач")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить список задач",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// Impersonate выдает администратору короткоживущий токен пользователя. Запросы с этим токеном
+// помечаются в логе полем impersonator_id
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	user, ok := h.parseTargetUser(c)
+	if !ok {
+		return
+	}
+
+	if user.Role == model.RoleAdmin {
+		c.JSON(http.StatusForbidden, model.ErrorResponse{
+			Code:      http.StatusForbidden,
+			Message:   "нельзя действовать от имени администратора",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	if !h.audit(c, model.AuditActionImpersonate, &user.ID, "") {
+		return
+	}
+
+	adminID := c.GetInt64("user_id")
+	token, expiresAt, err := h.jwtMiddleware.GenerateImpersonationToken(user.ID, user.Role, adminID)
+	if err != nil {
+		h.log.Error().Err(err).Int64("user_id", user.ID).Msg("ошибка генерации токена")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось сгенерировать токен",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.ImpersonateResponse{
+		Token:     token,
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (h *AdminHandler) ListAudit(c *gin.Context) {
+	page, pageSize := parsePage(c)
+
+	entries, err := h.auditService.List(c.Request.Context(), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить журнал аудита",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// Unlock снимает блокировку входа с пользователя; параметр ip дополнительно снимает блокировку с адреса
+func (h *AdminHandler) Unlock(c *gin.Context) {
+	username := c.Param("username")
+	ip := c.Query("ip")
+
+	if !h.loginGuard.HasFailures(username, ip) {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Code:      http.StatusNotFound,
+			Message:   "блокировка не найдена",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	// запись в журнал делается до снятия блокировки, как и для остальных действий администратора
+	if !h.audit(c, model.AuditActionUnlockLogin, nil, fmt.Sprintf("username=%s ip=%s", username, ip)) {
+		return
+	}
+
+	h.loginGuard.Unlock(username, ip)
+	c.Status(http.StatusNoContent)
+}
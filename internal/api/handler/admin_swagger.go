@@ -0,0 +1,82 @@
+package handler
+
+// Swagger аннотации для Admin хендлеров
+
+// UnlockLogin снимает блокировку входа
+// @Summary Снять блокировку входа
+// @Description Сбрасывает счетчик неудачных попыток входа пользователя и, если указан ip, адреса клиента
+// @Tags Admin
+// @Security BearerAuth
+// @Param username path string true "Имя пользователя"
+// @Param ip query string false "IP адрес, с которого также нужно снять блокировку"
+// @Success 204 "Блокировка снята"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Недостаточно прав"
+// @Failure 404 {object} model.ErrorResponseSwagger "Блокировка не найдена"
+// @Router /api/v1/admin/lockouts/{username} [delete]
+func (h *AdminHandler) UnlockLoginDoc() {}
+
+// ListUsers список пользователей
+// @Summary Получить список пользователей
+// @Description Возвращает пользователей сервиса с пагинацией
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Номер страницы" minimum(1) default(1)
+// @Param page_size query int false "Количество элементов на странице" minimum(1) maximum(100) default(10)
+// @Success 200 {object} model.UserListResponse "Список пользователей"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Недостаточно прав"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/admin/users [get]
+func (h *AdminHandler) ListUsersDoc() {}
+
+// ListUserTasks задачи пользователя
+// @Summary Получить задачи пользователя
+// @Description Возвращает задачи любого пользователя; просмотр записывается в журнал аудита
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя" minimum(1)
+// @Param page query int false "Номер страницы" minimum(1) default(1)
+// @Param page_size query int false "Количество элементов на странице" minimum(1) maximum(100) default(10)
+// @Param status query string false "Фильтр по статусу" Enums(pending, in_progress, completed)
+// @Param search query string false "Поиск по заголовку и описанию"
+// @Success 200 {object} model.TaskListResponseSwagger "Список задач"
+// @Failure 400 {object} model.ErrorResponseSwagger "Некорректные параметры запроса"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Недостаточно прав"
+// @Failure 404 {object} model.ErrorResponseSwagger "Пользователь не найден"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/admin/users/{id}/tasks [get]
+func (h *AdminHandler) ListUserTasksDoc() {}
+
+// Impersonate вход от имени пользователя
+// @Summary Войти от имени пользователя
+// @Description Выдает короткоживущий токен пользователя; действие и все запросы с токеном записываются в аудит
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID пользователя" minimum(1)
+// @Success 200 {object} model.ImpersonateResponse "Токен пользователя"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Недостаточно прав"
+// @Failure 404 {object} model.ErrorResponseSwagger "Пользователь не найден"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+func (h *AdminHandler) ImpersonateDoc() {}
+
+// ListAudit журнал аудита
+// @Summary Получить журнал аудита
+// @Description Возвращает действия администраторов, новые записи первыми
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Номер страницы" minimum(1) default(1)
+// @Param page_size query int false "Количество элементов на странице" minimum(1) maximum(100) default(10)
+// @Success 200 {object} model.AuditListResponse "Журнал аудита"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Недостаточно прав"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/admin/audit [get]
+func (h *AdminHandler) ListAuditDoc() {}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/kkboranbay/task-service/internal/testutils"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type AdminHandlerTestSuite struct {
+	suite.Suite
+	mockUsers  *mocks.MockUserRepository
+	mockAudit  *mocks.MockAuditRepository
+	mockTasks  *mocks.MockTaskRepository
+	loginGuard *service.LoginGuard
+	jwt        *middleware.JWTMiddleware
+	router     *gin.Engine
+}
+
+func (suite *AdminHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *AdminHandlerTestSuite) SetupTest() {
+	suite.mockUsers = new(mocks.MockUserRepository)
+	suite.mockAudit = new(mocks.MockAuditRepository)
+	suite.mockTasks = new(mocks.MockTaskRepository)
+	logger := zerolog.Nop()
+
+	suite.jwt = middleware.NewJWTMiddleware(config.AuthConfig{
+		JWTSecret:        "test-secret",
+		TokenExpireDelta: time.Hour,
+		ImpersonationTTL: time.Minute,
+	}, &logger)
+	suite.loginGuard = service.NewLoginGuard(config.LoginGuardConfig{
+		MaxFailures:      1,
+		MaxFailuresPerIP: 100,
+		FailureWindow:    time.Minute,
+		LockoutDuration:  time.Minute,
+	}, &logger)
+
+	adminHandler := NewAdminHandler(
+		service.NewUserService(suite.mockUsers, &logger),
+		service.NewTaskService(suite.mockTasks, &logger),
+		service.NewAuditService(suite.mockAudit, &logger),
+		suite.loginGuard,
+		suite.jwt,
+		&logger,
+	)
+
+	suite.router = gin.New()
+	api := suite.router.Group("/api/v1", suite.jwt.AuthRequired())
+	adminHandler.Register(api)
+}
+
+func (suite *AdminHandlerTestSuite) request(method, path string, role model.Role) *httptest.ResponseRecorder {
+	token, err := suite.jwt.GenerateToken(1, role)
+	require.NoError(suite.T(), err)
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *AdminHandlerTestSuite) TestRequiresAdminRole() {
+	w := suite.request(http.MethodGet, "/api/v1/admin/users", model.RoleUser)
+
+	assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	suite.mockUsers.AssertNotCalled(suite.T(), "List", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AdminHandlerTestSuite) TestListUsers() {
+	suite.mockUsers.On("List", mock.Anything, 20, 20).Return(&model.UserListResponse{
+		Total: 21,
+		Users: []model.User{{ID: 21, Username: "bob", PasswordHash: "secret", Role: model.RoleUser}},
+	}, nil).Once()
+
+	w := suite.request(http.MethodGet, "/api/v1/admin/users?page=2&page_size=20", model.RoleAdmin)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	assert.Contains(suite.T(), w.Body.String(), `"username":"bob"`)
+	assert.NotContains(suite.T(), w.Body.String(), "secret")
+}
+
+func (suite *AdminHandlerTestSuite) TestListUserTasks() {
+	targetID := int64(7)
+	suite.mockUsers.On("GetByID", mock.Anything, targetID).
+		Return(&model.User{ID: targetID, Username: "bob", Role: model.RoleUser}, nil).Once()
+	suite.mockAudit.On("Create", mock.Anything, model.AuditEntry{
+		ActorID:      1,
+		Action:       model.AuditActionViewTasks,
+		TargetUserID: &targetID,
+	}).Return(nil).Once()
+	suite.mockTasks.On("List", mock.Anything, targetID, model.TaskFilter{}, 10, 0).Return(&model.TaskListResponse{
+		Total: 1,
+		Tasks: []model.Task{*testutils.TaskFixture(func(t *model.Task) { t.UserID = targetID })},
+	}, nil).Once()
+
+	w := suite.request(http.MethodGet, "/api/v1/admin/users/7/tasks", model.RoleAdmin)
+
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	suite.mockAudit.AssertExpectations(suite.T())
+	suite.mockTasks.AssertExpectations(suite.T())
+}
+
+func (suite *AdminHandlerTestSuite) TestImpersonate() {
+	tests := []struct {
+		name           string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "successful_impersonation",
+			setupMock: func() {
+				suite.mockUsers.On("GetByID", mock.Anything, int64(7)).
+					Return(&model.User{ID: 7, Username: "bob", Role: model.RoleUser}, nil).Once()
+				suite.mockAudit.On("Create", mock.Anything, mock.MatchedBy(func(e model.AuditEntry) bool {
+					return e.ActorID == 1 && e.Action == model.AuditActionImpersonate && *e.TargetUserID == 7
+				})).Return(nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "admin_target",
+			setupMock: func() {
+				suite.mockUsers.On("GetByID", mock.Anything, int64(7)).
+					Return(&model.User{ID: 7, Username: "root", Role: model.RoleAdmin}, nil).Once()
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "user_not_found",
+			setupMock: func() {
+				suite.mockUsers.On("GetByID", mock.Anything, int64(7)).Return(nil, repository.ErrNotFound).Once()
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "audit_failure",
+			setupMock: func() {
+				suite.mockUsers.On("GetByID", mock.Anything, int64(7)).
+					Return(&model.User{ID: 7, Username: "bob", Role: model.RoleUser}, nil).Once()
+				suite.mockAudit.On("Create", mock.Anything, mock.Anything).Return(errors.New("database error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			tt.setupMock()
+
+			w := suite.request(http.MethodPost, "/api/v1/admin/users/7/impersonate", model.RoleAdmin)
+
+			require.Equal(suite.T(), tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp model.ImpersonateResponse
+			require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+
+			claims, err := suite.jwt.ParseToken(resp.Token)
+			require.NoError(suite.T(), err)
+			assert.Equal(suite.T(), int64(7), claims.UserID)
+			assert.Equal(suite.T(), int64(1), claims.ImpersonatorID)
+			assert.False(suite.T(), claims.HasScope(model.ScopeAdmin))
+		})
+	}
+}
+
+func (suite *AdminHandlerTestSuite) TestUnlock() {
+	tests := []struct {
+		name           string
+		locked         bool
+		auditErr       error
+		expectedStatus int
+		stillLocked    bool
+	}{
+		{name: "unlocks", locked: true, expectedStatus: http.StatusNoContent},
+		{name: "not_locked", expectedStatus: http.StatusNotFound},
+		// без записи в журнале блокировка остается
+		{name: "audit_failure", locked: true, auditErr: errors.New("database error"), expectedStatus: http.StatusInternalServerError, stillLocked: true},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			if tt.locked {
+				suite.loginGuard.RecordFailure(context.Background(), "bob", "10.0.0.1")
+				suite.mockAudit.On("Create", mock.Anything, mock.MatchedBy(func(e model.AuditEntry) bool {
+					return e.ActorID == 1 && e.Action == model.AuditActionUnlockLogin
+				})).Return(tt.auditErr).Once()
+			}
+
+			w := suite.request(http.MethodDelete, "/api/v1/admin/lockouts/bob", model.RoleAdmin)
+
+			require.Equal(suite.T(), tt.expectedStatus, w.Code)
+			assert.Equal(suite.T(), tt.stillLocked, suite.loginGuard.HasFailures("bob", ""))
+			suite.mockAudit.AssertExpectations(suite.T())
+		})
+	}
+}
+
+func TestAdminHandlerSuite(t *testing.T) {
+	suite.Run(t, new(AdminHandlerTestSuite))
+}
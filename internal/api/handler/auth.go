@@ -1,30 +1,57 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/kkboranbay/task-service/internal/api/middleware"
 	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
 	"github.com/rs/zerolog"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type AuthHandler struct {
-	jwtMiddleware *middleware.JWTMiddleware
-	log           *zerolog.Logger
+	jwtMiddleware    *middleware.JWTMiddleware
+	userService      *service.UserService
+	twoFactorService *service.TwoFactorService
+	loginGuard       *service.LoginGuard
+	log              *zerolog.Logger
 }
 
-func NewAuthHandler(jwtMiddleware *middleware.JWTMiddleware, log *zerolog.Logger) *AuthHandler {
+func NewAuthHandler(
+	jwtMiddleware *middleware.JWTMiddleware,
+	userService *service.UserService,
+	twoFactorService *service.TwoFactorService,
+	loginGuard *service.LoginGuard,
+	log *zerolog.Logger,
+) *AuthHandler {
 	return &AuthHandler{
-		jwtMiddleware: jwtMiddleware,
-		log:           log,
+		jwtMiddleware:    jwtMiddleware,
+		userService:      userService,
+		twoFactorService: twoFactorService,
+		loginGuard:       loginGuard,
+		log:              log,
 	}
 }
 
-func (h *AuthHandler) Register(router *gin.Engine) {
+// Register подключает маршруты авторизации; loginMiddleware выполняется перед проверкой учетных данных
+func (h *AuthHandler) Register(router *gin.Engine, loginMiddleware ...gin.HandlerFunc) {
 	auth := router.Group("/auth")
 	{
-		auth.POST("/login", h.Login)
+		auth.POST("/login", append(loginMiddleware, h.Login)...)
+		auth.POST("/2fa/enroll", append(loginMiddleware, h.EnrollTwoFactor)...)
+		auth.POST("/2fa/verify", append(loginMiddleware, h.VerifyTwoFactor)...)
 	}
+
+	router.GET("/.well-known/jwks.json", h.JWKS)
+}
+
+// JWKS публикует открытые ключи, которыми другие сервисы могут проверять наши токены
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.jwtMiddleware.JWKS())
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -32,28 +59,67 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.log.Error().Err(err).Msg("ошибка разбора JSON")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "некорректные данные запроса",
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные данные запроса",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
 
-	if req.Username != "admin" || req.Password != "admin" {
-		h.log.Warn().Str("username", req.Username).Msg("неверные учетные данные")
-		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-			Code:    http.StatusUnauthorized,
-			Message: "неверные учетные данные",
+	ip := c.ClientIP()
+	if h.loginBlocked(c, req.Username, ip) {
+		return
+	}
+	defer h.loginGuard.Release(req.Username, ip)
+
+	user, err := h.userService.Authenticate(c.Request.Context(), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			h.loginGuard.RecordFailure(c.Request.Context(), req.Username, ip)
+			h.log.Warn().Str("username", req.Username).Str("ip", ip).Msg("неверные учетные данные")
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Message:   "неверные учетные данные",
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error().Err(err).Str("username", req.Username).Msg("ошибка проверки учетных данных")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось выполнить вход",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
 
-	userID := int64(1)
-	token, err := h.jwtMiddleware.GenerateToken(userID)
+	// счетчик неудач сбрасывается только после второго фактора, иначе верный пароль
+	// позволял бы бесконечно перебирать коды
+	required := h.twoFactorService.Required()
+	enabled, err := h.twoFactorService.Enabled(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось выполнить вход",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+	if enabled || required {
+		twoFactorChallenge(c, h.jwtMiddleware, h.log, user, !enabled)
+		return
+	}
+
+	h.loginGuard.RecordSuccess(req.Username)
+
+	userID := user.ID
+	token, err := h.jwtMiddleware.GenerateToken(userID, user.Role)
 	if err != nil {
 		h.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка генерации токена")
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "не удалось сгенерировать токен",
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось сгенерировать токен",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -65,3 +131,192 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		UserID: userID,
 	})
 }
+
+// twoFactorChallenge выдает токен второго шага входа вместо токена доступа. Используется
+// и при входе по паролю, и при входе через OIDC: второй шаг один — /auth/2fa/verify
+func twoFactorChallenge(c *gin.Context, jwtMiddleware *middleware.JWTMiddleware, log *zerolog.Logger, user *model.User, enrollmentRequired bool) {
+	challengeToken, expiresAt, err := jwtMiddleware.GenerateChallengeToken(user.ID, user.Role)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", user.ID).Msg("ошибка генерации токена")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось сгенерировать токен",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	log.Info().Int64("user_id", user.ID).Bool("enrollment_required", enrollmentRequired).Msg("требуется второй фактор")
+
+	c.JSON(http.StatusOK, model.TwoFactorChallengeResponse{
+		TwoFactorRequired:  true,
+		EnrollmentRequired: enrollmentRequired,
+		ChallengeToken:     challengeToken,
+		ExpiresAt:          expiresAt,
+	})
+}
+
+// EnrollTwoFactor начинает обязательную настройку 2FA на втором шаге входа
+func (h *AuthHandler) EnrollTwoFactor(c *gin.Context) {
+	var req model.TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error().Err(err).Msg("ошибка разбора JSON")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные данные запроса",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	user, ok := h.challengeUser(c, req.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(c.Request.Context(), user)
+	if err != nil {
+		if errors.Is(err, service.ErrTwoFactorEnabled) {
+			c.JSON(http.StatusConflict, model.ErrorResponse{
+				Code:      http.StatusConflict,
+				Message:   err.Error(),
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось начать настройку 2FA",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// VerifyTwoFactor проверяет код второго фактора и выдает токен доступа
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req model.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error().Err(err).Msg("ошибка разбора JSON")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные данные запроса",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	user, ok := h.challengeUser(c, req.ChallengeToken)
+	if !ok {
+		return
+	}
+
+	ip := c.ClientIP()
+	if h.loginBlocked(c, user.Username, ip) {
+		return
+	}
+	defer h.loginGuard.Release(user.Username, ip)
+
+	recoveryCodes, err := h.twoFactorService.CompleteChallenge(c.Request.Context(), user.ID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTwoFactorCode):
+			h.loginGuard.RecordFailure(c.Request.Context(), user.Username, ip)
+			h.log.Warn().Int64("user_id", user.ID).Str("ip", ip).Msg("неверный код второго фактора")
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Message:   err.Error(),
+				RequestID: c.GetString("request_id"),
+			})
+		case errors.Is(err, service.ErrTwoFactorNotEnrolled):
+			c.JSON(http.StatusBadRequest, model.ErrorResponse{
+				Code:      http.StatusBadRequest,
+				Message:   "сначала начните настройку 2FA через /auth/2fa/enroll",
+				RequestID: c.GetString("request_id"),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Message:   "не удалось выполнить вход",
+				RequestID: c.GetString("request_id"),
+			})
+		}
+		return
+	}
+
+	h.loginGuard.RecordSuccess(user.Username)
+
+	token, err := h.jwtMiddleware.GenerateToken(user.ID, user.Role)
+	if err != nil {
+		h.log.Error().Err(err).Int64("user_id", user.ID).Msg("ошибка генерации токена")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось сгенерировать токен",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	h.log.Info().Str("username", user.Username).Int64("user_id", user.ID).Msg("успешная авторизация с 2FA")
+
+	c.JSON(http.StatusOK, model.LoginResponse{
+		Token:         token,
+		UserID:        user.ID,
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// challengeUser проверяет токен второго шага входа и возвращает его пользователя
+func (h *AuthHandler) challengeUser(c *gin.Context, challengeToken string) (*model.User, bool) {
+	claims, err := h.jwtMiddleware.ParseChallengeToken(challengeToken)
+	if err != nil {
+		h.log.Warn().Err(err).Msg("недействительный токен подтверждения входа")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "недействительный токен подтверждения входа, выполните вход заново",
+			RequestID: c.GetString("request_id"),
+		})
+		return nil, false
+	}
+
+	user, err := h.userService.GetUser(c.Request.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+				Code:      http.StatusUnauthorized,
+				Message:   "недействительный токен подтверждения входа, выполните вход заново",
+				RequestID: c.GetString("request_id"),
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось выполнить вход",
+			RequestID: c.GetString("request_id"),
+		})
+		return nil, false
+	}
+
+	return user, true
+}
+
+// loginBlocked отвечает 429, если вход для пользователя или адреса временно заблокирован.
+// Иначе попытка зарезервирована, и вызывающий освобождает ее через LoginGuard.Release
+func (h *AuthHandler) loginBlocked(c *gin.Context, username, ip string) bool {
+	err := h.loginGuard.Check(username, ip)
+	var blocked *service.LoginBlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+
+	h.log.Warn().Str("username", username).Str("ip", ip).Bool("locked", blocked.Locked).Msg("попытка входа отклонена")
+	c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(blocked.RetryAfter.Seconds())), 10))
+	c.JSON(http.StatusTooManyRequests, model.ErrorResponse{
+		Code:      http.StatusTooManyRequests,
+		Message:   "слишком много неудачных попыток входа, повторите позже",
+		RequestID: c.GetString("request_id"),
+	})
+	return true
+}
@@ -9,9 +9,49 @@ package handler
 // @Accept json
 // @Produce json
 // @Param credentials body model.LoginRequestSwagger true "Данные для входа"
-// @Success 200 {object} model.LoginResponseSwagger "Успешная авторизация"
+// @Success 200 {object} model.LoginResponseSwagger "Успешная авторизация или model.TwoFactorChallengeResponse, если нужен второй фактор"
 // @Failure 400 {object} model.ErrorResponseSwagger "Некорректные данные запроса"
 // @Failure 401 {object} model.ErrorResponseSwagger "Неверные учетные данные"
+// @Failure 429 {object} model.ErrorResponseSwagger "Слишком много попыток входа, см. заголовок Retry-After"
 // @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
 // @Router /auth/login [post]
 func (h *AuthHandler) LoginDoc() {}
+
+// JWKS открытые ключи проверки токенов
+// @Summary Открытые ключи JWT
+// @Description Возвращает JWKS с открытыми ключами, которыми подписываются токены. Во время ротации содержит и старый, и новый ключ
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} jwtkeys.JWKS "Набор открытых ключей"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKSDoc() {}
+
+// EnrollTwoFactor настройка 2FA при входе
+// @Summary Начать обязательную настройку 2FA
+// @Description Используется, когда вход вернул enrollment_required. Возвращает секрет и otpauth:// адрес для QR-кода; настройка завершается через /auth/2fa/verify
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorChallengeRequest true "Токен второго шага входа"
+// @Success 200 {object} model.TwoFactorEnrollment "Секрет и адрес для QR-кода"
+// @Failure 400 {object} model.ErrorResponseSwagger "Некорректные данные запроса"
+// @Failure 401 {object} model.ErrorResponseSwagger "Недействительный токен второго шага"
+// @Failure 409 {object} model.ErrorResponseSwagger "2FA уже включена"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /auth/2fa/enroll [post]
+func (h *AuthHandler) EnrollTwoFactorDoc() {}
+
+// VerifyTwoFactor второй шаг входа
+// @Summary Подтвердить вход кодом 2FA
+// @Description Принимает код из приложения или код восстановления и выдает JWT. Если вход завершает обязательную настройку 2FA, в ответе возвращаются коды восстановления
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorVerifyRequest true "Токен второго шага и код"
+// @Success 200 {object} model.LoginResponse "Успешная авторизация"
+// @Failure 400 {object} model.ErrorResponseSwagger "Некорректные данные запроса"
+// @Failure 401 {object} model.ErrorResponseSwagger "Неверный код или недействительный токен второго шага"
+// @Failure 429 {object} model.ErrorResponseSwagger "Слишком много неудачных попыток, см. заголовок Retry-After"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactorDoc() {}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/kkboranbay/task-service/internal/totp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type AuthHandlerTestSuite struct {
+	suite.Suite
+	mockUsers     *mocks.MockUserRepository
+	mockTwoFactor *mocks.MockTwoFactorRepository
+	jwt           *middleware.JWTMiddleware
+	user          *model.User
+	secret        string
+}
+
+func (suite *AuthHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *AuthHandlerTestSuite) SetupTest() {
+	suite.mockUsers = new(mocks.MockUserRepository)
+	suite.mockTwoFactor = new(mocks.MockTwoFactorRepository)
+
+	// хэш пароля "admin"
+	suite.user = &model.User{
+		ID:           1,
+		Username:     "admin",
+		PasswordHash: "$2a$10$GCC./P84VFBl33u4B0QLoOSSanof2vJgCMd8mT6SOJVsCecBGfF6.",
+		Role:         model.RoleAdmin,
+	}
+	suite.mockUsers.On("GetByUsername", mock.Anything, "admin").Return(suite.user, nil)
+	suite.mockUsers.On("GetByID", mock.Anything, int64(1)).Return(suite.user, nil)
+
+	var err error
+	suite.secret, err = totp.GenerateSecret()
+	require.NoError(suite.T(), err)
+}
+
+func (suite *AuthHandlerTestSuite) router(requireTwoFactor bool) *gin.Engine {
+	logger := zerolog.Nop()
+	cfg := config.AuthConfig{
+		JWTSecret:             "test-secret",
+		TokenExpireDelta:      time.Hour,
+		TwoFactorChallengeTTL: time.Minute,
+		TwoFactorIssuer:       "Task Service",
+		RequireTwoFactor:      requireTwoFactor,
+		LoginGuard: config.LoginGuardConfig{
+			MaxFailures:      3,
+			MaxFailuresPerIP: 100,
+			FailureWindow:    time.Minute,
+			LockoutDuration:  time.Minute,
+		},
+	}
+	suite.jwt = middleware.NewJWTMiddleware(cfg, &logger)
+
+	authHandler := NewAuthHandler(
+		suite.jwt,
+		service.NewUserService(suite.mockUsers, &logger),
+		service.NewTwoFactorService(suite.mockTwoFactor, cfg, &logger),
+		service.NewLoginGuard(cfg.LoginGuard, &logger),
+		&logger,
+	)
+
+	router := gin.New()
+	authHandler.Register(router)
+	router.GET("/api/v1/ping", suite.jwt.AuthRequired(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func (suite *AuthHandlerTestSuite) post(router *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	data, err := json.Marshal(body)
+	require.NoError(suite.T(), err)
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *AuthHandlerTestSuite) login(router *gin.Engine) model.TwoFactorChallengeResponse {
+	w := suite.post(router, "/auth/login", model.LoginRequest{Username: "admin", Password: "admin"})
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var challenge model.TwoFactorChallengeResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &challenge))
+	require.True(suite.T(), challenge.TwoFactorRequired)
+	return challenge
+}
+
+func (suite *AuthHandlerTestSuite) enabledTwoFactor() *model.TwoFactor {
+	enabledAt := time.Now().Add(-time.Hour)
+	return &model.TwoFactor{UserID: 1, Secret: suite.secret, EnabledAt: &enabledAt}
+}
+
+func (suite *AuthHandlerTestSuite) currentCode() string {
+	code, err := totp.Code(suite.secret, totp.Step(time.Now()))
+	require.NoError(suite.T(), err)
+	return code
+}
+
+func (suite *AuthHandlerTestSuite) TestLoginWithoutTwoFactor() {
+	suite.mockTwoFactor.On("Get", mock.Anything, int64(1)).Return(nil, repository.ErrNotFound)
+
+	w := suite.post(suite.router(false), "/auth/login", model.LoginRequest{Username: "admin", Password: "admin"})
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var resp model.LoginResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(suite.T(), resp.Token)
+}
+
+func (suite *AuthHandlerTestSuite) TestLoginWithTwoFactor() {
+	router := suite.router(false)
+	suite.mockTwoFactor.On("Get", mock.Anything, int64(1)).Return(suite.enabledTwoFactor(), nil)
+	suite.mockTwoFactor.On("ConsumeStep", mock.Anything, int64(1), mock.AnythingOfType("int64")).Return(true, nil).Once()
+
+	challenge := suite.login(router)
+	assert.False(suite.T(), challenge.EnrollmentRequired)
+
+	// токен второго шага не дает доступа к API
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+	req.Header.Set("Authorization", "Bearer "+challenge.ChallengeToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+
+	w = suite.post(router, "/auth/2fa/verify", model.TwoFactorVerifyRequest{ChallengeToken: challenge.ChallengeToken, Code: suite.currentCode()})
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var resp model.LoginResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	claims, err := suite.jwt.ParseToken(resp.Token)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), claims.UserID)
+	assert.Empty(suite.T(), resp.RecoveryCodes)
+}
+
+func (suite *AuthHandlerTestSuite) TestAccessTokenIsNotAChallenge() {
+	router := suite.router(false)
+
+	token, err := suite.jwt.GenerateToken(1, model.RoleAdmin)
+	require.NoError(suite.T(), err)
+
+	w := suite.post(router, "/auth/2fa/verify", model.TwoFactorVerifyRequest{ChallengeToken: token, Code: "123456"})
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func (suite *AuthHandlerTestSuite) TestWrongCodesLockAccount() {
+	router := suite.router(false)
+	suite.mockTwoFactor.On("Get", mock.Anything, int64(1)).Return(suite.enabledTwoFactor(), nil)
+	suite.mockTwoFactor.On("ConsumeRecoveryCode", mock.Anything, int64(1), mock.Anything).Return(false, nil)
+
+	challenge := suite.login(router)
+
+	for i := 0; i < 3; i++ {
+		w := suite.post(router, "/auth/2fa/verify", model.TwoFactorVerifyRequest{ChallengeToken: challenge.ChallengeToken, Code: "wrong-code"})
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	}
+
+	w := suite.post(router, "/auth/2fa/verify", model.TwoFactorVerifyRequest{ChallengeToken: challenge.ChallengeToken, Code: suite.currentCode()})
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+	suite.mockTwoFactor.AssertNotCalled(suite.T(), "ConsumeStep", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *AuthHandlerTestSuite) TestParallelLoginsRespectLimit() {
+	router := suite.router(false)
+
+	var (
+		wg           sync.WaitGroup
+		unauthorized atomic.Int32
+		start        = make(chan struct{})
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if suite.post(router, "/auth/login", model.LoginRequest{Username: "admin", Password: "wrong"}).Code == http.StatusUnauthorized {
+				unauthorized.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// проверенных паролей не больше MaxFailures, остальные попытки отклонены до проверки
+	assert.LessOrEqual(suite.T(), int(unauthorized.Load()), 3)
+	w := suite.post(router, "/auth/login", model.LoginRequest{Username: "admin", Password: "admin"})
+	assert.Equal(suite.T(), http.StatusTooManyRequests, w.Code)
+}
+
+func (suite *AuthHandlerTestSuite) TestRequiredEnrollmentDuringLogin() {
+	router := suite.router(true)
+	suite.mockTwoFactor.On("Get", mock.Anything, int64(1)).Return(nil, repository.ErrNotFound).Twice()
+
+	challenge := suite.login(router)
+	assert.True(suite.T(), challenge.EnrollmentRequired)
+
+	suite.mockTwoFactor.On("SavePending", mock.Anything, int64(1), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { suite.secret = args.String(2) }).
+		Return(nil).Once()
+
+	w := suite.post(router, "/auth/2fa/enroll", model.TwoFactorChallengeRequest{ChallengeToken: challenge.ChallengeToken})
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var enrollment model.TwoFactorEnrollment
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &enrollment))
+	assert.Equal(suite.T(), suite.secret, enrollment.Secret)
+	assert.Contains(suite.T(), enrollment.ProvisioningURI, "otpauth://totp/")
+
+	suite.mockTwoFactor.On("Get", mock.Anything, int64(1)).Return(&model.TwoFactor{UserID: 1, Secret: suite.secret}, nil)
+	suite.mockTwoFactor.On("Enable", mock.Anything, int64(1), mock.AnythingOfType("int64"), mock.Anything).Return(nil).Once()
+
+	w = suite.post(router, "/auth/2fa/verify", model.TwoFactorVerifyRequest{ChallengeToken: challenge.ChallengeToken, Code: suite.currentCode()})
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var resp model.LoginResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.NotEmpty(suite.T(), resp.Token)
+	assert.Len(suite.T(), resp.RecoveryCodes, 10)
+
+	suite.mockTwoFactor.AssertExpectations(suite.T())
+}
+
+func TestAuthHandlerSuite(t *testing.T) {
+	suite.Run(t, new(AuthHandlerTestSuite))
+}
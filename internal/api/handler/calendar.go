@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/export"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strings"
+)
+
+// calendarFeedMaxAge подсказка календарным клиентам, как часто опрашивать ленту
+const calendarFeedMaxAge = "max-age=300"
+
+type CalendarHandler struct {
+	calendarService *service.CalendarService
+	publicURL       string
+	log             *zerolog.Logger
+}
+
+func NewCalendarHandler(calendarService *service.CalendarService, log *zerolog.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+		log:             log,
+	}
+}
+
+// WithPublicURL задает внешний адрес сервиса для ссылки на ленту, например https://tasks.example.com
+func (h *CalendarHandler) WithPublicURL(publicURL string) *CalendarHandler {
+	h.publicURL = publicURL
+	return h
+}
+
+// Register подключает управление токеном подписки к группе с JWT авторизацией
+func (h *CalendarHandler) Register(router *gin.RouterGroup) {
+	calendar := router.Group("/calendar/token")
+	{
+		calendar.GET("", h.GetToken)
+		calendar.POST("", h.RotateToken)
+		calendar.DELETE("", h.RevokeToken)
+	}
+}
+
+// RegisterFeed подключает публичную ленту; доступ к ней определяется только секретным токеном в URL
+func (h *CalendarHandler) RegisterFeed(router gin.IRouter) {
+	router.GET("/calendar/:token", h.Feed)
+}
+
+func (h *CalendarHandler) getUserID(c *gin.Context) (int64, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.log.Error().Msg("user_id не найден в контексте")
+		return 0, false
+	}
+	id, ok := userID.(int64)
+	if !ok {
+		h.log.Error().Interface("user_id", userID).Msg("некорректный тип user_id в контексте")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *CalendarHandler) GetToken(c *gin.Context) {
+	userID, ok := h.getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	token, err := h.calendarService.GetToken(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrCalendarTokenNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Code:      http.StatusNotFound,
+				Message:   "подписка на календарь не настроена",
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error().Err(err).Msg("ошибка получения токена календаря")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить токен календаря",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// RotateToken выпускает новую ссылку на подписку и отзывает предыдущую
+func (h *CalendarHandler) RotateToken(c *gin.Context) {
+	userID, ok := h.getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	token, createdAt, err := h.calendarService.RotateToken(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка выпуска токена календаря")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось выпустить токен календаря",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.CalendarTokenResponse{
+		Token:     token,
+		URL:       h.feedURL(c, token),
+		CreatedAt: createdAt,
+	})
+}
+
+func (h *CalendarHandler) RevokeToken(c *gin.Context) {
+	userID, ok := h.getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	if err := h.calendarService.RevokeToken(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, service.ErrCalendarTokenNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Code:      http.StatusNotFound,
+				Message:   "подписка на календарь не настроена",
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error().Err(err).Msg("ошибка отзыва токена календаря")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось отозвать токен календаря",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Feed отдает задачи со сроком выполнения в формате iCalendar. Ответ сопровождается ETag,
+// и повторный запрос с If-None-Match получает 304 без выборки задач
+func (h *CalendarHandler) Feed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("token"), ".ics")
+	if !ok || token == "" {
+		c.JSON(http.StatusNotFound, model.ErrorResponse{
+			Code:      http.StatusNotFound,
+			Message:   "календарь не найден",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	userID, err := h.calendarService.ResolveToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, service.ErrCalendarTokenNotFound) {
+			c.JSON(http.StatusNotFound, model.ErrorResponse{
+				Code:      http.StatusNotFound,
+				Message:   "календарь не найден",
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
+		h.log.Error().Err(err).Msg("ошибка проверки токена календаря")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить календарь",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	etag, lastModified, err := h.calendarService.FeedVersion(ctx, userID)
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка получения версии календаря")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить календарь",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, "+calendarFeedMaxAge)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// календарь собирается в память, чтобы ошибка базы не превращалась в обрезанную ленту,
+	// которую клиент закэширует как актуальную
+	var body strings.Builder
+	writer := export.NewICSWriter(&body, export.ICSComponentEvent, "Задачи")
+	if err := writer.Begin(); err != nil {
+		h.log.Error().Err(err).Msg("ошибка построения календаря")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить календарь",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+	err = h.calendarService.WriteFeed(ctx, userID, writer.Write)
+	if err == nil {
+		err = writer.End()
+	}
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка построения календаря")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить календарь",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="tasks.ics"`)
+	c.Data(http.StatusOK, export.ContentType(export.FormatICS), []byte(body.String()))
+}
+
+// etagMatches проверяет заголовок If-None-Match, который может содержать несколько значений или *
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// feedURL строит адрес ленты от PUBLIC_URL, а без него — от хоста, на который пришел запрос.
+// X-Forwarded-Proto и X-Forwarded-Host не учитываются: клиент мог бы подставить в ссылку чужой хост
+func (h *CalendarHandler) feedURL(c *gin.Context, token string) string {
+	path := "/calendar/" + token + ".ics"
+	if h.publicURL != "" {
+		return h.publicURL + path
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + path
+}
@@ -0,0 +1,54 @@
+package handler
+
+// Swagger аннотации для Calendar хендлеров
+
+// GetCalendarToken возвращает сведения о подписке
+// @Summary Получить сведения о подписке на календарь
+// @Description Возвращает время выпуска действующего токена; сам токен повторно не показывается
+// @Tags Calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.CalendarTokenSwagger "Сведения о подписке"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 404 {object} model.ErrorResponseSwagger "Подписка не настроена"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/calendar/token [get]
+func (h *CalendarHandler) GetCalendarTokenDoc() {}
+
+// RotateCalendarToken выпускает новую ссылку
+// @Summary Выпустить ссылку на календарь
+// @Description Создает новый секретный токен подписки; предыдущая ссылка перестает работать
+// @Tags Calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} model.CalendarTokenResponseSwagger "Новая ссылка на подписку"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/calendar/token [post]
+func (h *CalendarHandler) RotateCalendarTokenDoc() {}
+
+// RevokeCalendarToken отзывает ссылку
+// @Summary Отозвать ссылку на календарь
+// @Description Удаляет токен подписки; лента становится недоступной
+// @Tags Calendar
+// @Security BearerAuth
+// @Success 204 "Ссылка отозвана"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 404 {object} model.ErrorResponseSwagger "Подписка не настроена"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/calendar/token [delete]
+func (h *CalendarHandler) RevokeCalendarTokenDoc() {}
+
+// CalendarFeed отдает ленту задач
+// @Summary Лента задач в формате iCalendar
+// @Description Публичная лента задач со сроком выполнения для подписки в Google Calendar, Outlook и других клиентах. Поддерживает условные запросы через If-None-Match
+// @Tags Calendar
+// @Produce text/calendar
+// @Param token path string true "Секретный токен с расширением .ics"
+// @Param If-None-Match header string false "ETag ранее полученной ленты"
+// @Success 200 {file} file "Календарь задач"
+// @Success 304 "Лента не изменилась"
+// @Failure 404 {object} model.ErrorResponseSwagger "Календарь не найден"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /calendar/{token} [get]
+func (h *CalendarHandler) CalendarFeedDoc() {}
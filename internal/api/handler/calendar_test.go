@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/kkboranbay/task-service/internal/testutils"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type CalendarHandlerTestSuite struct {
+	suite.Suite
+	mockTokens *mocks.MockCalendarTokenRepository
+	mockTasks  *mocks.MockTaskRepository
+	router     *gin.Engine
+}
+
+func (suite *CalendarHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+func (suite *CalendarHandlerTestSuite) SetupTest() {
+	suite.setup("")
+}
+
+// setup собирает роутер; publicURL соответствует PUBLIC_URL
+func (suite *CalendarHandlerTestSuite) setup(publicURL string) {
+	suite.mockTokens = new(mocks.MockCalendarTokenRepository)
+	suite.mockTasks = new(mocks.MockTaskRepository)
+	logger := zerolog.Nop()
+	calendarService := service.NewCalendarService(suite.mockTokens, suite.mockTasks, &logger)
+	calendarHandler := NewCalendarHandler(calendarService, &logger).WithPublicURL(publicURL)
+
+	suite.router = gin.New()
+	calendarHandler.RegisterFeed(suite.router)
+
+	api := suite.router.Group("/api/v1")
+	api.Use(func(c *gin.Context) {
+		c.Set("user_id", int64(1))
+		c.Next()
+	})
+	calendarHandler.Register(api)
+}
+
+func (suite *CalendarHandlerTestSuite) TestRotateToken() {
+	createdAt := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	suite.mockTokens.On("Upsert", mock.Anything, int64(1), mock.AnythingOfType("string")).
+		Return(createdAt, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/calendar/token", nil)
+	req.Host = "tasks.example.com"
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	require.Equal(suite.T(), http.StatusCreated, w.Code)
+
+	var resp model.CalendarTokenResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Len(suite.T(), resp.Token, 43)
+	assert.Equal(suite.T(), "http://tasks.example.com/calendar/"+resp.Token+".ics", resp.URL)
+
+	// в базу попадает только хэш токена
+	sum := sha256.Sum256([]byte(resp.Token))
+	suite.mockTokens.AssertCalled(suite.T(), "Upsert", mock.Anything, int64(1), hex.EncodeToString(sum[:]))
+}
+
+func (suite *CalendarHandlerTestSuite) TestFeedURL() {
+	tests := []struct {
+		name      string
+		publicURL string
+		want      string
+	}{
+		// заголовки клиента не влияют на ссылку
+		{name: "request_host", want: "http://tasks.example.com/calendar/"},
+		{name: "public_url", publicURL: "https://calendar.example.com", want: "https://calendar.example.com/calendar/"},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.setup(tt.publicURL)
+			suite.mockTokens.On("Upsert", mock.Anything, int64(1), mock.AnythingOfType("string")).
+				Return(time.Now(), nil).Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/calendar/token", nil)
+			req.Host = "tasks.example.com"
+			req.Header.Set("X-Forwarded-Proto", "https")
+			req.Header.Set("X-Forwarded-Host", "evil.example.com")
+			w := httptest.NewRecorder()
+			suite.router.ServeHTTP(w, req)
+
+			require.Equal(suite.T(), http.StatusCreated, w.Code)
+			var resp model.CalendarTokenResponse
+			require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(suite.T(), tt.want+resp.Token+".ics", resp.URL)
+		})
+	}
+}
+
+func (suite *CalendarHandlerTestSuite) TestRevokeToken() {
+	tests := []struct {
+		name           string
+		repoErr        error
+		expectedStatus int
+	}{
+		{name: "successful_revoke", expectedStatus: http.StatusNoContent},
+		{name: "not_configured", repoErr: repository.ErrNotFound, expectedStatus: http.StatusNotFound},
+		{name: "repository_error", repoErr: errors.New("database error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.SetupTest()
+			suite.mockTokens.On("Delete", mock.Anything, int64(1)).Return(tt.repoErr).Once()
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/calendar/token", nil)
+			w := httptest.NewRecorder()
+			suite.router.ServeHTTP(w, req)
+
+			assert.Equal(suite.T(), tt.expectedStatus, w.Code)
+			suite.mockTokens.AssertExpectations(suite.T())
+		})
+	}
+}
+
+func (suite *CalendarHandlerTestSuite) TestFeed() {
+	const token = "secret-token"
+	sum := sha256.Sum256([]byte(token))
+	tokenHash := hex.EncodeToString(sum[:])
+
+	due := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC)
+	tasks := []model.Task{
+		*testutils.TaskFixture(func(t *model.Task) {
+			t.ID = 1
+			t.Title = "со сроком"
+			t.DueDate = &due
+		}),
+		*testutils.TaskFixture(func(t *model.Task) {
+			t.ID = 2
+			t.Title = "без срока"
+		}),
+	}
+	stats := &model.TaskStats{Count: 2, LastUpdated: &updated}
+
+	suite.Run("full_feed", func() {
+		suite.SetupTest()
+		suite.mockTokens.On("GetUserID", mock.Anything, tokenHash).Return(int64(1), nil).Once()
+		suite.mockTasks.On("Stats", mock.Anything, int64(1)).Return(stats, nil).Once()
+		suite.mockTasks.On("Iterate", mock.Anything, int64(1), model.TaskFilter{}, mock.Anything).
+			Return(tasks, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/calendar/"+token+".ics", nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		require.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.Equal(suite.T(), "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.NotEmpty(suite.T(), w.Header().Get("ETag"))
+		assert.Equal(suite.T(), "Tue, 20 Feb 2024 12:00:00 GMT", w.Header().Get("Last-Modified"))
+		assert.Contains(suite.T(), w.Body.String(), "SUMMARY:со сроком")
+		assert.NotContains(suite.T(), w.Body.String(), "без срока")
+		suite.mockTasks.AssertExpectations(suite.T())
+	})
+
+	suite.Run("not_modified", func() {
+		suite.SetupTest()
+		suite.mockTokens.On("GetUserID", mock.Anything, tokenHash).Return(int64(1), nil).Twice()
+		suite.mockTasks.On("Stats", mock.Anything, int64(1)).Return(stats, nil).Twice()
+		suite.mockTasks.On("Iterate", mock.Anything, int64(1), model.TaskFilter{}, mock.Anything).
+			Return(tasks, nil).Once()
+
+		first := httptest.NewRecorder()
+		suite.router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/calendar/"+token+".ics", nil))
+		require.Equal(suite.T(), http.StatusOK, first.Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/calendar/"+token+".ics", nil)
+		req.Header.Set("If-None-Match", first.Header().Get("ETag"))
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusNotModified, w.Code)
+		assert.Empty(suite.T(), w.Body.String())
+		suite.mockTasks.AssertExpectations(suite.T())
+	})
+
+	suite.Run("etag_changes_with_tasks", func() {
+		suite.SetupTest()
+		changed := updated.Add(time.Minute)
+		suite.mockTokens.On("GetUserID", mock.Anything, tokenHash).Return(int64(1), nil).Twice()
+		suite.mockTasks.On("Stats", mock.Anything, int64(1)).Return(stats, nil).Once()
+		suite.mockTasks.On("Stats", mock.Anything, int64(1)).
+			Return(&model.TaskStats{Count: 2, LastUpdated: &changed}, nil).Once()
+		suite.mockTasks.On("Iterate", mock.Anything, int64(1), model.TaskFilter{}, mock.Anything).
+			Return(tasks, nil).Twice()
+
+		first := httptest.NewRecorder()
+		suite.router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/calendar/"+token+".ics", nil))
+		require.Equal(suite.T(), http.StatusOK, first.Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/calendar/"+token+".ics", nil)
+		req.Header.Set("If-None-Match", first.Header().Get("ETag"))
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.NotEqual(suite.T(), first.Header().Get("ETag"), w.Header().Get("ETag"))
+	})
+
+	suite.Run("unknown_token", func() {
+		suite.SetupTest()
+		suite.mockTokens.On("GetUserID", mock.Anything, mock.AnythingOfType("string")).
+			Return(int64(0), repository.ErrNotFound).Once()
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calendar/other.ics", nil))
+
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+
+	suite.Run("missing_extension", func() {
+		suite.SetupTest()
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/calendar/"+token, nil))
+
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+		suite.mockTokens.AssertNotCalled(suite.T(), "GetUserID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCalendarHandlerSuite(t *testing.T) {
+	suite.Run(t, new(CalendarHandlerTestSuite))
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"net/http"
+)
+
+// statusClientClosedRequest нестандартный код nginx: клиент закрыл соединение, не дождавшись ответа
+const statusClientClosedRequest = 499
+
+// storageErrorResponse отвечает 504 или 503, если запрос к базе не уложился в отведенное время
+// или база недоступна, чтобы клиент мог отличить перегрузку базы от ошибки в запросе.
+// Если клиент сам отменил запрос, ответ 499 нужен только для журнала запросов.
+// Возвращает false для остальных ошибок
+func storageErrorResponse(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled):
+		c.JSON(statusClientClosedRequest, model.ErrorResponse{
+			Code:      statusClientClosedRequest,
+			Message:   "запрос отменен клиентом",
+			RequestID: c.GetString("request_id"),
+		})
+	case errors.Is(err, service.ErrStorageTimeout):
+		c.JSON(http.StatusGatewayTimeout, model.ErrorResponse{
+			Code:      http.StatusGatewayTimeout,
+			Message:   "база данных не ответила вовремя",
+			RequestID: c.GetString("request_id"),
+			ErrorCode: model.ErrorCodeDBTimeout,
+		})
+	case errors.Is(err, service.ErrStorageUnavailable):
+		c.JSON(http.StatusServiceUnavailable, model.ErrorResponse{
+			Code:      http.StatusServiceUnavailable,
+			Message:   "база данных недоступна",
+			RequestID: c.GetString("request_id"),
+			ErrorCode: model.ErrorCodeDBUnavailable,
+		})
+	default:
+		return false
+	}
+	return true
+}
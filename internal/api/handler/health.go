@@ -2,43 +2,51 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/health"
 	"github.com/rs/zerolog"
 	"net/http"
 )
 
 type HealthHandler struct {
-	db  *pgxpool.Pool
-	log *zerolog.Logger
+	registry *health.Registry
+	log      *zerolog.Logger
 }
 
-func NewHealthHandler(db *pgxpool.Pool, log *zerolog.Logger) *HealthHandler {
+func NewHealthHandler(registry *health.Registry, log *zerolog.Logger) *HealthHandler {
 	return &HealthHandler{
-		db:  db,
-		log: log,
+		registry: registry,
+		log:      log,
 	}
 }
 
 func (h *HealthHandler) Register(router *gin.Engine) {
-	router.GET("/health", h.Check)
-	router.GET("/readiness", h.Readiness)
+	router.GET("/livez", h.Live)
+	router.GET("/readyz", h.Ready)
+	router.GET("/startupz", h.Startup)
+
+	// прежние адреса оставлены для существующих проб и docker-compose
+	router.GET("/health", h.Live)
+	router.GET("/readiness", h.Ready)
 }
 
-type HealthResponse struct {
-	Status string `json:"status"`
+func (h *HealthHandler) Live(c *gin.Context) {
+	writeReport(c, h.registry.Live())
 }
 
-func (h *HealthHandler) Check(c *gin.Context) {
-	c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+// Ready отчет по каждой зависимости; 503, если отказал критичный компонент или сервер останавливается
+func (h *HealthHandler) Ready(c *gin.Context) {
+	writeReport(c, h.registry.Ready(c.Request.Context()))
 }
 
-// Readiness проверяет готовность сервиса к работе, включая соединение с БД
-func (h *HealthHandler) Readiness(c *gin.Context) {
-	if err := h.db.Ping(c.Request.Context()); err != nil {
-		h.log.Error().Err(err).Msg("ошибка подключения к базе данных")
-		c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: "database connection failed"})
-		return
-	}
+func (h *HealthHandler) Startup(c *gin.Context) {
+	writeReport(c, h.registry.Startup(c.Request.Context()))
+}
 
-	c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+// writeReport деградация некритичных компонентов не снимает сервис с балансировки
+func writeReport(c *gin.Context, report health.Report) {
+	status := http.StatusOK
+	if report.Status == health.StatusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
 }
@@ -1,12 +1,30 @@
 package handler
 
-// Health проверка состояния сервиса
-// @Summary Проверка состояния сервиса
-// @Description Возвращает статус работоспособности API сервиса
+// Live проверка жизнеспособности процесса
+// @Summary Проверка жизнеспособности
+// @Description Отвечает, пока процесс работает; зависимости не проверяются. Также доступна по адресу /health
 // @Tags Health
-// @Accept json
 // @Produce json
-// @Success 200 {object} model.HealthResponseSwagger "Сервис работает нормально"
-// @Failure 500 {object} model.ErrorResponseSwagger "Сервис недоступен"
-// @Router /health [get]
-func HealthCheckDoc() {}
+// @Success 200 {object} model.HealthReportSwagger "Процесс работает"
+// @Router /livez [get]
+func LiveDoc() {}
+
+// Ready проверка готовности принимать запросы
+// @Summary Проверка готовности
+// @Description Проверяет зависимости и возвращает отчет по каждой. Отказ некритичного компонента дает статус degraded с кодом 200. Во время остановки сервиса возвращает 503. Также доступна по адресу /readiness
+// @Tags Health
+// @Produce json
+// @Success 200 {object} model.HealthReportSwagger "Сервис готов"
+// @Failure 503 {object} model.HealthReportSwagger "Критичный компонент недоступен или сервис останавливается"
+// @Router /readyz [get]
+func ReadyDoc() {}
+
+// Startup проверка завершения запуска
+// @Summary Проверка запуска
+// @Description Успешна, когда критичные зависимости были доступны хотя бы один раз; после этого зависимости не проверяются
+// @Tags Health
+// @Produce json
+// @Success 200 {object} model.HealthReportSwagger "Запуск завершен"
+// @Failure 503 {object} model.HealthReportSwagger "Зависимости еще недоступны"
+// @Router /startupz [get]
+func StartupDoc() {}
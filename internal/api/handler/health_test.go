@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/health"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		dbErr          error
+		draining       bool
+		expectedCode   int
+		expectedStatus health.Status
+	}{
+		{name: "live_ignores_dependencies", path: "/livez", dbErr: errors.New("нет соединения"), expectedCode: http.StatusOK, expectedStatus: health.StatusOK},
+		{name: "legacy_health", path: "/health", expectedCode: http.StatusOK, expectedStatus: health.StatusOK},
+		{name: "ready", path: "/readyz", expectedCode: http.StatusOK, expectedStatus: health.StatusOK},
+		{name: "ready_database_down", path: "/readyz", dbErr: errors.New("нет соединения"), expectedCode: http.StatusServiceUnavailable, expectedStatus: health.StatusUnavailable},
+		{name: "ready_draining", path: "/readiness", draining: true, expectedCode: http.StatusServiceUnavailable, expectedStatus: health.StatusUnavailable},
+		{name: "startup_database_down", path: "/startupz", dbErr: errors.New("нет соединения"), expectedCode: http.StatusServiceUnavailable, expectedStatus: health.StatusUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zerolog.Nop()
+			registry := health.NewRegistry(&log)
+			registry.Register(health.Check{Name: "database", Critical: true, Check: func(ctx context.Context) error {
+				return tt.dbErr
+			}})
+			if tt.draining {
+				registry.SetDraining()
+			}
+
+			router := gin.New()
+			NewHealthHandler(registry, &log).Register(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			var report health.Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, tt.expectedStatus, report.Status)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/oidc"
+	"github.com/kkboranbay/task-service/internal/secrets"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	oidcStateCookie = "oidc_state"
+	// oidcStateSecret ключ в хранилище секретов, которым подписывается cookie состояния
+	oidcStateSecret = "OIDC_STATE_SECRET"
+)
+
+// OIDCHandler вход через внешнего OpenID Connect провайдера по authorization code flow с PKCE
+type OIDCHandler struct {
+	provider         *oidc.Provider
+	userService      *service.UserService
+	twoFactorService *service.TwoFactorService
+	jwtMiddleware    *middleware.JWTMiddleware
+	secrets          *secrets.Store
+	stateTTL         time.Duration
+	secureCookie     bool
+	log              *zerolog.Logger
+}
+
+func NewOIDCHandler(
+	provider *oidc.Provider,
+	userService *service.UserService,
+	twoFactorService *service.TwoFactorService,
+	jwtMiddleware *middleware.JWTMiddleware,
+	secretStore *secrets.Store,
+	stateTTL time.Duration,
+	redirectURL string,
+	log *zerolog.Logger,
+) *OIDCHandler {
+	return &OIDCHandler{
+		provider:         provider,
+		userService:      userService,
+		twoFactorService: twoFactorService,
+		jwtMiddleware:    jwtMiddleware,
+		secrets:          secretStore,
+		stateTTL:         stateTTL,
+		secureCookie:     strings.HasPrefix(redirectURL, "https://"),
+		log:              log,
+	}
+}
+
+func (h *OIDCHandler) Register(router *gin.Engine, loginMiddleware ...gin.HandlerFunc) {
+	auth := router.Group("/auth/oidc")
+	{
+		auth.GET("/login", append(loginMiddleware, h.Login)...)
+		auth.GET("/callback", append(loginMiddleware, h.Callback)...)
+	}
+}
+
+// Login сохраняет state, nonce и PKCE verifier в подписанной cookie и перенаправляет к провайдеру
+func (h *OIDCHandler) Login(c *gin.Context) {
+	state, err := oidc.NewState(h.stateTTL)
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка генерации состояния OIDC входа")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось начать вход",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	// SameSite=Lax: cookie должна прийти на callback после перехода с сайта провайдера
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state.Seal([]byte(h.secrets.Get(oidcStateSecret))), int(h.stateTTL.Seconds()), "/auth/oidc", "", h.secureCookie, true)
+	c.Redirect(http.StatusFound, h.provider.AuthCodeURL(state.State, state.Nonce, state.Verifier))
+}
+
+// Callback обменивает код на ID токен, создает пользователя при первом входе и выдает JWT сервиса
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	sealed, _ := c.Cookie(oidcStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", "", h.secureCookie, true)
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		h.log.Warn().Str("error", providerErr).Str("description", c.Query("error_description")).Msg("провайдер OIDC отклонил вход")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "провайдер отклонил вход",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	state, err := h.openState(sealed)
+	if err != nil || state.State != c.Query("state") || c.Query("code") == "" {
+		h.log.Warn().Str("ip", c.ClientIP()).Msg("недействительное состояние OIDC входа")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "недействительное состояние входа, начните вход заново",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	rawIDToken, err := h.provider.Exchange(ctx, c.Query("code"), state.Verifier)
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка обмена кода авторизации OIDC")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "не удалось выполнить вход через провайдера",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	claims, err := h.provider.Verify(ctx, rawIDToken, state.Nonce)
+	if err != nil {
+		h.log.Warn().Err(err).Msg("ошибка проверки ID токена")
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "не удалось выполнить вход через провайдера",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	user, err := h.userService.ProvisionExternalUser(ctx, model.ExternalIdentity{
+		Issuer:            h.provider.Issuer(),
+		Subject:           claims.Subject,
+		PreferredUsername: claims.PreferredUsername,
+		Email:             claims.Email,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось выполнить вход",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	// второй фактор проверяется так же, как при входе по паролю: провайдер подтверждает
+	// только личность, а включенная в сервисе 2FA или AUTH_2FA_REQUIRED действуют для любого входа
+	enabled, err := h.twoFactorService.Enabled(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось выполнить вход",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+	if enabled || h.twoFactorService.Required() {
+		twoFactorChallenge(c, h.jwtMiddleware, h.log, user, !enabled)
+		return
+	}
+
+	token, err := h.jwtMiddleware.GenerateToken(user.ID, user.Role)
+	if err != nil {
+		h.log.Error().Err(err).Int64("user_id", user.ID).Msg("ошибка генерации токена")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось сгенерировать токен",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	h.log.Info().Str("username", user.Username).Int64("user_id", user.ID).Msg("успешная авторизация через OIDC")
+
+	c.JSON(http.StatusOK, model.LoginResponse{
+		Token:  token,
+		UserID: user.ID,
+	})
+}
+
+// openState проверяет cookie текущим OIDC_STATE_SECRET, а после ротации и предыдущим,
+// чтобы не оборвать входы, начатые до смены секрета
+func (h *OIDCHandler) openState(sealed string) (oidc.State, error) {
+	state, err := oidc.OpenState([]byte(h.secrets.Get(oidcStateSecret)), sealed)
+	if err != nil {
+		if previous := h.secrets.Previous(oidcStateSecret); previous != "" {
+			return oidc.OpenState([]byte(previous), sealed)
+		}
+	}
+	return state, err
+}
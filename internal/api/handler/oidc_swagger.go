@@ -0,0 +1,26 @@
+package handler
+
+// Swagger аннотации для OIDC хендлеров
+
+// Login начало входа через OIDC
+// @Summary Вход через OIDC провайдера
+// @Description Перенаправляет на страницу входа внешнего провайдера (authorization code flow с PKCE). Доступно, если задан OIDC_ISSUER_URL
+// @Tags Authentication
+// @Success 302 "Перенаправление к провайдеру"
+// @Failure 429 {object} model.ErrorResponseSwagger "Превышен лимит запросов"
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) LoginDoc() {}
+
+// Callback завершение входа через OIDC
+// @Summary Завершение входа через OIDC
+// @Description Обменивает код авторизации на ID токен, при первом входе создает пользователя и возвращает JWT. Если у пользователя включена 2FA или задан AUTH_2FA_REQUIRED, вход завершается через /auth/2fa/verify
+// @Tags Authentication
+// @Produce json
+// @Param code query string true "Код авторизации"
+// @Param state query string true "Состояние, выданное при начале входа"
+// @Success 200 {object} model.LoginResponseSwagger "Успешная авторизация или model.TwoFactorChallengeResponse, если нужен второй фактор"
+// @Failure 400 {object} model.ErrorResponseSwagger "Недействительное состояние входа"
+// @Failure 401 {object} model.ErrorResponseSwagger "Провайдер отклонил вход или ID токен недействителен"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) CallbackDoc() {}
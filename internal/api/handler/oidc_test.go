@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/oidc"
+	"github.com/kkboranbay/task-service/internal/oidc/oidctest"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/kkboranbay/task-service/internal/secrets"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type OIDCHandlerTestSuite struct {
+	suite.Suite
+	idp           *oidctest.Server
+	mockUsers     *mocks.MockUserRepository
+	mockTwoFactor *mocks.MockTwoFactorRepository
+	jwt           *middleware.JWTMiddleware
+	secrets       *secrets.Store
+	router        *gin.Engine
+}
+
+func (suite *OIDCHandlerTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	suite.idp = oidctest.NewServer("task-service", "client-secret")
+}
+
+func (suite *OIDCHandlerTestSuite) TearDownSuite() {
+	suite.idp.Close()
+}
+
+func (suite *OIDCHandlerTestSuite) SetupTest() {
+	suite.setup(false)
+}
+
+// setup собирает роутер с OIDC хендлером; requireTwoFactor соответствует AUTH_2FA_REQUIRED
+func (suite *OIDCHandlerTestSuite) setup(requireTwoFactor bool) {
+	suite.mockUsers = new(mocks.MockUserRepository)
+	suite.mockTwoFactor = new(mocks.MockTwoFactorRepository)
+	logger := zerolog.Nop()
+
+	cfg := config.OIDCConfig{
+		IssuerURL:    suite.idp.URL,
+		ClientID:     suite.idp.ClientID,
+		ClientSecret: suite.idp.ClientSecret,
+		RedirectURL:  "http://localhost:8080/auth/oidc/callback",
+		Scopes:       []string{"openid", "profile", "email"},
+		StateTTL:     time.Minute,
+	}
+	provider, err := oidc.NewProvider(context.Background(), cfg, suite.idp.Client())
+	require.NoError(suite.T(), err)
+
+	authConfig := config.AuthConfig{
+		JWTSecret:             "test-secret",
+		TokenExpireDelta:      time.Hour,
+		TwoFactorChallengeTTL: time.Minute,
+		RequireTwoFactor:      requireTwoFactor,
+	}
+	suite.jwt = middleware.NewJWTMiddleware(authConfig, &logger)
+	suite.secrets = secrets.NewStore(map[string]string{"OIDC_STATE_SECRET": "state-secret"})
+
+	oidcHandler := NewOIDCHandler(
+		provider,
+		service.NewUserService(suite.mockUsers, &logger),
+		service.NewTwoFactorService(suite.mockTwoFactor, authConfig, &logger),
+		suite.jwt,
+		suite.secrets,
+		cfg.StateTTL,
+		cfg.RedirectURL,
+		&logger,
+	)
+
+	suite.router = gin.New()
+	oidcHandler.Register(suite.router)
+}
+
+// login начинает вход и проходит авторизацию у провайдера; возвращает cookie состояния и адрес callback
+func (suite *OIDCHandlerTestSuite) login() (*http.Cookie, *url.URL) {
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil))
+	require.Equal(suite.T(), http.StatusFound, w.Code)
+
+	cookies := w.Result().Cookies()
+	require.Len(suite.T(), cookies, 1)
+	assert.True(suite.T(), cookies[0].HttpOnly)
+
+	authorizeURL := w.Header().Get("Location")
+	assert.Contains(suite.T(), authorizeURL, "code_challenge_method=S256")
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authorizeURL)
+	require.NoError(suite.T(), err)
+	defer resp.Body.Close()
+	require.Equal(suite.T(), http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(suite.T(), err)
+	return cookies[0], callback
+}
+
+func (suite *OIDCHandlerTestSuite) callback(cookie *http.Cookie, callback *url.URL) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	return w
+}
+
+func (suite *OIDCHandlerTestSuite) TestProvisionsUserOnFirstLogin() {
+	suite.idp.SetUser(oidctest.User{Subject: "sub-7", Email: "jane@example.com", PreferredUsername: "jane"})
+
+	suite.mockUsers.On("GetByExternalID", mock.Anything, suite.idp.URL, "sub-7").
+		Return(nil, repository.ErrNotFound).Once()
+	suite.mockUsers.On("CreateExternal", mock.Anything, model.User{Username: "jane", Role: model.RoleUser}, suite.idp.URL, "sub-7").
+		Return(&model.User{ID: 15, Username: "jane", Role: model.RoleUser}, nil).Once()
+	suite.mockTwoFactor.On("Get", mock.Anything, int64(15)).Return(nil, repository.ErrNotFound)
+
+	w := suite.callback(suite.login())
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+
+	var resp model.LoginResponse
+	require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(suite.T(), int64(15), resp.UserID)
+
+	claims, err := suite.jwt.ParseToken(resp.Token)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(15), claims.UserID)
+	assert.Equal(suite.T(), model.RoleUser, claims.Role)
+
+	suite.mockUsers.AssertExpectations(suite.T())
+}
+
+func (suite *OIDCHandlerTestSuite) TestExistingUser() {
+	suite.idp.SetUser(oidctest.User{Subject: "sub-8", PreferredUsername: "john"})
+
+	suite.mockUsers.On("GetByExternalID", mock.Anything, suite.idp.URL, "sub-8").
+		Return(&model.User{ID: 3, Username: "john", Role: model.RoleUser}, nil).Once()
+	suite.mockTwoFactor.On("Get", mock.Anything, int64(3)).Return(nil, repository.ErrNotFound)
+
+	w := suite.callback(suite.login())
+	require.Equal(suite.T(), http.StatusOK, w.Code)
+	suite.mockUsers.AssertNotCalled(suite.T(), "CreateExternal", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OIDCHandlerTestSuite) TestRejectsInvalidState() {
+	cookie, callback := suite.login()
+
+	suite.Run("missing_cookie", func() {
+		w := suite.callback(nil, callback)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	})
+
+	suite.Run("state_mismatch", func() {
+		forged := *callback
+		query := forged.Query()
+		query.Set("state", "forged")
+		forged.RawQuery = query.Encode()
+
+		w := suite.callback(cookie, &forged)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	})
+
+	suite.mockUsers.AssertNotCalled(suite.T(), "GetByExternalID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OIDCHandlerTestSuite) TestStateSecretRotation() {
+	suite.idp.SetUser(oidctest.User{Subject: "sub-8", PreferredUsername: "john"})
+	suite.mockUsers.On("GetByExternalID", mock.Anything, suite.idp.URL, "sub-8").
+		Return(&model.User{ID: 3, Username: "john", Role: model.RoleUser}, nil)
+	suite.mockTwoFactor.On("Get", mock.Anything, int64(3)).Return(nil, repository.ErrNotFound)
+
+	suite.Run("started_before_rotation", func() {
+		cookie, callback := suite.login()
+		suite.secrets.Update(map[string]string{"OIDC_STATE_SECRET": "rotated-secret"})
+
+		w := suite.callback(cookie, callback)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+	})
+
+	suite.Run("two_rotations_ago", func() {
+		cookie, callback := suite.login()
+		suite.secrets.Update(map[string]string{"OIDC_STATE_SECRET": "second-rotation"})
+		suite.secrets.Update(map[string]string{"OIDC_STATE_SECRET": "third-rotation"})
+
+		w := suite.callback(cookie, callback)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	})
+
+	suite.Run("sealed_with_jwt_secret", func() {
+		state, err := oidc.NewState(time.Minute)
+		require.NoError(suite.T(), err)
+		cookie := &http.Cookie{Name: "oidc_state", Value: state.Seal([]byte("test-secret"))}
+		callback, _ := url.Parse("/auth/oidc/callback?code=code&state=" + state.State)
+
+		w := suite.callback(cookie, callback)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	})
+}
+
+func (suite *OIDCHandlerTestSuite) TestTwoFactor() {
+	tests := []struct {
+		name               string
+		required           bool
+		twoFactor          *model.TwoFactor
+		enrollmentRequired bool
+	}{
+		{name: "enabled", twoFactor: &model.TwoFactor{UserID: 3, Secret: "JBSWY3DPEHPK3PXP", EnabledAt: &time.Time{}}},
+		{name: "required_not_enrolled", required: true, enrollmentRequired: true},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.setup(tt.required)
+			suite.idp.SetUser(oidctest.User{Subject: "sub-8", PreferredUsername: "john"})
+			suite.mockUsers.On("GetByExternalID", mock.Anything, suite.idp.URL, "sub-8").
+				Return(&model.User{ID: 3, Username: "john", Role: model.RoleUser}, nil).Once()
+			if tt.twoFactor != nil {
+				suite.mockTwoFactor.On("Get", mock.Anything, int64(3)).Return(tt.twoFactor, nil)
+			} else {
+				suite.mockTwoFactor.On("Get", mock.Anything, int64(3)).Return(nil, repository.ErrNotFound)
+			}
+
+			w := suite.callback(suite.login())
+			require.Equal(suite.T(), http.StatusOK, w.Code)
+
+			// вместо токена доступа выдается токен второго шага для /auth/2fa/verify
+			var resp model.TwoFactorChallengeResponse
+			require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.True(suite.T(), resp.TwoFactorRequired)
+			assert.Equal(suite.T(), tt.enrollmentRequired, resp.EnrollmentRequired)
+			assert.NotContains(suite.T(), w.Body.String(), `"token"`)
+
+			claims, err := suite.jwt.ParseChallengeToken(resp.ChallengeToken)
+			require.NoError(suite.T(), err)
+			assert.Equal(suite.T(), int64(3), claims.UserID)
+			_, err = suite.jwt.ParseToken(resp.ChallengeToken)
+			assert.Error(suite.T(), err)
+		})
+	}
+}
+
+func (suite *OIDCHandlerTestSuite) TestProviderError() {
+	cookie, _ := suite.login()
+	callback, _ := url.Parse("/auth/oidc/callback?error=access_denied")
+
+	w := suite.callback(cookie, callback)
+	assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+}
+
+func TestOIDCHandlerSuite(t *testing.T) {
+	suite.Run(t, new(OIDCHandlerTestSuite))
+}
@@ -1,17 +1,27 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/export"
+	"github.com/kkboranbay/task-service/internal/importer"
 	"github.com/kkboranbay/task-service/internal/model"
 	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/kkboranbay/task-service/pkg/logger"
 	"github.com/rs/zerolog"
 	"net/http"
 	"strconv"
+	"time"
 )
 
+// maxImportSize ограничивает размер загружаемого файла импорта
+const maxImportSize = 32 << 20
+
 type TaskHandler struct {
-	taskService *service.TaskService
-	log         *zerolog.Logger
+	taskService  *service.TaskService
+	log          *zerolog.Logger
+	writeTimeout time.Duration
 }
 
 func NewTaskHandler(taskService *service.TaskService, log *zerolog.Logger) *TaskHandler {
@@ -21,11 +31,21 @@ func NewTaskHandler(taskService *service.TaskService, log *zerolog.Logger) *Task
 	}
 }
 
+// WithWriteTimeout задает время на запись очередной порции экспорта. Экспорт продлевает
+// дедлайн записи после каждой задачи, поэтому SERVER_WRITE_TIMEOUT ограничивает паузу между
+// порциями, а не всю выгрузку
+func (h *TaskHandler) WithWriteTimeout(timeout time.Duration) *TaskHandler {
+	h.writeTimeout = timeout
+	return h
+}
+
 func (h *TaskHandler) Register(router *gin.RouterGroup) {
 	tasks := router.Group("/tasks")
 	{
 		tasks.POST("", h.Create)
 		tasks.GET("", h.List)
+		tasks.GET("/export", h.Export)
+		tasks.POST("/import", h.Import)
 		tasks.GET("/:id", h.GetByID)
 		tasks.PUT("/:id", h.Update)
 		tasks.DELETE("/:id", h.Delete)
@@ -46,12 +66,45 @@ func (h *TaskHandler) getUserID(c *gin.Context) (int64, bool) {
 	return id, true
 }
 
+// parseTaskFilter читает фильтр списка задач из query-параметров status, search, due_before и due_after
+func parseTaskFilter(c *gin.Context) (model.TaskFilter, error) {
+	var filter model.TaskFilter
+
+	if status := c.Query("status"); status != "" {
+		taskStatus := model.TaskStatus(status)
+		if !taskStatus.IsValid() {
+			return filter, fmt.Errorf("некорректный статус задачи: %s", status)
+		}
+		filter.Status = &taskStatus
+	}
+
+	filter.Search = c.Query("search")
+
+	for param, target := range map[string]**time.Time{
+		"due_before": &filter.DueBefore,
+		"due_after":  &filter.DueAfter,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("ошибка парсинга %s: %w", param, err)
+		}
+		*target = &t
+	}
+
+	return filter, nil
+}
+
 func (h *TaskHandler) Create(c *gin.Context) {
 	userID, ok := h.getUserID(c)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-			Code:    http.StatusUnauthorized,
-			Message: "unauthorized",
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -60,18 +113,23 @@ func (h *TaskHandler) Create(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.log.Error().Err(err).Msg("ошибка разбора JSON")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "некорректные данные запроса",
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные данные запроса",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
 
 	task, err := h.taskService.CreateTask(c.Request.Context(), userID, req)
 	if err != nil {
-		h.log.Error().Err(err).Msg("ошибка создания задачи")
+		logger.Error(h.log, err).Msg("ошибка создания задачи")
+		if storageErrorResponse(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "не удалось создать задачу",
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось создать задачу",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -83,8 +141,9 @@ func (h *TaskHandler) GetByID(c *gin.Context) {
 	userID, ok := h.getUserID(c)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-			Code:    http.StatusUnauthorized,
-			Message: "unauthorized",
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -94,18 +153,23 @@ func (h *TaskHandler) GetByID(c *gin.Context) {
 	if err != nil {
 		h.log.Error().Err(err).Str("id", idStr).Msg("ошибка парсинга ID")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "некорректный ID задачи",
+			Code:      http.StatusBadRequest,
+			Message:   "некорректный ID задачи",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
 
 	task, err := h.taskService.GetTaskByID(c.Request.Context(), id, userID)
 	if err != nil {
-		h.log.Error().Err(err).Int64("id", id).Msg("ошибка получения задачи")
+		logger.Error(h.log, err).Int64("id", id).Msg("ошибка получения задачи")
+		if storageErrorResponse(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, model.ErrorResponse{
-			Code:    http.StatusNotFound,
-			Message: "задача не найдена",
+			Code:      http.StatusNotFound,
+			Message:   "задача не найдена",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -117,8 +181,9 @@ func (h *TaskHandler) List(c *gin.Context) {
 	userID, ok := h.getUserID(c)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-			Code:    http.StatusUnauthorized,
-			Message: "unauthorized",
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -133,12 +198,27 @@ func (h *TaskHandler) List(c *gin.Context) {
 		pageSize = 10
 	}
 
-	tasks, err := h.taskService.GetTaskList(c.Request.Context(), userID, page, pageSize)
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка разбора фильтра")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные параметры фильтра",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	tasks, err := h.taskService.GetTaskList(c.Request.Context(), userID, filter, page, pageSize)
 	if err != nil {
-		h.log.Error().Err(err).Msg("ошибка получения списка задач")
+		logger.Error(h.log, err).Msg("ошибка получения списка задач")
+		if storageErrorResponse(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "не удалось получить список задач",
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить список задач",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -146,12 +226,179 @@ func (h *TaskHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, tasks)
 }
 
+// Export отдает задачи пользователя файлом в формате csv, json, ndjson или ics с учетом фильтров списка
+func (h *TaskHandler) Export(c *gin.Context) {
+	userID, ok := h.getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка разбора фильтра")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные параметры фильтра",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	format := export.Format(c.DefaultQuery("format", string(export.FormatCSV)))
+	writer, err := export.NewWriter(format, c.Writer)
+	if err == nil && format == export.FormatICS {
+		var component export.ICSComponent
+		component, err = export.ParseICSComponent(c.Query("ics_component"))
+		writer = export.NewICSWriter(c.Writer, component, "Задачи")
+	}
+	if err != nil {
+		h.log.Error().Err(err).Str("format", string(format)).Msg("ошибка выбора формата экспорта")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "неподдерживаемый формат экспорта",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	// заголовки отправляются с первой задачей, чтобы ошибку до начала выгрузки можно было вернуть как 500
+	started := false
+	rc := http.NewResponseController(c.Writer)
+	start := func() error {
+		started = true
+		if err := h.extendWriteDeadline(rc); err != nil {
+			return err
+		}
+		c.Header("Content-Type", export.ContentType(format))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+		c.Status(http.StatusOK)
+		return writer.Begin()
+	}
+
+	err = h.taskService.ExportTasks(c.Request.Context(), userID, filter, func(task *model.Task) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(task); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return h.extendWriteDeadline(rc)
+	})
+	if err != nil {
+		logger.Error(h.log, err).Msg("ошибка экспорта задач")
+		if !started && !storageErrorResponse(c, err) {
+			c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+				Code:      http.StatusInternalServerError,
+				Message:   "не удалось экспортировать задачи",
+				RequestID: c.GetString("request_id"),
+			})
+		}
+		// иначе заголовки уже отправлены и клиент получит оборванный файл
+		return
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			h.log.Error().Err(err).Msg("ошибка записи экспорта")
+			return
+		}
+	}
+
+	if err := writer.End(); err != nil {
+		h.log.Error().Err(err).Msg("ошибка записи экспорта")
+	}
+}
+
+// extendWriteDeadline сдвигает дедлайн записи ответа на writeTimeout от текущего момента
+func (h *TaskHandler) extendWriteDeadline(rc *http.ResponseController) error {
+	if h.writeTimeout <= 0 {
+		return nil
+	}
+	err := rc.SetWriteDeadline(time.Now().Add(h.writeTimeout))
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// Import загружает задачи из CSV или NDJSON; некорректные строки попадают в отчет и не мешают импорту остальных
+func (h *TaskHandler) Import(c *gin.Context) {
+	userID, ok := h.getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	format, err := importer.DetectFormat(c.Query("format"), c.ContentType())
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка определения формата импорта")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "неподдерживаемый формат импорта",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	rows, rowErrors, err := importer.Parse(format, body)
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка разбора файла импорта")
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, model.ErrorResponse{
+				Code:      http.StatusRequestEntityTooLarge,
+				Message:   "файл импорта слишком большой",
+				RequestID: c.GetString("request_id"),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "не удалось разобрать файл импорта",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	report, err := h.taskService.ImportTasks(c.Request.Context(), userID, rows, rowErrors, dryRun)
+	if err != nil {
+		logger.Error(h.log, err).Msg("ошибка импорта задач")
+		if storageErrorResponse(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось импортировать задачи",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
 func (h *TaskHandler) Update(c *gin.Context) {
 	userID, ok := h.getUserID(c)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-			Code:    http.StatusUnauthorized,
-			Message: "unauthorized",
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -161,8 +408,9 @@ func (h *TaskHandler) Update(c *gin.Context) {
 	if err != nil {
 		h.log.Error().Err(err).Str("id", idStr).Msg("ошибка парсинга ID")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "некорректный ID задачи",
+			Code:      http.StatusBadRequest,
+			Message:   "некорректный ID задачи",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -171,18 +419,23 @@ func (h *TaskHandler) Update(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.log.Error().Err(err).Msg("ошибка разбора JSON")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "некорректные данные запроса",
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные данные запроса",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
 
 	task, err := h.taskService.UpdateTask(c.Request.Context(), id, userID, req)
 	if err != nil {
-		h.log.Error().Err(err).Int64("id", id).Msg("ошибка обновления задачи")
+		logger.Error(h.log, err).Int64("id", id).Msg("ошибка обновления задачи")
+		if storageErrorResponse(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, model.ErrorResponse{
-			Code:    http.StatusNotFound,
-			Message: "задача не найдена или не удалось обновить",
+			Code:      http.StatusNotFound,
+			Message:   "задача не найдена или не удалось обновить",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -194,8 +447,9 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 	userID, ok := h.getUserID(c)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-			Code:    http.StatusUnauthorized,
-			Message: "unauthorized",
+			Code:      http.StatusUnauthorized,
+			Message:   "unauthorized",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
@@ -205,18 +459,23 @@ func (h *TaskHandler) Delete(c *gin.Context) {
 	if err != nil {
 		h.log.Error().Err(err).Str("id", idStr).Msg("ошибка парсинга ID")
 		c.JSON(http.StatusBadRequest, model.ErrorResponse{
-			Code:    http.StatusBadRequest,
-			Message: "некорректный ID задачи",
+			Code:      http.StatusBadRequest,
+			Message:   "некорректный ID задачи",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
 
 	err = h.taskService.DeleteTask(c.Request.Context(), id, userID)
 	if err != nil {
-		h.log.Error().Err(err).Int64("id", id).Msg("ошибка удаления задачи")
+		logger.Error(h.log, err).Int64("id", id).Msg("ошибка удаления задачи")
+		if storageErrorResponse(c, err) {
+			return
+		}
 		c.JSON(http.StatusNotFound, model.ErrorResponse{
-			Code:    http.StatusNotFound,
-			Message: "задача не найдена или не удалось удалить",
+			Code:      http.StatusNotFound,
+			Message:   "задача не найдена или не удалось удалить",
+			RequestID: c.GetString("request_id"),
 		})
 		return
 	}
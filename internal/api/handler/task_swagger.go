@@ -14,6 +14,8 @@ package handler
 // @Failure 400 {object} model.ErrorResponseSwagger "Некорректные данные запроса"
 // @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
 // @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Failure 503 {object} model.ErrorResponseSwagger "База данных недоступна (error_code db_unavailable)"
+// @Failure 504 {object} model.ErrorResponseSwagger "База данных не ответила вовремя (error_code db_timeout)"
 // @Router /api/v1/tasks [post]
 func (h *TaskHandler) CreateTaskDoc() {}
 
@@ -30,6 +32,8 @@ func (h *TaskHandler) CreateTaskDoc() {}
 // @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
 // @Failure 404 {object} model.ErrorResponseSwagger "Задача не найдена"
 // @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Failure 503 {object} model.ErrorResponseSwagger "База данных недоступна (error_code db_unavailable)"
+// @Failure 504 {object} model.ErrorResponseSwagger "База данных не ответила вовремя (error_code db_timeout)"
 // @Router /api/v1/tasks/{id} [get]
 func (h *TaskHandler) GetTaskDoc() {}
 
@@ -42,10 +46,16 @@ func (h *TaskHandler) GetTaskDoc() {}
 // @Security BearerAuth
 // @Param page query int false "Номер страницы" minimum(1) default(1)
 // @Param page_size query int false "Количество элементов на странице" minimum(1) maximum(100) default(10)
+// @Param status query string false "Фильтр по статусу" Enums(pending, in_progress, completed)
+// @Param search query string false "Поиск по заголовку и описанию"
+// @Param due_before query string false "Срок выполнения раньше (RFC3339)"
+// @Param due_after query string false "Срок выполнения не раньше (RFC3339)"
 // @Success 200 {object} model.TaskListResponseSwagger "Список задач"
 // @Failure 400 {object} model.ErrorResponseSwagger "Некорректные параметры запроса"
 // @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
 // @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Failure 503 {object} model.ErrorResponseSwagger "База данных недоступна (error_code db_unavailable)"
+// @Failure 504 {object} model.ErrorResponseSwagger "База данных не ответила вовремя (error_code db_timeout)"
 // @Router /api/v1/tasks [get]
 func (h *TaskHandler) ListTasksDoc() {}
 
@@ -63,6 +73,8 @@ func (h *TaskHandler) ListTasksDoc() {}
 // @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
 // @Failure 404 {object} model.ErrorResponseSwagger "Задача не найдена"
 // @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Failure 503 {object} model.ErrorResponseSwagger "База данных недоступна (error_code db_unavailable)"
+// @Failure 504 {object} model.ErrorResponseSwagger "База данных не ответила вовремя (error_code db_timeout)"
 // @Router /api/v1/tasks/{id} [put]
 func (h *TaskHandler) UpdateTaskDoc() {}
 
@@ -79,5 +91,49 @@ func (h *TaskHandler) UpdateTaskDoc() {}
 // @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
 // @Failure 404 {object} model.ErrorResponseSwagger "Задача не найдена"
 // @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Failure 503 {object} model.ErrorResponseSwagger "База данных недоступна (error_code db_unavailable)"
+// @Failure 504 {object} model.ErrorResponseSwagger "База данных не ответила вовремя (error_code db_timeout)"
 // @Router /api/v1/tasks/{id} [delete]
 func (h *TaskHandler) DeleteTaskDoc() {}
+
+// ExportTasks выгружает задачи в файл
+// @Summary Экспортировать задачи
+// @Description Потоково выгружает задачи пользователя в CSV, JSON, NDJSON или iCalendar с учетом фильтров списка.
+// @Description SERVER_WRITE_TIMEOUT ограничивает паузу между задачами в выгрузке, а не ее общую длительность
+// @Tags Tasks
+// @Produce text/csv,application/json,application/x-ndjson,text/calendar
+// @Security BearerAuth
+// @Param format query string false "Формат выгрузки" Enums(csv, json, ndjson, ics) default(csv)
+// @Param ics_component query string false "Компонент iCalendar для формата ics" Enums(vtodo, vevent) default(vtodo)
+// @Param status query string false "Фильтр по статусу" Enums(pending, in_progress, completed)
+// @Param search query string false "Поиск по заголовку и описанию"
+// @Param due_before query string false "Срок выполнения раньше (RFC3339)"
+// @Param due_after query string false "Срок выполнения не раньше (RFC3339)"
+// @Success 200 {file} file "Файл с задачами"
+// @Failure 400 {object} model.ErrorResponseSwagger "Некорректный формат или фильтр"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Failure 503 {object} model.ErrorResponseSwagger "База данных недоступна (error_code db_unavailable)"
+// @Failure 504 {object} model.ErrorResponseSwagger "База данных не ответила вовремя (error_code db_timeout)"
+// @Router /api/v1/tasks/export [get]
+func (h *TaskHandler) ExportTasksDoc() {}
+
+// ImportTasks загружает задачи из файла
+// @Summary Импортировать задачи
+// @Description Загружает задачи из CSV (колонки title, description, status, due_date) или NDJSON. Каждая строка проверяется по правилам создания задачи, некорректные строки попадают в отчет и не прерывают импорт
+// @Tags Tasks
+// @Accept text/csv,application/x-ndjson
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "Формат файла, по умолчанию определяется по Content-Type" Enums(csv, ndjson)
+// @Param dry_run query bool false "Только проверить файл, не сохраняя задачи" default(false)
+// @Param file body string true "Содержимое файла"
+// @Success 200 {object} model.ImportReport "Отчет об импорте"
+// @Failure 400 {object} model.ErrorResponseSwagger "Неподдерживаемый формат или файл не удалось разобрать"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 413 {object} model.ErrorResponseSwagger "Файл слишком большой"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Failure 503 {object} model.ErrorResponseSwagger "База данных недоступна (error_code db_unavailable)"
+// @Failure 504 {object} model.ErrorResponseSwagger "База данных не ответила вовремя (error_code db_timeout)"
+// @Router /api/v1/tasks/import [post]
+func (h *TaskHandler) ImportTasksDoc() {}
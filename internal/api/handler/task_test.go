@@ -2,7 +2,9 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/kkboranbay/task-service/internal/mocks"
@@ -14,9 +16,11 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 type TaskHandlerTestSuite struct {
@@ -226,7 +230,7 @@ func (suite *TaskHandlerTestSuite) TestListTasks() {
 					Tasks: []model.Task{*testutils.TaskFixture()},
 					Total: 1,
 				}
-				suite.mockRepo.On("List", mock.Anything, int64(1), 10, 0).
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 10, 0).
 					Return(expectedResponse, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
@@ -246,7 +250,7 @@ func (suite *TaskHandlerTestSuite) TestListTasks() {
 					Tasks: []model.Task{},
 					Total: 10,
 				}
-				suite.mockRepo.On("List", mock.Anything, int64(1), 5, 5).
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 5, 5).
 					Return(expectedResponse, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
@@ -254,6 +258,43 @@ func (suite *TaskHandlerTestSuite) TestListTasks() {
 				assert.Equal(t, float64(10), response["total"])
 			},
 		},
+		{
+			name:        "database_timeout",
+			queryParams: "",
+			setupMock: func() {
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 10, 0).
+					Return(nil, fmt.Errorf("ошибка получения списка задач: %w", service.ErrStorageTimeout)).Once()
+			},
+			expectedStatus: http.StatusGatewayTimeout,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				assert.Equal(t, float64(504), response["code"])
+				assert.Equal(t, model.ErrorCodeDBTimeout, response["error_code"])
+			},
+		},
+		{
+			name:        "database_unavailable",
+			queryParams: "",
+			setupMock: func() {
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 10, 0).
+					Return(nil, fmt.Errorf("ошибка получения списка задач: %w", service.ErrStorageUnavailable)).Once()
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				assert.Equal(t, model.ErrorCodeDBUnavailable, response["error_code"])
+			},
+		},
+		{
+			name:        "client_canceled",
+			queryParams: "",
+			setupMock: func() {
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 10, 0).
+					Return(nil, fmt.Errorf("ошибка получения списка задач: %w", context.Canceled)).Once()
+			},
+			expectedStatus: statusClientClosedRequest,
+			checkResponse: func(t *testing.T, response map[string]interface{}) {
+				assert.Nil(t, response["error_code"])
+			},
+		},
 		{
 			name:        "invalid_page_parameter",
 			queryParams: "?page=invalid",
@@ -262,7 +303,7 @@ func (suite *TaskHandlerTestSuite) TestListTasks() {
 					Tasks: []model.Task{},
 					Total: 0,
 				}
-				suite.mockRepo.On("List", mock.Anything, int64(1), 10, 0).
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 10, 0).
 					Return(expectedResponse, nil).Once()
 			},
 			expectedStatus: http.StatusOK,
@@ -418,6 +459,277 @@ func (suite *TaskHandlerTestSuite) TestDeleteTask() {
 	}
 }
 
+func (suite *TaskHandlerTestSuite) TestExportTasks() {
+	due := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	exported := []model.Task{
+		*testutils.TaskFixture(func(t *model.Task) {
+			t.ID = 1
+			t.Title = "первая, с запятой"
+			t.DueDate = &due
+		}),
+		*testutils.TaskFixture(func(t *model.Task) {
+			t.ID = 2
+			t.Title = "вторая"
+		}),
+	}
+	completed := model.TaskStatusCompleted
+
+	tests := []struct {
+		name                string
+		query               string
+		setupMock           func()
+		expectedStatus      int
+		expectedContentType string
+		expectedContains    []string
+		expectedMissing     []string
+	}{
+		{
+			name:  "csv_with_filter",
+			query: "?format=csv&status=completed",
+			setupMock: func() {
+				suite.mockRepo.On("Iterate", mock.Anything, int64(1), model.TaskFilter{Status: &completed}, mock.Anything).
+					Return(exported, nil).Once()
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedContains: []string{
+				"id,title,description,status,due_date,created_at,updated_at\n",
+				`1,"первая, с запятой",Test Description,pending,2024-03-01T09:00:00Z`,
+			},
+		},
+		{
+			name:  "ndjson",
+			query: "?format=ndjson",
+			setupMock: func() {
+				suite.mockRepo.On("Iterate", mock.Anything, int64(1), model.TaskFilter{}, mock.Anything).
+					Return(exported, nil).Once()
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson; charset=utf-8",
+			expectedContains:    []string{`"id":1,`, `"id":2,`},
+		},
+		{
+			name:  "empty_json",
+			query: "?format=json",
+			setupMock: func() {
+				suite.mockRepo.On("Iterate", mock.Anything, int64(1), model.TaskFilter{}, mock.Anything).
+					Return(nil, nil).Once()
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedContains:    []string{"[]"},
+		},
+		{
+			name:  "ics_events_only_with_due_date",
+			query: "?format=ics&ics_component=vevent",
+			setupMock: func() {
+				suite.mockRepo.On("Iterate", mock.Anything, int64(1), model.TaskFilter{}, mock.Anything).
+					Return(exported, nil).Once()
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/calendar; charset=utf-8",
+			expectedContains: []string{
+				"BEGIN:VEVENT\r\nUID:task-1-1@task-service",
+				"DTSTART:20240301T090000Z",
+				`SUMMARY:первая\, с запятой`,
+			},
+			expectedMissing: []string{"вторая"},
+		},
+		{
+			name:           "unsupported_format",
+			query:          "?format=xml",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid_filter",
+			query:          "?due_before=yesterday",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "repository_error_before_first_row",
+			query: "?format=csv",
+			setupMock: func() {
+				suite.mockRepo.On("Iterate", mock.Anything, int64(1), model.TaskFilter{}, mock.Anything).
+					Return(nil, errors.New("database error")).Once()
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/export"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			suite.router.ServeHTTP(w, req)
+
+			assert.Equal(suite.T(), tt.expectedStatus, w.Code)
+			if tt.expectedContentType != "" {
+				assert.Equal(suite.T(), tt.expectedContentType, w.Header().Get("Content-Type"))
+			}
+			for _, expected := range tt.expectedContains {
+				assert.Contains(suite.T(), w.Body.String(), expected)
+			}
+			for _, missing := range tt.expectedMissing {
+				assert.NotContains(suite.T(), w.Body.String(), missing)
+			}
+
+			suite.mockRepo.AssertExpectations(suite.T())
+		})
+	}
+}
+
+func (suite *TaskHandlerTestSuite) TestExportLongerThanWriteTimeout() {
+	const writeTimeout = 100 * time.Millisecond
+	suite.handler.WithWriteTimeout(writeTimeout)
+
+	// выгрузка идет дольше writeTimeout, но паузы между задачами короче него
+	suite.mockRepo.On("Iterate", mock.Anything, int64(1), model.TaskFilter{}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(3).(func(task *model.Task) error)
+			for i := int64(1); i <= 5; i++ {
+				time.Sleep(writeTimeout / 2)
+				if err := fn(testutils.TaskFixture(func(t *model.Task) { t.ID = i })); err != nil {
+					return
+				}
+			}
+		}).
+		Return(nil, nil).Once()
+
+	server := httptest.NewUnstartedServer(suite.router)
+	server.Config.WriteTimeout = writeTimeout
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/tasks/export?format=ndjson")
+	require.NoError(suite.T(), err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
+	assert.Equal(suite.T(), 5, bytes.Count(body, []byte("\n")))
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TaskHandlerTestSuite) TestImportTasks() {
+	csvBody := "title,description,status,due_date\n" +
+		"Первая,описание,pending,2024-03-01T09:00:00Z\n" +
+		",без заголовка,pending,\n" +
+		"Третья,,unknown,\n" +
+		"Четвертая,,completed,завтра\n" +
+		"Пятая,,in_progress,\n"
+
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedReport *model.ImportReport
+	}{
+		{
+			name:        "csv_with_invalid_rows",
+			contentType: "text/csv",
+			body:        csvBody,
+			setupMock: func() {
+				suite.mockRepo.On("BulkCreate", mock.Anything, int64(1), mock.MatchedBy(func(tasks []model.CreateTaskRequest) bool {
+					return len(tasks) == 2 && tasks[0].Title == "Первая" && tasks[1].Title == "Пятая"
+				})).Return(int64(2), nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedReport: &model.ImportReport{
+				Total:    5,
+				Imported: 2,
+				Failed:   3,
+				Errors: []model.ImportRowError{
+					{Row: 3, Error: "поле title не прошло проверку required"},
+					{Row: 4, Error: "некорректный статус задачи: unknown"},
+					{Row: 5, Error: "некорректный формат due_date, ожидается RFC3339"},
+				},
+			},
+		},
+		{
+			name:           "dry_run_does_not_write",
+			query:          "?dry_run=true",
+			contentType:    "text/csv",
+			body:           csvBody,
+			setupMock:      func() {},
+			expectedStatus: http.StatusOK,
+			expectedReport: &model.ImportReport{
+				DryRun: true,
+				Total:  5,
+				Failed: 3,
+				Errors: []model.ImportRowError{
+					{Row: 3, Error: "поле title не прошло проверку required"},
+					{Row: 4, Error: "некорректный статус задачи: unknown"},
+					{Row: 5, Error: "некорректный формат due_date, ожидается RFC3339"},
+				},
+			},
+		},
+		{
+			name:        "ndjson_by_format_param",
+			query:       "?format=ndjson",
+			contentType: "text/plain",
+			body:        `{"title":"из json","status":"pending"}` + "\n\n{broken\n",
+			setupMock: func() {
+				suite.mockRepo.On("BulkCreate", mock.Anything, int64(1), []model.CreateTaskRequest{
+					{Title: "из json", Status: model.TaskStatusPending},
+				}).Return(int64(1), nil).Once()
+			},
+			expectedStatus: http.StatusOK,
+			expectedReport: &model.ImportReport{
+				Total:    2,
+				Imported: 1,
+				Failed:   1,
+				Errors: []model.ImportRowError{
+					{Row: 3, Error: "некорректный JSON: invalid character 'b' looking for beginning of object key string"},
+				},
+			},
+		},
+		{
+			name:           "csv_without_title_column",
+			contentType:    "text/csv",
+			body:           "name,status\nx,pending\n",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown_content_type",
+			contentType:    "application/xml",
+			body:           "<tasks/>",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/import"+tt.query, bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			suite.router.ServeHTTP(w, req)
+
+			assert.Equal(suite.T(), tt.expectedStatus, w.Code)
+			if tt.expectedReport != nil {
+				var report model.ImportReport
+				require.NoError(suite.T(), json.Unmarshal(w.Body.Bytes(), &report))
+				assert.Equal(suite.T(), *tt.expectedReport, report)
+			}
+
+			suite.mockRepo.AssertExpectations(suite.T())
+		})
+	}
+}
+
 func TestTaskHandlerSuite(t *testing.T) {
 	suite.Run(t, new(TaskHandlerTestSuite))
 }
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/rs/zerolog"
+	"net/http"
+)
+
+// TwoFactorHandler управление TOTP 2FA для текущего пользователя
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+	userService      *service.UserService
+	log              *zerolog.Logger
+}
+
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService, userService *service.UserService, log *zerolog.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		userService:      userService,
+		log:              log,
+	}
+}
+
+func (h *TwoFactorHandler) Register(router *gin.RouterGroup) {
+	twoFactor := router.Group("/2fa", h.requireOwnSession)
+	{
+		twoFactor.GET("", h.Status)
+		twoFactor.POST("/enroll", h.Enroll)
+		twoFactor.POST("/confirm", h.Confirm)
+		twoFactor.POST("/disable", h.Disable)
+	}
+}
+
+// requireOwnSession запрещает менять 2FA из сессии имперсонации и по персональному токену
+func (h *TwoFactorHandler) requireOwnSession(c *gin.Context) {
+	_, impersonated := c.Get("impersonator_id")
+	_, accessToken := c.Get("access_token_id")
+	if impersonated || accessToken {
+		c.AbortWithStatusJSON(http.StatusForbidden, model.ErrorResponse{
+			Code:      http.StatusForbidden,
+			Message:   "настройки 2FA меняются только в сессии, открытой входом пользователя",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+	c.Next()
+}
+
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	status, err := h.twoFactorService.Status(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось получить настройки 2FA",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// Enroll выдает новый секрет и otpauth:// адрес для QR-кода
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	user, err := h.userService.GetUser(c.Request.Context(), c.GetInt64("user_id"))
+	if err != nil {
+		h.log.Error().Err(err).Msg("ошибка получения пользователя")
+		c.JSON(http.StatusInternalServerError, model.ErrorResponse{
+			Code:      http.StatusInternalServerError,
+			Message:   "не удалось начать настройку 2FA",
+			RequestID: c.GetString("request_id"),
+		})
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(c.Request.Context(), user)
+	if err != nil {
+		h.respondError(c, err, "не удалось начать настройку 2FA")
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm включает 2FA по коду из приложения и возвращает коды восстановления
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	var req model.TwoFactorCodeRequest
+	if !h.bindCode(c, &req) {
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(c.Request.Context(), c.GetInt64("user_id"), req.Code)
+	if err != nil {
+		h.respondError(c, err, "не удалось включить 2FA")
+		return
+	}
+
+	c.JSON(http.StatusOK, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req model.TwoFactorCodeRequest
+	if !h.bindCode(c, &req) {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.Request.Context(), c.GetInt64("user_id"), req.Code); err != nil {
+		h.respondError(c, err, "не удалось отключить 2FA")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TwoFactorHandler) bindCode(c *gin.Context, req *model.TwoFactorCodeRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		h.log.Error().Err(err).Msg("ошибка разбора JSON")
+		c.JSON(http.StatusBadRequest, model.ErrorResponse{
+			Code:      http.StatusBadRequest,
+			Message:   "некорректные данные запроса",
+			RequestID: c.GetString("request_id"),
+		})
+		return false
+	}
+	return true
+}
+
+func (h *TwoFactorHandler) respondError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		status = http.StatusUnauthorized
+	case errors.Is(err, service.ErrTwoFactorEnabled):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrTwoFactorRequired):
+		status = http.StatusForbidden
+	}
+
+	if status != http.StatusInternalServerError {
+		message = err.Error()
+	}
+	c.JSON(status, model.ErrorResponse{
+		Code:      status,
+		Message:   message,
+		RequestID: c.GetString("request_id"),
+	})
+}
@@ -0,0 +1,63 @@
+package handler
+
+// Swagger аннотации для TwoFactor хендлеров
+
+// TwoFactorStatus состояние 2FA
+// @Summary Состояние двухфакторной аутентификации
+// @Description Включена ли 2FA, требует ли ее политика и сколько осталось кодов восстановления
+// @Tags TwoFactor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.TwoFactorStatus "Состояние 2FA"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Сессия имперсонации или персональный токен"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/2fa [get]
+func (h *TwoFactorHandler) StatusDoc() {}
+
+// TwoFactorEnroll начало настройки 2FA
+// @Summary Начать настройку 2FA
+// @Description Создает новый секрет TOTP и возвращает otpauth:// адрес для QR-кода. 2FA включается после подтверждения кодом
+// @Tags TwoFactor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.TwoFactorEnrollment "Секрет и адрес для QR-кода"
+// @Failure 401 {object} model.ErrorResponseSwagger "Не авторизован"
+// @Failure 403 {object} model.ErrorResponseSwagger "Сессия имперсонации или персональный токен"
+// @Failure 409 {object} model.ErrorResponseSwagger "2FA уже включена"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/2fa/enroll [post]
+func (h *TwoFactorHandler) EnrollDoc() {}
+
+// TwoFactorConfirm подтверждение настройки 2FA
+// @Summary Включить 2FA
+// @Description Подтверждает настройку кодом из приложения и возвращает коды восстановления. Коды показываются один раз
+// @Tags TwoFactor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body model.TwoFactorCodeRequest true "Код из приложения"
+// @Success 200 {object} model.RecoveryCodesResponse "Коды восстановления"
+// @Failure 400 {object} model.ErrorResponseSwagger "Некорректные данные запроса"
+// @Failure 401 {object} model.ErrorResponseSwagger "Неверный код"
+// @Failure 404 {object} model.ErrorResponseSwagger "Настройка 2FA не начата"
+// @Failure 409 {object} model.ErrorResponseSwagger "2FA уже включена"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/2fa/confirm [post]
+func (h *TwoFactorHandler) ConfirmDoc() {}
+
+// TwoFactorDisable отключение 2FA
+// @Summary Отключить 2FA
+// @Description Отключает 2FA после проверки кода из приложения или кода восстановления. Недоступно, если 2FA обязательна
+// @Tags TwoFactor
+// @Accept json
+// @Security BearerAuth
+// @Param request body model.TwoFactorCodeRequest true "Код из приложения или код восстановления"
+// @Success 204 "2FA отключена"
+// @Failure 400 {object} model.ErrorResponseSwagger "Некорректные данные запроса"
+// @Failure 401 {object} model.ErrorResponseSwagger "Неверный код"
+// @Failure 403 {object} model.ErrorResponseSwagger "2FA обязательна политикой"
+// @Failure 404 {object} model.ErrorResponseSwagger "2FA не включена"
+// @Failure 500 {object} model.ErrorResponseSwagger "Внутренняя ошибка сервера"
+// @Router /api/v1/2fa/disable [post]
+func (h *TwoFactorHandler) DisableDoc() {}
@@ -1,11 +1,15 @@
 package middleware
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/jwtkeys"
 	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/secrets"
 	"github.com/rs/zerolog"
 	"net/http"
 	"strings"
@@ -13,95 +17,272 @@ import (
 )
 
 type UserClaims struct {
-	UserID int64 `json:"user_id"`
+	UserID int64      `json:"user_id"`
+	Role   model.Role `json:"role,omitempty"`
+	Scopes []string   `json:"scopes,omitempty"`
+	// ImpersonatorID администратор, который действует от имени UserID
+	ImpersonatorID int64 `json:"impersonator_id,omitempty"`
+	// AccessTokenID персональный токен, которым аутентифицирован запрос; в JWT не передается
+	AccessTokenID int64 `json:"-"`
+	// Purpose назначение служебного токена; такие токены не дают доступа к API
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// purposeTwoFactor токен между проверкой пароля и вводом кода второго фактора
+const purposeTwoFactor = "2fa"
+
+// AccessTokenAuthenticator проверяет персональные токены доступа
+type AccessTokenAuthenticator interface {
+	AuthenticateAccessToken(ctx context.Context, secret string) (*model.AccessToken, error)
+}
+
 type JWTMiddleware struct {
-	config config.AuthConfig
-	log    *zerolog.Logger
+	config       config.AuthConfig
+	log          *zerolog.Logger
+	accessTokens AccessTokenAuthenticator
+	keys         *jwtkeys.KeySet
+	secrets      *secrets.Store
 }
 
 func NewJWTMiddleware(config config.AuthConfig, logger *zerolog.Logger) *JWTMiddleware {
-	return &JWTMiddleware{config, logger}
+	return &JWTMiddleware{config: config, log: logger}
+}
+
+// WithKeySet переключает подпись на асимметричные ключи; HS256 токены после этого принимаются
+// только при AcceptHS256, чтобы уже выданные токены дожили до истечения
+func (m *JWTMiddleware) WithKeySet(keys *jwtkeys.KeySet) *JWTMiddleware {
+	m.keys = keys
+	return m
+}
+
+// WithSecrets берет JWT_SECRET из хранилища секретов при каждой подписи и проверке.
+// После ротации токены, подписанные предыдущим секретом, принимаются до истечения срока
+func (m *JWTMiddleware) WithSecrets(store *secrets.Store) *JWTMiddleware {
+	m.secrets = store
+	return m
+}
+
+// JWKS открытые ключи проверки токенов; при подписи HS256 список пуст
+func (m *JWTMiddleware) JWKS() jwtkeys.JWKS {
+	if m.keys == nil {
+		return jwtkeys.JWKS{Keys: []jwtkeys.JWK{}}
+	}
+	return m.keys.JWKS()
+}
+
+// WithAccessTokens включает прием персональных токенов в Authorization: Bearer и X-API-Key
+func (m *JWTMiddleware) WithAccessTokens(accessTokens AccessTokenAuthenticator) *JWTMiddleware {
+	m.accessTokens = accessTokens
+	return m
 }
 
 func (m *JWTMiddleware) AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-				Code:    http.StatusUnauthorized,
-				Message: "токен аутентификации отсутствует",
-			})
-			c.Abort()
-			return
+		tokenString := c.GetHeader("X-API-Key")
+		if tokenString == "" {
+			authHeader := c.GetHeader("Authorization")
+			if authHeader == "" {
+				c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+					Code:      http.StatusUnauthorized,
+					Message:   "токен аутентификации отсутствует",
+					RequestID: c.GetString("request_id"),
+				})
+				c.Abort()
+				return
+			}
+
+			splitToken := strings.Split(authHeader, "Bearer ")
+			if len(splitToken) != 2 {
+				c.JSON(http.StatusUnauthorized, model.ErrorResponse{
+					Code:      http.StatusUnauthorized,
+					Message:   "неверный формат токена",
+					RequestID: c.GetString("request_id"),
+				})
+				c.Abort()
+				return
+			}
+
+			tokenString = splitToken[1]
 		}
 
-		splitToken := strings.Split(authHeader, "Bearer ")
-		if len(splitToken) != 2 {
+		claims, err := m.Authenticate(c.Request.Context(), tokenString)
+		if err != nil {
+			m.log.Error().Err(err).Msg("ошибка проверки токена аутентификации")
 			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-				Code:    http.StatusUnauthorized,
-				Message: "неверный формат токена",
+				Code:      http.StatusUnauthorized,
+				Message:   "недействительный токен аутентификации",
+				RequestID: c.GetString("request_id"),
 			})
 			c.Abort()
 			return
 		}
 
-		tokenString := splitToken[1]
+		setClaims(c, claims)
 
-		claims := &UserClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("неожиданный алгоритм подписи")
-			}
+		c.Next()
+	}
+}
 
-			return []byte(m.config.JWTSecret), nil
-		})
+// Authenticate принимает JWT или персональный токен доступа и возвращает claims запроса
+func (m *JWTMiddleware) Authenticate(ctx context.Context, tokenString string) (*UserClaims, error) {
+	if !strings.HasPrefix(tokenString, model.AccessTokenPrefix) {
+		return m.ParseToken(tokenString)
+	}
 
-		if err != nil {
-			m.log.Error().Err(err).Str("token", tokenString).Msg("ошибка парсинга JWT токена")
-			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-				Code:    http.StatusUnauthorized,
-				Message: "недействительный токен аутентификации",
-			})
-			c.Abort()
-			return
-		}
+	if m.accessTokens == nil {
+		return nil, errors.New("персональные токены доступа не поддерживаются")
+	}
 
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, model.ErrorResponse{
-				Code:    http.StatusUnauthorized,
-				Message: "недействительный токен аутентификации",
-			})
-			c.Abort()
-			return
+	token, err := m.accessTokens.AuthenticateAccessToken(ctx, tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserClaims{
+		UserID:        token.UserID,
+		Role:          token.Role,
+		Scopes:        token.Scopes,
+		AccessTokenID: token.ID,
+	}, nil
+}
+
+// ParseToken проверяет подпись и срок действия токена доступа и возвращает его claims
+func (m *JWTMiddleware) ParseToken(tokenString string) (*UserClaims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != "" {
+		return nil, errors.New("токен не предназначен для доступа к API")
+	}
+
+	return claims, nil
+}
+
+// ParseChallengeToken проверяет токен, выданный для ввода кода второго фактора
+func (m *JWTMiddleware) ParseChallengeToken(tokenString string) (*UserClaims, error) {
+	claims, err := m.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != purposeTwoFactor {
+		return nil, errors.New("токен не предназначен для подтверждения входа")
+	}
+
+	return claims, nil
+}
+
+func (m *JWTMiddleware) parse(tokenString string) (*UserClaims, error) {
+	claims := &UserClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, m.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("недействительный токен")
+	}
+
+	return claims, nil
+}
+
+// verificationKey выбирает ключ проверки по алгоритму и kid из заголовка токена
+func (m *JWTMiddleware) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if m.keys != nil && !m.config.AcceptHS256 {
+			return nil, errors.New("подпись HS256 отключена")
 		}
+		return m.hmacVerificationKeys(), nil
+	}
 
-		c.Set("user_id", claims.UserID)
+	if m.keys == nil {
+		return nil, errors.New("неожиданный алгоритм подписи")
+	}
 
-		c.Next()
+	kid, _ := token.Header["kid"].(string)
+	key, ok := m.keys.VerificationKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("неизвестный ключ подписи %q", kid)
 	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, errors.New("неожиданный алгоритм подписи")
+	}
+
+	return key.Public, nil
 }
 
-func (m *JWTMiddleware) GenerateToken(userID int64) (string, error) {
-	now := time.Now()
-	claims := UserClaims{
+func (m *JWTMiddleware) GenerateToken(userID int64, role model.Role) (string, error) {
+	token, _, err := m.signToken(UserClaims{
 		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(m.config.TokenExpireDelta)),
-			IssuedAt:  jwt.NewNumericDate(now),
-			NotBefore: jwt.NewNumericDate(now),
-		},
+		Role:   role,
+		Scopes: model.DefaultScopes(role),
+	}, m.config.TokenExpireDelta)
+	return token, err
+}
+
+// GenerateImpersonationToken выпускает короткоживущий токен пользователя userID для администратора impersonatorID.
+// Права администратора в такой токен не переносятся
+func (m *JWTMiddleware) GenerateImpersonationToken(userID int64, role model.Role, impersonatorID int64) (string, time.Time, error) {
+	return m.signToken(UserClaims{
+		UserID:         userID,
+		Role:           role,
+		Scopes:         model.DefaultScopes(role),
+		ImpersonatorID: impersonatorID,
+	}, m.config.ImpersonationTTL)
+}
+
+// GenerateChallengeToken выпускает короткоживущий токен второго шага входа после проверки пароля
+func (m *JWTMiddleware) GenerateChallengeToken(userID int64, role model.Role) (string, time.Time, error) {
+	return m.signToken(UserClaims{
+		UserID:  userID,
+		Role:    role,
+		Purpose: purposeTwoFactor,
+	}, m.config.TwoFactorChallengeTTL)
+}
+
+func (m *JWTMiddleware) signToken(claims UserClaims, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
 	}
 
+	var signingKey interface{} = m.hmacSecret()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if m.keys != nil {
+		key := m.keys.SigningKey()
+		token = jwt.NewWithClaims(key.Method(), claims)
+		token.Header["kid"] = key.ID
+		signingKey = key.Private()
+	}
 
-	signedToken, err := token.SignedString([]byte(m.config.JWTSecret))
+	signedToken, err := token.SignedString(signingKey)
 	if err != nil {
-		m.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка подписи JWT токена")
-		return "", err
+		m.log.Error().Err(err).Int64("user_id", claims.UserID).Msg("ошибка подписи JWT токена")
+		return "", time.Time{}, err
+	}
+
+	return signedToken, expiresAt, nil
+}
+
+func (m *JWTMiddleware) hmacSecret() []byte {
+	if m.secrets != nil {
+		return []byte(m.secrets.Get("JWT_SECRET"))
 	}
+	return []byte(m.config.JWTSecret)
+}
 
-	return signedToken, nil
+func (m *JWTMiddleware) hmacVerificationKeys() jwt.VerificationKeySet {
+	keys := jwt.VerificationKeySet{Keys: []jwt.VerificationKey{m.hmacSecret()}}
+	if m.secrets != nil {
+		if previous := m.secrets.Previous("JWT_SECRET"); previous != "" {
+			keys.Keys = append(keys.Keys, []byte(previous))
+		}
+	}
+	return keys
 }
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/jwtkeys"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/secrets"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKey(t *testing.T, name string, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func TestKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	oldFile := writeKey(t, "old.pem", rsaKey)
+	newFile := writeKey(t, "new.pem", edKey)
+
+	logger := zerolog.Nop()
+	cfg := config.AuthConfig{JWTSecret: "legacy-secret", TokenExpireDelta: time.Hour}
+
+	legacy := NewJWTMiddleware(cfg, &logger)
+	legacyToken, err := legacy.GenerateToken(1, model.RoleUser)
+	require.NoError(t, err)
+
+	oldKeys, err := jwtkeys.Load(oldFile, nil)
+	require.NoError(t, err)
+	m := NewJWTMiddleware(cfg, &logger).WithKeySet(oldKeys)
+
+	oldToken, err := m.GenerateToken(1, model.RoleUser)
+	require.NoError(t, err)
+
+	// HS256 токены не принимаются после перехода на ключи, если это явно не разрешено
+	_, err = m.ParseToken(legacyToken)
+	assert.Error(t, err)
+
+	// шаг 2 ротации: подписываем новым ключом, старый остается для проверки
+	rotated, err := jwtkeys.Load(newFile, []string{oldFile})
+	require.NoError(t, err)
+	oldKeys.Replace(rotated)
+
+	newToken, err := m.GenerateToken(2, model.RoleAdmin)
+	require.NoError(t, err)
+
+	claims, err := m.ParseToken(oldToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+
+	claims, err = m.ParseToken(newToken)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, claims.Role)
+	assert.Len(t, m.JWKS().Keys, 2)
+
+	// шаг 3: старый ключ удален, выданные им токены больше не принимаются
+	final, err := jwtkeys.Load(newFile, nil)
+	require.NoError(t, err)
+	oldKeys.Replace(final)
+
+	_, err = m.ParseToken(oldToken)
+	assert.Error(t, err)
+	_, err = m.ParseToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestAcceptHS256DuringMigration(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys, err := jwtkeys.Load(writeKey(t, "key.pem", edKey), nil)
+	require.NoError(t, err)
+
+	logger := zerolog.Nop()
+	cfg := config.AuthConfig{JWTSecret: "legacy-secret", TokenExpireDelta: time.Hour}
+	legacyToken, err := NewJWTMiddleware(cfg, &logger).GenerateToken(1, model.RoleUser)
+	require.NoError(t, err)
+
+	cfg.AcceptHS256 = true
+	m := NewJWTMiddleware(cfg, &logger).WithKeySet(keys)
+
+	_, err = m.ParseToken(legacyToken)
+	assert.NoError(t, err)
+}
+
+func TestHMACSecretRotation(t *testing.T) {
+	logger := zerolog.Nop()
+	store := secrets.NewStore(map[string]string{"JWT_SECRET": "first-secret"})
+	m := NewJWTMiddleware(config.AuthConfig{JWTSecret: "first-secret", TokenExpireDelta: time.Hour}, &logger).WithSecrets(store)
+
+	oldToken, err := m.GenerateToken(1, model.RoleUser)
+	require.NoError(t, err)
+
+	store.Update(map[string]string{"JWT_SECRET": "second-secret"})
+
+	newToken, err := m.GenerateToken(1, model.RoleUser)
+	require.NoError(t, err)
+
+	// новые токены подписываются новым секретом, выданные ранее остаются действительными
+	_, err = NewJWTMiddleware(config.AuthConfig{JWTSecret: "second-secret"}, &logger).ParseToken(newToken)
+	assert.NoError(t, err)
+	_, err = m.ParseToken(oldToken)
+	assert.NoError(t, err)
+
+	// после следующей ротации первый секрет больше не принимается
+	store.Update(map[string]string{"JWT_SECRET": "third-secret"})
+	_, err = m.ParseToken(oldToken)
+	assert.Error(t, err)
+	_, err = m.ParseToken(newToken)
+	assert.NoError(t, err)
+}
@@ -1,12 +1,22 @@
 package middleware
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
+	"strings"
 	"time"
 )
 
+// RequestIDHeader заголовок с ID запроса: принимается от клиента или балансировщика
+// и возвращается в ответе
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину чужого ID, чтобы он не раздувал журнал
+const maxRequestIDLength = 128
+
 type RequestLogger struct {
 	log *zerolog.Logger
 }
@@ -15,27 +25,46 @@ func NewRequestLogger(log *zerolog.Logger) *RequestLogger {
 	return &RequestLogger{log}
 }
 
+// Middleware назначает запросу ID и кладет в контекст запроса журнал с полем request_id,
+// который сервисы получают через logger.FromContext
 func (l *RequestLogger) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
 		method := c.Request.Method
 
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		log := l.log.With().Str("request_id", requestID).Logger()
+		c.Request = c.Request.WithContext(log.WithContext(c.Request.Context()))
+
 		c.Next()
 
 		duration := time.Since(start)
 		status := c.Writer.Status()
+		path = redactPathParams(c, path)
 
-		logEvent := l.log.Info()
+		logEvent := log.Info()
 
 		if status >= 400 {
-			logEvent = l.log.Warn()
+			logEvent = log.Warn()
 		}
 		if status >= 500 {
-			logEvent = l.log.Error()
+			logEvent = log.Error()
+		}
+
+		// запросы администратора от имени пользователя помечаются для журнала аудита
+		if _, ok := c.Get("impersonator_id"); ok {
+			logEvent = logEvent.Int64("impersonator_id", c.GetInt64("impersonator_id")).Int64("user_id", c.GetInt64("user_id"))
 		}
 
 		logEvent.
+			Ctx(c.Request.Context()).
 			Str("method", method).
 			Str("path", path).
 			Int("status", status).
@@ -45,3 +74,36 @@ func (l *RequestLogger) Middleware() gin.HandlerFunc {
 			Msg(fmt.Sprintf("%s %s %d %s", method, path, status, duration))
 	}
 }
+
+// redactPathParams скрывает секреты из пути, например токен в ссылке на календарь
+func redactPathParams(c *gin.Context, path string) string {
+	if token := c.Param("token"); token != "" {
+		path = strings.Replace(path, token, "[REDACTED]", 1)
+	}
+	return path
+}
+
+// validRequestID пропускает только ID из печатных символов без пробелов, чтобы клиент
+// не мог подделать строки журнала
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
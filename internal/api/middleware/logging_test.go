@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/pkg/logger"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "accepts_client_id", header: "req-42.a_b:c", expected: "req-42.a_b:c"},
+		{name: "generates_missing_id"},
+		{name: "replaces_id_with_spaces", header: "id\nподдельная строка"},
+		{name: "replaces_long_id", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log := zerolog.New(&logs)
+			fallback := zerolog.Nop()
+
+			router := gin.New()
+			router.Use(NewRequestLogger(&log).Middleware())
+			router.GET("/tasks", func(c *gin.Context) {
+				logger.FromContext(c.Request.Context(), &fallback).Info().Msg("обработка запроса")
+				c.JSON(http.StatusNotFound, model.ErrorResponse{
+					Code:      http.StatusNotFound,
+					Message:   "задача не найдена",
+					RequestID: c.GetString("request_id"),
+				})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(RequestIDHeader)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, requestID)
+			} else {
+				assert.Len(t, requestID, 32)
+			}
+
+			var resp model.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, requestID, resp.RequestID)
+
+			// запись обработчика и итоговая запись о запросе содержат один и тот же ID
+			lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+			require.Len(t, lines, 2)
+			for _, line := range lines {
+				assert.Contains(t, line, `"request_id":"`+requestID+`"`)
+			}
+		})
+	}
+}
+
+func TestRequestLoggerRedactsPathToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	log := zerolog.New(&logs)
+
+	router := gin.New()
+	router.Use(NewRequestLogger(&log).Middleware())
+	router.GET("/calendar/:token", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/calendar/s3cr3t-feed-token", nil))
+
+	assert.NotContains(t, logs.String(), "s3cr3t-feed-token")
+	assert.Contains(t, logs.String(), `"path":"/calendar/[REDACTED]"`)
+}
@@ -2,49 +2,20 @@ package middleware
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/kkboranbay/task-service/internal/metrics"
 	"strconv"
 	"time"
 )
 
-var (
-	httpRequestsTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests",
-		},
-		[]string{"method", "path", "status"},
-	)
-
-	httpRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "HTTP request duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"method", "path", "status"},
-	)
-
-	httpRequestsInFlight = promauto.NewGauge(
-		prometheus.GaugeOpts{
-			Name: "http_requests_in_flight",
-			Help: "Current number of HTTP requests being processed",
-		},
-	)
-)
-
-func PrometheusMiddleware() gin.HandlerFunc {
+func PrometheusMiddleware(m *metrics.Metrics) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		httpRequestsInFlight.Inc()
-		defer httpRequestsInFlight.Dec()
+		done := m.HTTPRequestStarted()
+		defer done()
 
 		start := time.Now()
 
 		c.Next()
 
-		duration := time.Since(start).Seconds()
-
 		method := c.Request.Method
 		path := c.FullPath()
 		if path == "" {
@@ -52,7 +23,6 @@ func PrometheusMiddleware() gin.HandlerFunc {
 		}
 		status := strconv.Itoa(c.Writer.Status())
 
-		httpRequestsTotal.WithLabelValues(method, path, status).Inc()
-		httpRequestDuration.WithLabelValues(method, path, status).Observe(duration)
+		m.ObserveHTTPRequest(method, path, status, time.Since(start))
 	}
 }
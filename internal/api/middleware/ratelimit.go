@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/metrics"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/ratelimit"
+	"github.com/rs/zerolog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// LimitFunc выбирает лимит из текущих настроек; настройки могут меняться без перезапуска
+type LimitFunc func(cfg config.RateLimitConfig) ratelimit.Limit
+
+// UserLimit лимит для запросов аутентифицированного пользователя
+func UserLimit(cfg config.RateLimitConfig) ratelimit.Limit {
+	return ratelimit.Limit{Rate: cfg.UserRate, Burst: cfg.UserBurst}
+}
+
+// LoginLimit лимит для попыток входа с одного адреса
+func LoginLimit(cfg config.RateLimitConfig) ratelimit.Limit {
+	return ratelimit.Limit{Rate: cfg.LoginRate, Burst: cfg.LoginBurst}
+}
+
+type RateLimiter struct {
+	store   ratelimit.Store
+	cfg     atomic.Pointer[config.RateLimitConfig]
+	metrics *metrics.Metrics
+	log     *zerolog.Logger
+}
+
+func NewRateLimiter(store ratelimit.Store, cfg config.RateLimitConfig, log *zerolog.Logger) *RateLimiter {
+	r := &RateLimiter{
+		store: store,
+		log:   log,
+	}
+	r.cfg.Store(&cfg)
+	return r
+}
+
+// WithMetrics включает счетчик отклоненных запросов
+func (r *RateLimiter) WithMetrics(m *metrics.Metrics) *RateLimiter {
+	r.metrics = m
+	return r
+}
+
+// Update применяет новые лимиты к следующим запросам; хранилище при этом не меняется
+func (r *RateLimiter) Update(cfg config.RateLimitConfig) {
+	r.cfg.Store(&cfg)
+}
+
+// ByUser ограничивает запросы по user_id, поэтому подключается после AuthRequired()
+func (r *RateLimiter) ByUser(limit LimitFunc) gin.HandlerFunc {
+	return r.limit("user", limit, func(c *gin.Context) (string, bool) {
+		userID, ok := c.Get("user_id")
+		if !ok {
+			return "", false
+		}
+		id, ok := userID.(int64)
+		if !ok {
+			return "", false
+		}
+		return "user:" + strconv.FormatInt(id, 10), true
+	})
+}
+
+// ByIP ограничивает запросы по адресу клиента; используется там, где пользователь еще не известен
+func (r *RateLimiter) ByIP(scope string, limit LimitFunc) gin.HandlerFunc {
+	return r.limit(scope, limit, func(c *gin.Context) (string, bool) {
+		return scope + ":" + c.ClientIP(), true
+	})
+}
+
+func (r *RateLimiter) limit(scope string, limit LimitFunc, key func(c *gin.Context) (string, bool)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := r.cfg.Load()
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		k, ok := key(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		result, err := r.store.Take(c.Request.Context(), k, limit(*cfg))
+		if err != nil {
+			// недоступность хранилища лимитов не должна останавливать сервис
+			r.log.Error().Err(err).Str("scope", scope).Msg("ошибка проверки лимита запросов")
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", formatSeconds(result.ResetAfter))
+
+		if !result.Allowed {
+			r.metrics.RateLimitRejected(scope)
+			r.log.Warn().Str("scope", scope).Str("key", k).Msg("превышен лимит запросов")
+
+			c.Header("Retry-After", formatSeconds(result.RetryAfter))
+			c.JSON(http.StatusTooManyRequests, model.ErrorResponse{
+				Code:      http.StatusTooManyRequests,
+				Message:   "слишком много запросов",
+				RequestID: c.GetString("request_id"),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// formatSeconds округляет длительность вверх до целых секунд, как ожидают клиенты в Retry-After
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
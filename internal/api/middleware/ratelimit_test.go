@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/ratelimit"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiterUpdate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), config.RateLimitConfig{
+		Enabled:    true,
+		LoginRate:  0.001,
+		LoginBurst: 1,
+	}, &logger)
+
+	router := gin.New()
+	router.GET("/login", limiter.ByIP("login", LoginLimit), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	request := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/login", nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request())
+	assert.Equal(t, http.StatusTooManyRequests, request())
+
+	// отключение лимитов действует на следующий запрос без пересоздания маршрутов
+	limiter.Update(config.RateLimitConfig{LoginRate: 0.001, LoginBurst: 1})
+	assert.Equal(t, http.StatusOK, request())
+
+	// после повторного включения состояние корзины в хранилище сохраняется
+	limiter.Update(config.RateLimitConfig{Enabled: true, LoginRate: 0.001, LoginBurst: 1})
+	assert.Equal(t, http.StatusTooManyRequests, request())
+}
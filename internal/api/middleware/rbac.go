@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/model"
+	"net/http"
+	"slices"
+)
+
+// EffectiveRole роль из токена; токены без роли выпущены до ее появления и считаются пользовательскими
+func (c *UserClaims) EffectiveRole() model.Role {
+	if c.Role == "" {
+		return model.RoleUser
+	}
+	return c.Role
+}
+
+// EffectiveScopes права токена; токены, выпущенные до появления scopes, получают права роли
+func (c *UserClaims) EffectiveScopes() []string {
+	if len(c.Scopes) == 0 {
+		return model.DefaultScopes(c.EffectiveRole())
+	}
+	return c.Scopes
+}
+
+func (c *UserClaims) HasScope(scope string) bool {
+	return slices.Contains(c.EffectiveScopes(), scope)
+}
+
+// setClaims переносит claims токена в контекст запроса для хендлеров и RequireRole/RequireScope
+func setClaims(c *gin.Context, claims *UserClaims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("role", claims.EffectiveRole())
+	c.Set("scopes", claims.EffectiveScopes())
+	if claims.ImpersonatorID != 0 {
+		c.Set("impersonator_id", claims.ImpersonatorID)
+	}
+	if claims.AccessTokenID != 0 {
+		c.Set("access_token_id", claims.AccessTokenID)
+	}
+}
+
+// RequireRole пропускает пользователей с одной из ролей; подключается после AuthRequired()
+func RequireRole(roles ...model.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		if r, ok := role.(model.Role); !ok || !slices.Contains(roles, r) {
+			forbidden(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope пропускает запрос, только если у токена есть все перечисленные права
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasScopes(c, scopes...) {
+			forbidden(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireMethodScope требует read для безопасных методов и write для остальных
+func RequireMethodScope(read, write string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := write
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			scope = read
+		}
+
+		if !hasScopes(c, scope) {
+			forbidden(c)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func hasScopes(c *gin.Context, required ...string) bool {
+	value, _ := c.Get("scopes")
+	granted, _ := value.([]string)
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func forbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, model.ErrorResponse{
+		Code:      http.StatusForbidden,
+		Message:   "недостаточно прав",
+		RequestID: c.GetString("request_id"),
+	})
+	c.Abort()
+}
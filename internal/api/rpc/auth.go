@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/model"
+	taskv1 "github.com/kkboranbay/task-service/proto/task/v1"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+type userIDKey struct{}
+
+// writeMethods вызовы, изменяющие задачи; для них нужно право tasks:write, для остальных tasks:read
+var writeMethods = map[string]bool{
+	taskv1.TaskService_CreateTask_FullMethodName: true,
+	taskv1.TaskService_UpdateTask_FullMethodName: true,
+	taskv1.TaskService_DeleteTask_FullMethodName: true,
+}
+
+// AuthInterceptor проверяет JWT из метаданных "authorization" тем же способом, что и JWTMiddleware
+type AuthInterceptor struct {
+	jwtMiddleware *middleware.JWTMiddleware
+	log           *zerolog.Logger
+}
+
+func NewAuthInterceptor(jwtMiddleware *middleware.JWTMiddleware, log *zerolog.Logger) *AuthInterceptor {
+	return &AuthInterceptor{
+		jwtMiddleware: jwtMiddleware,
+		log:           log,
+	}
+}
+
+func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (i *AuthInterceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var tokenString string
+	if apiKey := md.Get("x-api-key"); len(apiKey) > 0 {
+		tokenString = apiKey[0]
+	} else {
+		if len(md.Get("authorization")) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "токен аутентификации отсутствует")
+		}
+
+		var ok bool
+		tokenString, ok = strings.CutPrefix(md.Get("authorization")[0], "Bearer ")
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "неверный формат токена")
+		}
+	}
+
+	claims, err := i.jwtMiddleware.Authenticate(ctx, tokenString)
+	if err != nil {
+		i.log.Error().Err(err).Msg("ошибка проверки токена аутентификации")
+		return nil, status.Error(codes.Unauthenticated, "недействительный токен аутентификации")
+	}
+
+	scope := model.ScopeTasksRead
+	if writeMethods[method] {
+		scope = model.ScopeTasksWrite
+	}
+	if !claims.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "недостаточно прав")
+	}
+
+	return context.WithValue(ctx, userIDKey{}, claims.UserID), nil
+}
+
+func userIDFromContext(ctx context.Context) (int64, error) {
+	userID, ok := ctx.Value(userIDKey{}).(int64)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	return userID, nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	taskv1 "github.com/kkboranbay/task-service/proto/task/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+var statusToProto = map[model.TaskStatus]taskv1.TaskStatus{
+	model.TaskStatusPending:    taskv1.TaskStatus_TASK_STATUS_PENDING,
+	model.TaskStatusInProgress: taskv1.TaskStatus_TASK_STATUS_IN_PROGRESS,
+	model.TaskStatusCompleted:  taskv1.TaskStatus_TASK_STATUS_COMPLETED,
+}
+
+var statusFromProto = map[taskv1.TaskStatus]model.TaskStatus{
+	taskv1.TaskStatus_TASK_STATUS_PENDING:     model.TaskStatusPending,
+	taskv1.TaskStatus_TASK_STATUS_IN_PROGRESS: model.TaskStatusInProgress,
+	taskv1.TaskStatus_TASK_STATUS_COMPLETED:   model.TaskStatusCompleted,
+}
+
+var eventTypeToProto = map[service.TaskEventType]taskv1.TaskEventType{
+	service.TaskEventCreated: taskv1.TaskEventType_TASK_EVENT_TYPE_CREATED,
+	service.TaskEventUpdated: taskv1.TaskEventType_TASK_EVENT_TYPE_UPDATED,
+	service.TaskEventDeleted: taskv1.TaskEventType_TASK_EVENT_TYPE_DELETED,
+}
+
+func taskToProto(task *model.Task) *taskv1.Task {
+	return &taskv1.Task{
+		Id:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      statusToProto[task.Status],
+		UserId:      task.UserID,
+		DueDate:     timeToProto(task.DueDate),
+		CreatedAt:   timestamppb.New(task.CreatedAt),
+		UpdatedAt:   timestamppb.New(task.UpdatedAt),
+	}
+}
+
+func timeToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func timeFromProto(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
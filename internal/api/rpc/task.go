@@ -0,0 +1,220 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/kkboranbay/task-service/pkg/logger"
+	taskv1 "github.com/kkboranbay/task-service/proto/task/v1"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"strings"
+	"sync"
+)
+
+type TaskServer struct {
+	taskv1.UnimplementedTaskServiceServer
+	taskService *service.TaskService
+	log         *zerolog.Logger
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+func NewTaskServer(taskService *service.TaskService, log *zerolog.Logger) *TaskServer {
+	return &TaskServer{
+		taskService: taskService,
+		log:         log,
+		done:        make(chan struct{}),
+	}
+}
+
+// Close завершает открытые WatchTasks потоки, чтобы GracefulStop не ждал их до таймаута
+func (s *TaskServer) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *TaskServer) CreateTask(ctx context.Context, req *taskv1.CreateTaskRequest) (*taskv1.Task, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(req.GetTitle()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "некорректные данные запроса")
+	}
+
+	createReq := model.CreateTaskRequest{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		DueDate:     timeFromProto(req.GetDueDate()),
+	}
+	if req.GetStatus() != taskv1.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		taskStatus, ok := statusFromProto[req.GetStatus()]
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "некорректный статус задачи")
+		}
+		createReq.Status = taskStatus
+	}
+
+	task, err := s.taskService.CreateTask(ctx, userID, createReq)
+	if err != nil {
+		logger.Error(s.log, err).Msg("ошибка создания задачи")
+		if err := storageStatus(err); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, "не удалось создать задачу")
+	}
+
+	return taskToProto(task), nil
+}
+
+func (s *TaskServer) GetTask(ctx context.Context, req *taskv1.GetTaskRequest) (*taskv1.Task, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := s.taskService.GetTaskByID(ctx, req.GetId(), userID)
+	if err != nil {
+		logger.Error(s.log, err).Int64("id", req.GetId()).Msg("ошибка получения задачи")
+		if err := storageStatus(err); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.NotFound, "задача не найдена")
+	}
+
+	return taskToProto(task), nil
+}
+
+func (s *TaskServer) ListTasks(ctx context.Context, req *taskv1.ListTasksRequest) (*taskv1.ListTasksResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.taskService.GetTaskList(ctx, userID, model.TaskFilter{}, int(req.GetPage()), int(req.GetPageSize()))
+	if err != nil {
+		logger.Error(s.log, err).Msg("ошибка получения списка задач")
+		if err := storageStatus(err); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, "не удалось получить список задач")
+	}
+
+	tasks := make([]*taskv1.Task, 0, len(resp.Tasks))
+	for i := range resp.Tasks {
+		tasks = append(tasks, taskToProto(&resp.Tasks[i]))
+	}
+
+	return &taskv1.ListTasksResponse{
+		Tasks: tasks,
+		Total: resp.Total,
+	}, nil
+}
+
+func (s *TaskServer) UpdateTask(ctx context.Context, req *taskv1.UpdateTaskRequest) (*taskv1.Task, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updateReq := model.UpdateTaskRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		DueDate:     timeFromProto(req.GetDueDate()),
+	}
+	if req.GetStatus() != taskv1.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		taskStatus, ok := statusFromProto[req.GetStatus()]
+		if !ok {
+			return nil, status.Error(codes.InvalidArgument, "некорректный статус задачи")
+		}
+		updateReq.Status = &taskStatus
+	}
+
+	task, err := s.taskService.UpdateTask(ctx, req.GetId(), userID, updateReq)
+	if err != nil {
+		logger.Error(s.log, err).Int64("id", req.GetId()).Msg("ошибка обновления задачи")
+		if err := storageStatus(err); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.NotFound, "задача не найдена или не удалось обновить")
+	}
+
+	return taskToProto(task), nil
+}
+
+func (s *TaskServer) DeleteTask(ctx context.Context, req *taskv1.DeleteTaskRequest) (*emptypb.Empty, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.taskService.DeleteTask(ctx, req.GetId(), userID); err != nil {
+		logger.Error(s.log, err).Int64("id", req.GetId()).Msg("ошибка удаления задачи")
+		if err := storageStatus(err); err != nil {
+			return nil, err
+		}
+		return nil, status.Error(codes.NotFound, "задача не найдена или не удалось удалить")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *TaskServer) WatchTasks(_ *taskv1.WatchTasksRequest, stream taskv1.TaskService_WatchTasksServer) error {
+	userID, err := userIDFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	events, unsubscribe := s.taskService.Subscribe(userID)
+	defer unsubscribe()
+
+	// заголовки отправляются после подписки: клиент может дождаться их и не пропустить события
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	s.log.Info().Int64("user_id", userID).Msg("подписка на события задач")
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.done:
+			return status.Error(codes.Unavailable, "сервер останавливается")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			err := stream.Send(&taskv1.TaskEvent{
+				Type: eventTypeToProto[event.Type],
+				Task: taskToProto(&event.Task),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// storageStatus переводит таймаут и недоступность базы в DeadlineExceeded и Unavailable,
+// чтобы клиент мог повторить вызов, а отмену вызова клиентом — в Canceled; для остальных
+// ошибок возвращает nil
+func storageStatus(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "вызов отменен клиентом")
+	case errors.Is(err, service.ErrStorageTimeout):
+		return status.Error(codes.DeadlineExceeded, "база данных не ответила вовремя")
+	case errors.Is(err, service.ErrStorageUnavailable):
+		return status.Error(codes.Unavailable, "база данных недоступна")
+	default:
+		return nil
+	}
+}
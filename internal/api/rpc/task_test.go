@@ -0,0 +1,175 @@
+package rpc
+
+import (
+	"context"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/service"
+	"github.com/kkboranbay/task-service/internal/testutils"
+	taskv1 "github.com/kkboranbay/task-service/proto/task/v1"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+type TaskServerTestSuite struct {
+	suite.Suite
+	mockRepo   *mocks.MockTaskRepository
+	jwt        *middleware.JWTMiddleware
+	grpcServer *grpc.Server
+	taskServer *TaskServer
+	conn       *grpc.ClientConn
+	client     taskv1.TaskServiceClient
+}
+
+func (suite *TaskServerTestSuite) SetupTest() {
+	logger := zerolog.Nop()
+	suite.mockRepo = new(mocks.MockTaskRepository)
+	suite.jwt = middleware.NewJWTMiddleware(config.AuthConfig{
+		JWTSecret:        "test-secret",
+		TokenExpireDelta: time.Hour,
+	}, &logger)
+
+	taskService := service.NewTaskService(suite.mockRepo, &logger)
+	interceptor := NewAuthInterceptor(suite.jwt, &logger)
+	suite.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptor.Unary()),
+		grpc.ChainStreamInterceptor(interceptor.Stream()),
+	)
+	suite.taskServer = NewTaskServer(taskService, &logger)
+	taskv1.RegisterTaskServiceServer(suite.grpcServer, suite.taskServer)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = suite.grpcServer.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(suite.T(), err)
+	suite.conn = conn
+	suite.client = taskv1.NewTaskServiceClient(conn)
+}
+
+func (suite *TaskServerTestSuite) TearDownTest() {
+	suite.conn.Close()
+	suite.taskServer.Close()
+	suite.grpcServer.Stop()
+}
+
+func (suite *TaskServerTestSuite) authContext(userID int64) context.Context {
+	token, err := suite.jwt.GenerateToken(userID, model.RoleUser)
+	require.NoError(suite.T(), err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func (suite *TaskServerTestSuite) TestAuthentication() {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantCode codes.Code
+	}{
+		{
+			name:     "missing_token",
+			ctx:      context.Background(),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "invalid_format",
+			ctx:      metadata.AppendToOutgoingContext(context.Background(), "authorization", "Token abc"),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "invalid_token",
+			ctx:      metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer abc"),
+			wantCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			_, err := suite.client.GetTask(tt.ctx, &taskv1.GetTaskRequest{Id: 1})
+			assert.Equal(suite.T(), tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func (suite *TaskServerTestSuite) TestCreateTask() {
+	expectedTask := testutils.TaskFixture(func(t *model.Task) {
+		t.Title = "grpc task"
+		t.Status = model.TaskStatusInProgress
+	})
+	suite.mockRepo.On("Create", mock.Anything, int64(1), model.CreateTaskRequest{
+		Title:  "grpc task",
+		Status: model.TaskStatusInProgress,
+	}).Return(expectedTask, nil).Once()
+
+	task, err := suite.client.CreateTask(suite.authContext(1), &taskv1.CreateTaskRequest{
+		Title:  "grpc task",
+		Status: taskv1.TaskStatus_TASK_STATUS_IN_PROGRESS,
+	})
+
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "grpc task", task.GetTitle())
+	assert.Equal(suite.T(), taskv1.TaskStatus_TASK_STATUS_IN_PROGRESS, task.GetStatus())
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TaskServerTestSuite) TestCreateTaskEmptyTitle() {
+	_, err := suite.client.CreateTask(suite.authContext(1), &taskv1.CreateTaskRequest{Title: " "})
+
+	assert.Equal(suite.T(), codes.InvalidArgument, status.Code(err))
+	suite.mockRepo.AssertNotCalled(suite.T(), "Create")
+}
+
+func (suite *TaskServerTestSuite) TestGetTaskNotFound() {
+	suite.mockRepo.On("GetByID", mock.Anything, int64(999), int64(1)).
+		Return(nil, assert.AnError).Once()
+
+	_, err := suite.client.GetTask(suite.authContext(1), &taskv1.GetTaskRequest{Id: 999})
+
+	assert.Equal(suite.T(), codes.NotFound, status.Code(err))
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func (suite *TaskServerTestSuite) TestWatchTasks() {
+	ctx, cancel := context.WithTimeout(suite.authContext(1), 5*time.Second)
+	defer cancel()
+
+	stream, err := suite.client.WatchTasks(ctx, &taskv1.WatchTasksRequest{})
+	require.NoError(suite.T(), err)
+
+	_, err = stream.Header()
+	require.NoError(suite.T(), err)
+
+	suite.mockRepo.On("Delete", mock.Anything, int64(5), int64(1)).Return(nil).Once()
+	_, err = suite.client.DeleteTask(suite.authContext(1), &taskv1.DeleteTaskRequest{Id: 5})
+	require.NoError(suite.T(), err)
+
+	event, err := stream.Recv()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), taskv1.TaskEventType_TASK_EVENT_TYPE_DELETED, event.GetType())
+	assert.Equal(suite.T(), int64(5), event.GetTask().GetId())
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func TestTaskServerTestSuite(t *testing.T) {
+	suite.Run(t, new(TaskServerTestSuite))
+}
@@ -6,56 +6,160 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/api/gql"
 	"github.com/kkboranbay/task-service/internal/api/handler"
 	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/api/rpc"
 	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/health"
+	"github.com/kkboranbay/task-service/internal/jwtkeys"
+	"github.com/kkboranbay/task-service/internal/metrics"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/oidc"
+	"github.com/kkboranbay/task-service/internal/ratelimit"
+	"github.com/kkboranbay/task-service/internal/secrets"
 	"github.com/kkboranbay/task-service/internal/service"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	pg "github.com/kkboranbay/task-service/pkg/postgres"
+	taskv1 "github.com/kkboranbay/task-service/proto/task/v1"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 )
 
 type Server struct {
-	httpServer *http.Server
-	router     *gin.Engine
-	db         *pgxpool.Pool
-	log        *zerolog.Logger
-	cfg        config.ServerConfig
+	httpServer  *http.Server
+	redisClient *redis.Client
+	rateLimiter *middleware.RateLimiter
+	keys        *jwtkeys.KeySet
+	health      *health.Registry
+	grpcServer  *grpc.Server
+	taskServer  *rpc.TaskServer
+	router      *gin.Engine
+	db          *pgxpool.Pool
+	log         *zerolog.Logger
+	cfg         config.ServerConfig
 }
 
 func NewServer(
 	db *pgxpool.Pool,
 	taskService *service.TaskService,
+	calendarService *service.CalendarService,
+	userService *service.UserService,
+	auditService *service.AuditService,
+	accessTokenService *service.AccessTokenService,
+	twoFactorService *service.TwoFactorService,
+	secretStore *secrets.Store,
+	m *metrics.Metrics,
 	cfg config.Config,
 	log *zerolog.Logger,
-) *Server {
-	router := gin.New()
+) (*Server, error) {
+	router, err := newRouter(cfg.Server)
+	if err != nil {
+		return nil, err
+	}
 
 	requestLogger := middleware.NewRequestLogger(log)
-	jwtMiddleware := middleware.NewJWTMiddleware(cfg.Auth, log)
+	jwtMiddleware := middleware.NewJWTMiddleware(cfg.Auth, log).
+		WithAccessTokens(accessTokenService).
+		WithSecrets(secretStore)
+	var keys *jwtkeys.KeySet
+	if cfg.Auth.SigningKeyFile != "" {
+		keys, err = jwtkeys.Load(cfg.Auth.SigningKeyFile, cfg.Auth.VerificationKeyFiles)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка загрузки ключей подписи JWT: %w", err)
+		}
+		jwtMiddleware.WithKeySet(keys)
+		log.Info().Str("kid", keys.SigningKey().ID).Str("alg", keys.SigningKey().Algorithm).Msg("JWT подписываются асимметричным ключом")
+	}
 
+	// спан запроса открывается до логгера, чтобы trace_id попал в запись о запросе
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return c.FullPath() != "/metrics"
+	})))
 	router.Use(requestLogger.Middleware())
-	router.Use(middleware.PrometheusMiddleware())
+	router.Use(middleware.PrometheusMiddleware(m))
 	router.Use(gin.Recovery())
 
-	healthHandler := handler.NewHealthHandler(db, log)
+	healthRegistry := health.NewRegistry(log)
+	healthRegistry.Register(health.Check{Name: "database", Check: db.Ping, Critical: true})
+	healthRegistry.Register(health.Check{Name: "migrations", Check: pg.NewMigrator(db, log).CheckSchema, Critical: true})
+	healthHandler := handler.NewHealthHandler(healthRegistry, log)
 	healthHandler.Register(router)
 
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/metrics", gin.WrapH(m.Handler()))
 
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	authHandler := handler.NewAuthHandler(jwtMiddleware, log)
-	authHandler.Register(router)
+	rateLimitStore, redisClient := newRateLimitStore(cfg.RateLimit, secretStore)
+	if redisClient != nil {
+		healthRegistry.Register(health.Check{Name: "redis", Check: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}})
+	}
+	rateLimiter := middleware.NewRateLimiter(rateLimitStore, cfg.RateLimit, log).WithMetrics(m)
+	// лимитеры подключены всегда: RATE_LIMIT_ENABLED можно переключить без перезапуска
+	loginMiddleware := []gin.HandlerFunc{rateLimiter.ByIP("login", middleware.LoginLimit)}
+	apiMiddleware := []gin.HandlerFunc{jwtMiddleware.AuthRequired(), rateLimiter.ByUser(middleware.UserLimit)}
+	taskScope := middleware.RequireMethodScope(model.ScopeTasksRead, model.ScopeTasksWrite)
+
+	loginGuard := service.NewLoginGuard(cfg.Auth.LoginGuard, log).WithAudit(auditService)
+	authHandler := handler.NewAuthHandler(jwtMiddleware, userService, twoFactorService, loginGuard, log)
+	authHandler.Register(router, loginMiddleware...)
+
+	if cfg.Auth.OIDC.IssuerURL != "" {
+		discoveryCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		provider, err := oidc.NewProvider(discoveryCtx, cfg.Auth.OIDC, &http.Client{Timeout: 10 * time.Second})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка настройки OIDC провайдера: %w", err)
+		}
+
+		oidcHandler := handler.NewOIDCHandler(
+			provider,
+			userService,
+			twoFactorService,
+			jwtMiddleware,
+			secretStore,
+			cfg.Auth.OIDC.StateTTL,
+			cfg.Auth.OIDC.RedirectURL,
+			log,
+		)
+		oidcHandler.Register(router, loginMiddleware...)
+		log.Info().Str("issuer", provider.Issuer()).Msg("включен вход через OIDC")
+	}
 
 	api := router.Group("/api/v1")
-	api.Use(jwtMiddleware.AuthRequired())
+	api.Use(apiMiddleware...)
+
+	taskHandler := handler.NewTaskHandler(taskService, log).WithWriteTimeout(cfg.Server.WriteTimeout)
+	taskHandler.Register(api.Group("", taskScope))
+
+	adminHandler := handler.NewAdminHandler(userService, taskService, auditService, loginGuard, jwtMiddleware, log)
+	adminHandler.Register(api)
+
+	accessTokenHandler := handler.NewAccessTokenHandler(accessTokenService, log)
+	accessTokenHandler.Register(api)
 
-	taskHandler := handler.NewTaskHandler(taskService, log)
-	taskHandler.Register(api)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService, userService, log)
+	twoFactorHandler.Register(api)
+
+	calendarHandler := handler.NewCalendarHandler(calendarService, log).WithPublicURL(cfg.Server.PublicURL)
+	calendarHandler.Register(api.Group("", taskScope))
+	calendarHandler.RegisterFeed(router)
+
+	graphqlHandler, err := gql.NewHandler(taskService, log)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка построения GraphQL схемы: %w", err)
+	}
+	graphqlHandler.Register(router.Group("/graphql", append(apiMiddleware, middleware.RequireScope(model.ScopeTasksRead))...))
 
 	httpServer := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -64,16 +168,107 @@ func NewServer(
 		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
+	authInterceptor := rpc.NewAuthInterceptor(jwtMiddleware, log)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authInterceptor.Unary()),
+		grpc.ChainStreamInterceptor(authInterceptor.Stream()),
+	)
+	taskServer := rpc.NewTaskServer(taskService, log)
+	taskv1.RegisterTaskServiceServer(grpcServer, taskServer)
+
 	return &Server{
-		httpServer: httpServer,
-		router:     router,
-		db:         db,
-		log:        log,
-		cfg:        cfg.Server,
+		httpServer:  httpServer,
+		redisClient: redisClient,
+		rateLimiter: rateLimiter,
+		keys:        keys,
+		health:      healthRegistry,
+		grpcServer:  grpcServer,
+		taskServer:  taskServer,
+		router:      router,
+		db:          db,
+		log:         log,
+		cfg:         cfg.Server,
+	}, nil
+}
+
+// newRouter создает роутер, который берет адрес клиента из X-Forwarded-For и X-Real-IP
+// только от TRUSTED_PROXIES: по этому адресу работают лимиты и защита входа
+func newRouter(cfg config.ServerConfig) (*gin.Engine, error) {
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("ошибка настройки TRUSTED_PROXIES: %w", err)
 	}
+	return router, nil
 }
 
+// newRateLimitStore выбирает хранилище лимитов: Redis, если он настроен, иначе память процесса
+func newRateLimitStore(cfg config.RateLimitConfig, secretStore *secrets.Store) (ratelimit.Store, *redis.Client) {
+	if cfg.RedisAddr == "" {
+		return ratelimit.NewMemoryStore(), nil
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr: cfg.RedisAddr,
+		DB:   cfg.RedisDB,
+		// пароль читается при каждом новом соединении, чтобы подхватить ротацию
+		CredentialsProvider: func() (string, string) {
+			return "", secretStore.Get("RATE_LIMIT_REDIS_PASSWORD")
+		},
+	})
+	return ratelimit.NewRedisStore(client, "ratelimit:"), client
+}
+
+// RegisterHealthCheck добавляет проверку зависимости, созданной вне сервера, в /readyz
+func (s *Server) RegisterHealthCheck(check health.Check) {
+	s.health.Register(check)
+}
+
+// ApplyConfig применяет настройки, которые можно менять без перезапуска
+func (s *Server) ApplyConfig(cfg *config.Config) {
+	s.rateLimiter.Update(cfg.RateLimit)
+	s.reloadKeys(cfg.Auth)
+}
+
+// reloadKeys перечитывает ключи JWT из JWT_SIGNING_KEY_FILE и JWT_VERIFICATION_KEY_FILES.
+// Для ротации достаточно указать новый ключ подписи, а прежний перенести в ключи проверки.
+// Если файлы не читаются, продолжают действовать прежние ключи
+func (s *Server) reloadKeys(cfg config.AuthConfig) {
+	if s.keys == nil || cfg.SigningKeyFile == "" {
+		if (s.keys == nil) != (cfg.SigningKeyFile == "") {
+			s.log.Warn().Msg("переход между HS256 и асимметричными ключами вступит в силу только после перезапуска")
+		}
+		return
+	}
+
+	keys, err := jwtkeys.Load(cfg.SigningKeyFile, cfg.VerificationKeyFiles)
+	if err != nil {
+		s.log.Error().Err(err).Msg("ключи подписи JWT не перечитаны, действуют прежние")
+		return
+	}
+	s.keys.Replace(keys)
+	s.log.Info().Str("kid", keys.SigningKey().ID).Str("alg", keys.SigningKey().Algorithm).Msg("ключи подписи JWT перечитаны")
+}
+
+// Run запускает HTTP и gRPC серверы и блокируется до их остановки
 func (s *Server) Run() error {
+	errCh := make(chan error, 2)
+
+	go func() {
+		errCh <- s.runHTTP()
+	}()
+	go func() {
+		errCh <- s.runGRPC()
+	}()
+
+	for i := 0; i < 2; i++ {
+		if err := <-errCh; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) runHTTP() error {
 	s.log.Info().Str("addr", s.httpServer.Addr).Msg("Запуск HTTP сервера")
 	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("ошибка запуска HTTP сервера: %w", err)
@@ -81,20 +276,76 @@ func (s *Server) Run() error {
 	return nil
 }
 
+func (s *Server) runGRPC() error {
+	addr := ":" + s.cfg.GRPCPort
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("ошибка запуска gRPC сервера: %w", err)
+	}
+
+	s.log.Info().Str("addr", addr).Msg("Запуск gRPC сервера")
+	if err := s.grpcServer.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("ошибка запуска gRPC сервера: %w", err)
+	}
+	return nil
+}
+
 func (s *Server) Shutdown(ctx context.Context) error {
-	s.log.Info().Msg("Остановка HTTP сервера...")
+	s.health.SetDraining()
+	if s.cfg.DrainDelay > 0 {
+		s.log.Info().Dur("delay", s.cfg.DrainDelay).Msg("готовность снята, ожидание перед остановкой серверов")
+		select {
+		case <-time.After(s.cfg.DrainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	s.log.Info().Msg("Остановка HTTP и gRPC серверов...")
 
 	shutdownCtx, cancel := context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
 	defer cancel()
 
-	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.shutdownGRPC(shutdownCtx)
+	}()
+
+	err := s.httpServer.Shutdown(shutdownCtx)
+	wg.Wait()
+	if err != nil {
 		return fmt.Errorf("ошибка остановки HTTP сервера: %w", err)
 	}
 
+	if s.redisClient != nil {
+		if err := s.redisClient.Close(); err != nil {
+			s.log.Error().Err(err).Msg("ошибка закрытия соединения с Redis")
+		}
+	}
+
 	if s.db != nil {
 		s.db.Close()
 	}
 
-	s.log.Info().Msg("HTTP сервер успешно остановлен")
+	s.log.Info().Msg("HTTP и gRPC серверы успешно остановлены")
 	return nil
 }
+
+// shutdownGRPC дожидается завершения активных вызовов, а по истечении таймаута обрывает их
+func (s *Server) shutdownGRPC(ctx context.Context) {
+	s.taskServer.Close()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.log.Warn().Msg("таймаут graceful остановки gRPC сервера, принудительная остановка")
+		s.grpcServer.Stop()
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/gin-gonic/gin"
+	"github.com/kkboranbay/task-service/internal/api/middleware"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/jwtkeys"
+	"github.com/kkboranbay/task-service/internal/ratelimit"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoginLimitIgnoresForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zerolog.Nop()
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantSecond     int
+	}{
+		// httptest отправляет запросы с 192.0.2.1; X-Forwarded-For учитывается только от доверенного прокси
+		{name: "untrusted_client", wantSecond: http.StatusTooManyRequests},
+		{name: "trusted_proxy", trustedProxies: []string{"192.0.2.0/24"}, wantSecond: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := newRouter(config.ServerConfig{TrustedProxies: tt.trustedProxies})
+			require.NoError(t, err)
+
+			limiter := middleware.NewRateLimiter(ratelimit.NewMemoryStore(), config.RateLimitConfig{
+				Enabled:    true,
+				LoginRate:  0.001,
+				LoginBurst: 1,
+			}, &logger)
+			router.POST("/login", limiter.ByIP("login", middleware.LoginLimit), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			request := func(forwardedFor string) int {
+				req := httptest.NewRequest(http.MethodPost, "/login", nil)
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				req.Header.Set("X-Real-IP", forwardedFor)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				return w.Code
+			}
+
+			assert.Equal(t, http.StatusOK, request("203.0.113.1"))
+			assert.Equal(t, tt.wantSecond, request("203.0.113.2"))
+		})
+	}
+}
+
+func TestNewRouterRejectsInvalidProxy(t *testing.T) {
+	_, err := newRouter(config.ServerConfig{TrustedProxies: []string{"proxy.internal"}})
+	assert.Error(t, err)
+}
+
+func writeSigningKey(t *testing.T, dir, name string) string {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func TestApplyConfigReloadsKeys(t *testing.T) {
+	logger := zerolog.Nop()
+	dir := t.TempDir()
+	oldFile := writeSigningKey(t, dir, "old.pem")
+	newFile := writeSigningKey(t, dir, "new.pem")
+
+	keys, err := jwtkeys.Load(oldFile, nil)
+	require.NoError(t, err)
+	oldKID := keys.SigningKey().ID
+
+	server := &Server{
+		rateLimiter: middleware.NewRateLimiter(ratelimit.NewMemoryStore(), config.RateLimitConfig{}, &logger),
+		keys:        keys,
+		log:         &logger,
+	}
+
+	cfg := &config.Config{Auth: config.AuthConfig{SigningKeyFile: newFile, VerificationKeyFiles: []string{oldFile}}}
+	server.ApplyConfig(cfg)
+
+	newKID := keys.SigningKey().ID
+	assert.NotEqual(t, oldKID, newKID)
+	// токены, подписанные прежним ключом, проверяются до истечения срока
+	_, ok := keys.VerificationKey(oldKID)
+	assert.True(t, ok)
+
+	// при ошибке чтения остаются прежние ключи
+	cfg = &config.Config{Auth: config.AuthConfig{SigningKeyFile: filepath.Join(dir, "missing.pem")}}
+	server.ApplyConfig(cfg)
+	assert.Equal(t, newKID, keys.SigningKey().ID)
+}
@@ -1,70 +1,340 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"github.com/kkboranbay/task-service/internal/secrets"
 	"github.com/spf13/viper"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 )
 
+// Environment профиль запуска; в prod конфигурация проверяется строже, см. Validate
+type Environment string
+
+const (
+	EnvDev     Environment = "dev"
+	EnvStaging Environment = "staging"
+	EnvProd    Environment = "prod"
+)
+
+// значения по умолчанию, удобные для локального запуска и недопустимые в prod
+const (
+	defaultJWTSecret  = "qwertyuiopasdfghjklzxcvbnm123456"
+	defaultDBPassword = "postgres"
+)
+
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
-	Logger   LoggerConfig
+	Environment Environment
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Auth        AuthConfig
+	Logger      LoggerConfig
+	RateLimit   RateLimitConfig
+	Secrets     SecretsConfig
+	Tracing     TracingConfig
+	// File прочитанный YAML файл; пустой, если настройки заданы только окружением
+	File string
+}
+
+// SecretKeys переменные с секретами: их можно передать файлом через <KEY>_FILE
+// или хранить во внешнем хранилище под тем же именем
+var SecretKeys = []string{"JWT_SECRET", "DB_PASSWORD", "OIDC_CLIENT_SECRET", "OIDC_STATE_SECRET", "RATE_LIMIT_REDIS_PASSWORD", "ADMIN_PASSWORD"}
+
+// SecretsConfig хранилище секретов HashiCorp Vault; при пустом VaultAddr секреты берутся
+// только из окружения и файлов
+type SecretsConfig struct {
+	VaultAddr       string
+	VaultToken      string
+	VaultTokenFile  string
+	VaultMount      string
+	VaultPath       string
+	VaultKVVersion  int
+	RefreshInterval time.Duration
+}
+
+// TracingConfig экспорт трассировок OpenTelemetry по OTLP/gRPC. При выключенной трассировке
+// входящий traceparent все равно разбирается, чтобы trace_id попадал в логи
+type TracingConfig struct {
+	Enabled     bool
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	SampleRatio float64
 }
 
 type ServerConfig struct {
 	Port            string
+	GRPCPort        string
 	ReadTimeout     time.Duration
 	WriteTimeout    time.Duration
 	ShutdownTimeout time.Duration
+	// DrainDelay пауза между снятием готовности и остановкой серверов, чтобы
+	// балансировщик успел перестать направлять запросы
+	DrainDelay time.Duration
+	// TrustedProxies адреса и подсети прокси, которым доверяются X-Forwarded-For и X-Real-IP.
+	// Без них адрес клиента берется из соединения, иначе клиент может подменить его заголовком
+	TrustedProxies []string
+	// PublicURL внешний адрес сервиса, например https://tasks.example.com, для ссылок в ответах.
+	// Если не задан, ссылка строится по Host запроса, а заголовки X-Forwarded-* не учитываются
+	PublicURL string
 }
 
+// DatabaseConfig подключение к Postgres. DSN, если задан, заменяет Host, Port, User,
+// Password, DBName и параметры TLS; настройки пула применяются в обоих случаях
 type DatabaseConfig struct {
+	DSN      string
 	Host     string
 	Port     string
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
-	MaxConns int
-	Timeout  time.Duration
+	// SSLRootCert, SSLCert и SSLKey пути к CA и клиентскому сертификату для sslmode verify-*
+	SSLRootCert     string
+	SSLCert         string
+	SSLKey          string
+	ApplicationName string
+	MaxConns        int
+	MinConns        int
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// HealthCheckPeriod как часто пул проверяет и закрывает простаивающие и устаревшие соединения
+	HealthCheckPeriod time.Duration
+	// StatementCacheMode режим выполнения запросов pgx: cache_statement, cache_describe,
+	// describe_exec, exec или simple_protocol; последние два нужны за PgBouncer в режиме транзакций
+	StatementCacheMode string
+	Timeout            time.Duration
+	// QueryTimeout ограничивает одну операцию репозитория задач через контекст; должен быть
+	// меньше SERVER_WRITE_TIMEOUT, чтобы клиент получил ответ об ошибке, а не обрыв соединения
+	QueryTimeout time.Duration
+	// StatementTimeout и IdleInTransactionTimeout задаются сессии Postgres: сервер сам прерывает
+	// запросы и транзакции, которые пережили отмену на стороне сервиса
+	StatementTimeout         time.Duration
+	IdleInTransactionTimeout time.Duration
+	// ConnectRetryTimeout сколько при запуске ждать базу, которая еще не принимает соединения
+	ConnectRetryTimeout time.Duration
+	// AutoMigrate применяет встроенные миграции при запуске сервиса
+	AutoMigrate bool
+	// Replicas DSN реплик для чтения; без пароля в DSN используется пароль основной базы
+	Replicas []string
+	// ReplicaStickiness сколько после изменения чтения пользователя идут в основную базу,
+	// чтобы он видел свои записи несмотря на отставание реплик
+	ReplicaStickiness    time.Duration
+	ReplicaCheckInterval time.Duration
+	// PasswordSource возвращает актуальный пароль для новых соединений, если он ротируется
+	PasswordSource func() string `json:"-"`
 }
 
+// AuthConfig параметры выпуска токенов. Если задан SigningKeyFile, токены подписываются
+// RS256/EdDSA ключом из файла, а JWTSecret используется только при AcceptHS256
 type AuthConfig struct {
-	JWTSecret        string
-	TokenExpireDelta time.Duration
+	JWTSecret string
+	// AdminPassword пароль, который получает учетная запись admin при запуске, если у нее еще нет пароля
+	AdminPassword        string
+	SigningKeyFile       string
+	VerificationKeyFiles []string
+	AcceptHS256          bool
+	TokenExpireDelta     time.Duration
+	ImpersonationTTL     time.Duration
+	LoginGuard           LoginGuardConfig
+	OIDC                 OIDCConfig
+	// RequireTwoFactor требует подтверждение TOTP кодом при каждом входе по паролю и через OIDC;
+	// пользователи без 2FA настраивают ее на втором шаге входа
+	RequireTwoFactor      bool
+	TwoFactorIssuer       string
+	TwoFactorChallengeTTL time.Duration
+}
+
+// OIDCConfig вход через внешнего OpenID Connect провайдера; при пустом IssuerURL вход отключен
+type OIDCConfig struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// StateSecret ключ подписи cookie с состоянием входа; отдельный от JWT_SECRET,
+	// чтобы утечка одного из них не затрагивала другой
+	StateSecret string
+	RedirectURL string
+	Scopes      []string
+	StateTTL    time.Duration
 }
 
+// LoginGuardConfig пороги защиты /auth/login от перебора паролей
+type LoginGuardConfig struct {
+	MaxFailures      int
+	MaxFailuresPerIP int
+	FailureWindow    time.Duration
+	LockoutDuration  time.Duration
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+}
+
+// форматы и приемники журнала
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+	LogOutputStdout  = "stdout"
+	LogOutputFile    = "file"
+)
+
+// LoggerConfig журнал сервиса. Format console удобен локально, json разбирается системой
+// сбора логов; пустые Format и Output означают console и stdout
 type LoggerConfig struct {
-	Level string
+	Level    string
+	Format   string
+	Output   string
+	File     LogFileConfig
+	Sampling LogSamplingConfig
+}
+
+// LogFileConfig файл журнала с ротацией по размеру; старые файлы удаляются по количеству и возрасту
+type LogFileConfig struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+}
+
+// LogSamplingConfig сэмплирование частых записей: за Period пишутся первые Burst записей
+// уровня, затем каждая N-я. Every 0 или 1 отключает сэмплирование уровня; warn и выше
+// не сэмплируются никогда
+type LogSamplingConfig struct {
+	Burst      int
+	Period     time.Duration
+	InfoEvery  int
+	DebugEvery int
+}
+
+// RateLimitConfig лимиты запросов в секунду и размеры всплесков; при пустом RedisAddr
+// состояние хранится в памяти процесса
+type RateLimitConfig struct {
+	Enabled       bool
+	UserRate      float64
+	UserBurst     int
+	LoginRate     float64
+	LoginBurst    int
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
 }
 
 func LoadConfig() (*Config, error) {
-	viper.SetConfigName("config")
+	// значения из файлов и хранилища секретов задаются через viper.Set и иначе
+	// пережили бы повторную загрузку конфигурации
+	viper.Reset()
+	if file := os.Getenv("CONFIG_FILE"); file != "" {
+		viper.SetConfigFile(file)
+	} else {
+		viper.SetConfigName("config")
+		viper.AddConfigPath(".")
+		viper.AddConfigPath("./config")
+	}
 	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
-	viper.AddConfigPath("./config")
 	viper.AutomaticEnv()
 
+	viper.SetDefault("APP_ENV", string(EnvDev))
+
 	viper.SetDefault("SERVER_PORT", "8080")
+	viper.SetDefault("GRPC_PORT", "50051")
 	viper.SetDefault("SERVER_READ_TIMEOUT", "5s")
 	viper.SetDefault("SERVER_WRITE_TIMEOUT", "10s")
 	viper.SetDefault("SERVER_SHUTDOWN_TIMEOUT", "5s")
+	viper.SetDefault("SERVER_DRAIN_DELAY", "0s")
+	viper.SetDefault("TRUSTED_PROXIES", "")
+	viper.SetDefault("PUBLIC_URL", "")
 
+	viper.SetDefault("DB_DSN", "")
 	viper.SetDefault("DB_HOST", "localhost")
 	viper.SetDefault("DB_PORT", "5432")
 	viper.SetDefault("DB_USER", "postgres")
-	viper.SetDefault("DB_PASSWORD", "postgres")
+	viper.SetDefault("DB_PASSWORD", defaultDBPassword)
 	viper.SetDefault("DB_NAME", "taskdb")
 	viper.SetDefault("DB_SSLMODE", "disable")
+	viper.SetDefault("DB_SSLROOTCERT", "")
+	viper.SetDefault("DB_SSLCERT", "")
+	viper.SetDefault("DB_SSLKEY", "")
+	viper.SetDefault("DB_APPLICATION_NAME", "task-service")
 	viper.SetDefault("DB_MAX_CONNS", 10)
+	viper.SetDefault("DB_MIN_CONNS", 0)
+	viper.SetDefault("DB_MAX_CONN_LIFETIME", "1h")
+	viper.SetDefault("DB_MAX_CONN_IDLE_TIME", "30m")
+	viper.SetDefault("DB_HEALTH_CHECK_PERIOD", "1m")
+	viper.SetDefault("DB_STATEMENT_CACHE_MODE", "cache_statement")
 	viper.SetDefault("DB_TIMEOUT", "5s")
+	viper.SetDefault("DB_QUERY_TIMEOUT", "5s")
+	viper.SetDefault("DB_STATEMENT_TIMEOUT", "30s")
+	viper.SetDefault("DB_IDLE_IN_TRANSACTION_TIMEOUT", "1m")
+	viper.SetDefault("DB_CONNECT_RETRY_TIMEOUT", "30s")
+	viper.SetDefault("DB_AUTO_MIGRATE", false)
+	viper.SetDefault("DB_REPLICAS", "")
+	viper.SetDefault("DB_REPLICA_STICKINESS", "5s")
+	viper.SetDefault("DB_REPLICA_CHECK_INTERVAL", "5s")
 
-	viper.SetDefault("JWT_SECRET", "qwertyuiopasdfghjklzxcvbnm123456")
+	viper.SetDefault("JWT_SECRET", defaultJWTSecret)
+	viper.SetDefault("ADMIN_PASSWORD", "")
 	viper.SetDefault("JWT_EXPIRE_DELTA", "24h")
+	viper.SetDefault("JWT_IMPERSONATION_TTL", "1h")
+	viper.SetDefault("JWT_SIGNING_KEY_FILE", "")
+	viper.SetDefault("JWT_VERIFICATION_KEY_FILES", "")
+	viper.SetDefault("JWT_ACCEPT_HS256", false)
+
+	viper.SetDefault("OIDC_ISSUER_URL", "")
+	viper.SetDefault("OIDC_CLIENT_ID", "")
+	viper.SetDefault("OIDC_CLIENT_SECRET", "")
+	viper.SetDefault("OIDC_STATE_SECRET", "")
+	viper.SetDefault("OIDC_REDIRECT_URL", "http://localhost:8080/auth/oidc/callback")
+	viper.SetDefault("OIDC_SCOPES", "openid,profile,email")
+	viper.SetDefault("OIDC_STATE_TTL", "10m")
+
+	viper.SetDefault("AUTH_2FA_REQUIRED", false)
+	viper.SetDefault("AUTH_2FA_ISSUER", "Task Service")
+	viper.SetDefault("AUTH_2FA_CHALLENGE_TTL", "5m")
+
+	viper.SetDefault("LOGIN_MAX_FAILURES", 5)
+	viper.SetDefault("LOGIN_MAX_FAILURES_PER_IP", 20)
+	viper.SetDefault("LOGIN_FAILURE_WINDOW", "15m")
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "15m")
+	viper.SetDefault("LOGIN_BASE_DELAY", "1s")
+	viper.SetDefault("LOGIN_MAX_DELAY", "30s")
 
 	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", LogFormatConsole)
+	viper.SetDefault("LOG_OUTPUT", LogOutputStdout)
+	viper.SetDefault("LOG_FILE", "logs/task-service.log")
+	viper.SetDefault("LOG_FILE_MAX_SIZE_MB", 100)
+	viper.SetDefault("LOG_FILE_MAX_BACKUPS", 5)
+	viper.SetDefault("LOG_FILE_MAX_AGE_DAYS", 30)
+	viper.SetDefault("LOG_FILE_COMPRESS", false)
+	viper.SetDefault("LOG_SAMPLE_BURST", 100)
+	viper.SetDefault("LOG_SAMPLE_PERIOD", "1s")
+	viper.SetDefault("LOG_SAMPLE_INFO_EVERY", 0)
+	viper.SetDefault("LOG_SAMPLE_DEBUG_EVERY", 0)
+
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_USER_RPS", 20)
+	viper.SetDefault("RATE_LIMIT_USER_BURST", 40)
+	viper.SetDefault("RATE_LIMIT_LOGIN_RPS", 0.2)
+	viper.SetDefault("RATE_LIMIT_LOGIN_BURST", 5)
+	viper.SetDefault("RATE_LIMIT_REDIS_ADDR", "")
+	viper.SetDefault("RATE_LIMIT_REDIS_PASSWORD", "")
+	viper.SetDefault("RATE_LIMIT_REDIS_DB", 0)
+
+	viper.SetDefault("TRACING_ENABLED", false)
+	viper.SetDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317")
+	viper.SetDefault("OTEL_EXPORTER_OTLP_INSECURE", false)
+	viper.SetDefault("OTEL_SERVICE_NAME", "task-service")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+
+	viper.SetDefault("VAULT_ADDR", "")
+	viper.SetDefault("VAULT_TOKEN", "")
+	viper.SetDefault("VAULT_MOUNT", "secret")
+	viper.SetDefault("VAULT_PATH", "")
+	viper.SetDefault("VAULT_KV_VERSION", 2)
+	viper.SetDefault("SECRETS_REFRESH_INTERVAL", "5m")
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -72,7 +342,44 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
+	// DSN может содержать пароль, поэтому его тоже можно передать файлом
+	if err := loadSecretFiles(append(SecretKeys, "VAULT_TOKEN", "DB_DSN")); err != nil {
+		return nil, err
+	}
+
+	secretsRefresh, err := time.ParseDuration(viper.GetString("SECRETS_REFRESH_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга SECRETS_REFRESH_INTERVAL: %w", err)
+	}
+
+	secretsConfig := SecretsConfig{
+		VaultAddr:       viper.GetString("VAULT_ADDR"),
+		VaultToken:      viper.GetString("VAULT_TOKEN"),
+		VaultTokenFile:  viper.GetString("VAULT_TOKEN_FILE"),
+		VaultMount:      viper.GetString("VAULT_MOUNT"),
+		VaultPath:       viper.GetString("VAULT_PATH"),
+		VaultKVVersion:  viper.GetInt("VAULT_KV_VERSION"),
+		RefreshInterval: secretsRefresh,
+	}
+
+	if provider := NewSecretProvider(secretsConfig); provider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		values, err := provider.Secrets(ctx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка загрузки секретов из Vault: %w", err)
+		}
+		for _, key := range SecretKeys {
+			if value := values[key]; value != "" {
+				viper.Set(key, value)
+			}
+		}
+	}
+
 	var config Config
+	config.Environment = Environment(strings.ToLower(viper.GetString("APP_ENV")))
+	config.File = viper.ConfigFileUsed()
+	config.Secrets = secretsConfig
 
 	readTimeout, err := time.ParseDuration(viper.GetString("SERVER_READ_TIMEOUT"))
 	if err != nil {
@@ -89,11 +396,20 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("ошибка парсинга SERVER_SHUTDOWN_TIMEOUT: %w", err)
 	}
 
+	drainDelay, err := time.ParseDuration(viper.GetString("SERVER_DRAIN_DELAY"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга SERVER_DRAIN_DELAY: %w", err)
+	}
+
 	config.Server = ServerConfig{
 		Port:            viper.GetString("SERVER_PORT"),
+		GRPCPort:        viper.GetString("GRPC_PORT"),
 		ReadTimeout:     readTimeout,
 		WriteTimeout:    writeTimeout,
 		ShutdownTimeout: shutdownTimeout,
+		DrainDelay:      drainDelay,
+		TrustedProxies:  splitList(viper.GetString("TRUSTED_PROXIES")),
+		PublicURL:       strings.TrimSuffix(viper.GetString("PUBLIC_URL"), "/"),
 	}
 
 	dbTimeout, err := time.ParseDuration(viper.GetString("DB_TIMEOUT"))
@@ -101,15 +417,78 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("ошибка парсинга DB_TIMEOUT: %w", err)
 	}
 
+	maxConnLifetime, err := time.ParseDuration(viper.GetString("DB_MAX_CONN_LIFETIME"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_MAX_CONN_LIFETIME: %w", err)
+	}
+
+	maxConnIdleTime, err := time.ParseDuration(viper.GetString("DB_MAX_CONN_IDLE_TIME"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_MAX_CONN_IDLE_TIME: %w", err)
+	}
+
+	healthCheckPeriod, err := time.ParseDuration(viper.GetString("DB_HEALTH_CHECK_PERIOD"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_HEALTH_CHECK_PERIOD: %w", err)
+	}
+
+	queryTimeout, err := time.ParseDuration(viper.GetString("DB_QUERY_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_QUERY_TIMEOUT: %w", err)
+	}
+
+	statementTimeout, err := time.ParseDuration(viper.GetString("DB_STATEMENT_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_STATEMENT_TIMEOUT: %w", err)
+	}
+
+	idleInTransactionTimeout, err := time.ParseDuration(viper.GetString("DB_IDLE_IN_TRANSACTION_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_IDLE_IN_TRANSACTION_TIMEOUT: %w", err)
+	}
+
+	connectRetryTimeout, err := time.ParseDuration(viper.GetString("DB_CONNECT_RETRY_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_CONNECT_RETRY_TIMEOUT: %w", err)
+	}
+
+	replicaStickiness, err := time.ParseDuration(viper.GetString("DB_REPLICA_STICKINESS"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_REPLICA_STICKINESS: %w", err)
+	}
+
+	replicaCheckInterval, err := time.ParseDuration(viper.GetString("DB_REPLICA_CHECK_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга DB_REPLICA_CHECK_INTERVAL: %w", err)
+	}
+
 	config.Database = DatabaseConfig{
-		Host:     viper.GetString("DB_HOST"),
-		Port:     viper.GetString("DB_PORT"),
-		User:     viper.GetString("DB_USER"),
-		Password: viper.GetString("DB_PASSWORD"),
-		DBName:   viper.GetString("DB_NAME"),
-		SSLMode:  viper.GetString("DB_SSLMODE"),
-		MaxConns: viper.GetInt("DB_MAX_CONNS"),
-		Timeout:  dbTimeout,
+		DSN:                      viper.GetString("DB_DSN"),
+		Host:                     viper.GetString("DB_HOST"),
+		Port:                     viper.GetString("DB_PORT"),
+		User:                     viper.GetString("DB_USER"),
+		Password:                 viper.GetString("DB_PASSWORD"),
+		DBName:                   viper.GetString("DB_NAME"),
+		SSLMode:                  viper.GetString("DB_SSLMODE"),
+		SSLRootCert:              viper.GetString("DB_SSLROOTCERT"),
+		SSLCert:                  viper.GetString("DB_SSLCERT"),
+		SSLKey:                   viper.GetString("DB_SSLKEY"),
+		ApplicationName:          viper.GetString("DB_APPLICATION_NAME"),
+		MaxConns:                 viper.GetInt("DB_MAX_CONNS"),
+		MinConns:                 viper.GetInt("DB_MIN_CONNS"),
+		MaxConnLifetime:          maxConnLifetime,
+		MaxConnIdleTime:          maxConnIdleTime,
+		HealthCheckPeriod:        healthCheckPeriod,
+		StatementCacheMode:       viper.GetString("DB_STATEMENT_CACHE_MODE"),
+		Timeout:                  dbTimeout,
+		QueryTimeout:             queryTimeout,
+		StatementTimeout:         statementTimeout,
+		IdleInTransactionTimeout: idleInTransactionTimeout,
+		ConnectRetryTimeout:      connectRetryTimeout,
+		AutoMigrate:              viper.GetBool("DB_AUTO_MIGRATE"),
+		Replicas:                 splitList(viper.GetString("DB_REPLICAS")),
+		ReplicaStickiness:        replicaStickiness,
+		ReplicaCheckInterval:     replicaCheckInterval,
 	}
 
 	tokenExpireDelta, err := time.ParseDuration(viper.GetString("JWT_EXPIRE_DELTA"))
@@ -117,14 +496,207 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("ошибка парсинга JWT_EXPIRE_DELTA: %w", err)
 	}
 
+	impersonationTTL, err := time.ParseDuration(viper.GetString("JWT_IMPERSONATION_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга JWT_IMPERSONATION_TTL: %w", err)
+	}
+
+	loginGuard := LoginGuardConfig{
+		MaxFailures:      viper.GetInt("LOGIN_MAX_FAILURES"),
+		MaxFailuresPerIP: viper.GetInt("LOGIN_MAX_FAILURES_PER_IP"),
+	}
+	for key, target := range map[string]*time.Duration{
+		"LOGIN_FAILURE_WINDOW":   &loginGuard.FailureWindow,
+		"LOGIN_LOCKOUT_DURATION": &loginGuard.LockoutDuration,
+		"LOGIN_BASE_DELAY":       &loginGuard.BaseDelay,
+		"LOGIN_MAX_DELAY":        &loginGuard.MaxDelay,
+	} {
+		if *target, err = time.ParseDuration(viper.GetString(key)); err != nil {
+			return nil, fmt.Errorf("ошибка парсинга %s: %w", key, err)
+		}
+	}
+
+	oidcStateTTL, err := time.ParseDuration(viper.GetString("OIDC_STATE_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга OIDC_STATE_TTL: %w", err)
+	}
+
+	twoFactorChallengeTTL, err := time.ParseDuration(viper.GetString("AUTH_2FA_CHALLENGE_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга AUTH_2FA_CHALLENGE_TTL: %w", err)
+	}
+
 	config.Auth = AuthConfig{
-		JWTSecret:        viper.GetString("JWT_SECRET"),
-		TokenExpireDelta: tokenExpireDelta,
+		JWTSecret:            viper.GetString("JWT_SECRET"),
+		AdminPassword:        viper.GetString("ADMIN_PASSWORD"),
+		SigningKeyFile:       viper.GetString("JWT_SIGNING_KEY_FILE"),
+		VerificationKeyFiles: splitList(viper.GetString("JWT_VERIFICATION_KEY_FILES")),
+		AcceptHS256:          viper.GetBool("JWT_ACCEPT_HS256"),
+		TokenExpireDelta:     tokenExpireDelta,
+		ImpersonationTTL:     impersonationTTL,
+		LoginGuard:           loginGuard,
+		OIDC: OIDCConfig{
+			IssuerURL:    viper.GetString("OIDC_ISSUER_URL"),
+			ClientID:     viper.GetString("OIDC_CLIENT_ID"),
+			ClientSecret: viper.GetString("OIDC_CLIENT_SECRET"),
+			StateSecret:  viper.GetString("OIDC_STATE_SECRET"),
+			RedirectURL:  viper.GetString("OIDC_REDIRECT_URL"),
+			Scopes:       splitList(viper.GetString("OIDC_SCOPES")),
+			StateTTL:     oidcStateTTL,
+		},
+		RequireTwoFactor:      viper.GetBool("AUTH_2FA_REQUIRED"),
+		TwoFactorIssuer:       viper.GetString("AUTH_2FA_ISSUER"),
+		TwoFactorChallengeTTL: twoFactorChallengeTTL,
+	}
+
+	logSamplePeriod, err := time.ParseDuration(viper.GetString("LOG_SAMPLE_PERIOD"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга LOG_SAMPLE_PERIOD: %w", err)
 	}
 
 	config.Logger = LoggerConfig{
-		Level: viper.GetString("LOG_LEVEL"),
+		Level:  viper.GetString("LOG_LEVEL"),
+		Format: strings.ToLower(viper.GetString("LOG_FORMAT")),
+		Output: strings.ToLower(viper.GetString("LOG_OUTPUT")),
+		File: LogFileConfig{
+			Path:       viper.GetString("LOG_FILE"),
+			MaxSizeMB:  viper.GetInt("LOG_FILE_MAX_SIZE_MB"),
+			MaxBackups: viper.GetInt("LOG_FILE_MAX_BACKUPS"),
+			MaxAgeDays: viper.GetInt("LOG_FILE_MAX_AGE_DAYS"),
+			Compress:   viper.GetBool("LOG_FILE_COMPRESS"),
+		},
+		Sampling: LogSamplingConfig{
+			Burst:      viper.GetInt("LOG_SAMPLE_BURST"),
+			Period:     logSamplePeriod,
+			InfoEvery:  viper.GetInt("LOG_SAMPLE_INFO_EVERY"),
+			DebugEvery: viper.GetInt("LOG_SAMPLE_DEBUG_EVERY"),
+		},
+	}
+
+	config.RateLimit = RateLimitConfig{
+		Enabled:       viper.GetBool("RATE_LIMIT_ENABLED"),
+		UserRate:      viper.GetFloat64("RATE_LIMIT_USER_RPS"),
+		UserBurst:     viper.GetInt("RATE_LIMIT_USER_BURST"),
+		LoginRate:     viper.GetFloat64("RATE_LIMIT_LOGIN_RPS"),
+		LoginBurst:    viper.GetInt("RATE_LIMIT_LOGIN_BURST"),
+		RedisAddr:     viper.GetString("RATE_LIMIT_REDIS_ADDR"),
+		RedisPassword: viper.GetString("RATE_LIMIT_REDIS_PASSWORD"),
+		RedisDB:       viper.GetInt("RATE_LIMIT_REDIS_DB"),
+	}
+
+	config.Tracing = TracingConfig{
+		Enabled:     viper.GetBool("TRACING_ENABLED"),
+		Endpoint:    viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Insecure:    viper.GetBool("OTEL_EXPORTER_OTLP_INSECURE"),
+		ServiceName: viper.GetString("OTEL_SERVICE_NAME"),
+		SampleRatio: viper.GetFloat64("TRACING_SAMPLE_RATIO"),
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	return &config, nil
 }
+
+// SecretValues текущие значения секретов для secrets.Store
+func (c *Config) SecretValues() map[string]string {
+	return map[string]string{
+		"JWT_SECRET":                c.Auth.JWTSecret,
+		"DB_PASSWORD":               c.Database.Password,
+		"OIDC_CLIENT_SECRET":        c.Auth.OIDC.ClientSecret,
+		"OIDC_STATE_SECRET":         c.Auth.OIDC.StateSecret,
+		"RATE_LIMIT_REDIS_PASSWORD": c.RateLimit.RedisPassword,
+		"ADMIN_PASSWORD":            c.Auth.AdminPassword,
+	}
+}
+
+// redactedValue заменяет секреты в выводе конфигурации
+const redactedValue = "[REDACTED]"
+
+// Redacted копия конфигурации со скрытыми секретами для вывода оператору
+func (c Config) Redacted() Config {
+	mask := func(value *string) {
+		if *value != "" {
+			*value = redactedValue
+		}
+	}
+	mask(&c.Auth.JWTSecret)
+	mask(&c.Auth.AdminPassword)
+	mask(&c.Database.Password)
+	mask(&c.Auth.OIDC.ClientSecret)
+	mask(&c.Auth.OIDC.StateSecret)
+	mask(&c.RateLimit.RedisPassword)
+	mask(&c.Secrets.VaultToken)
+	c.Database.PasswordSource = nil
+	if c.Database.DSN != "" {
+		c.Database.DSN = redactDSN(c.Database.DSN)
+	}
+
+	replicas := make([]string, len(c.Database.Replicas))
+	for i, dsn := range c.Database.Replicas {
+		replicas[i] = redactDSN(dsn)
+	}
+	c.Database.Replicas = replicas
+	return c
+}
+
+// redactDSN скрывает пароль в DSN формата URL; DSN в формате key=value скрывается целиком
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		return redactedValue
+	}
+	return u.Redacted()
+}
+
+// NewSecretProvider хранилище секретов из конфигурации или nil, если оно не настроено
+func NewSecretProvider(cfg SecretsConfig) secrets.Provider {
+	if cfg.VaultAddr == "" {
+		return nil
+	}
+
+	token := func() string { return cfg.VaultToken }
+	if cfg.VaultTokenFile != "" {
+		// токен перечитывается из файла, который обновляет агент Vault
+		token = func() string {
+			if value, err := secrets.ReadFile(cfg.VaultTokenFile); err == nil {
+				return value
+			}
+			return cfg.VaultToken
+		}
+	}
+
+	return secrets.NewVaultProvider(cfg.VaultAddr, cfg.VaultMount, cfg.VaultPath, cfg.VaultKVVersion, token, &http.Client{Timeout: 10 * time.Second})
+}
+
+// loadSecretFiles подставляет значения из файлов, указанных в <KEY>_FILE
+func loadSecretFiles(keys []string) error {
+	for _, key := range keys {
+		file := viper.GetString(key + "_FILE")
+		if file == "" {
+			continue
+		}
+		if os.Getenv(key) != "" {
+			return fmt.Errorf("заданы одновременно %s и %s_FILE", key, key)
+		}
+
+		value, err := secrets.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		viper.Set(key, value)
+	}
+	return nil
+}
+
+// splitList разбирает список значений через запятую, пропуская пустые элементы
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"fmt"
+	"github.com/rs/zerolog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minSecretLength минимальная длина JWT_SECRET в prod: 256 бит для HMAC-SHA256
+const minSecretLength = 32
+
+// minAdminPasswordLength минимальная длина ADMIN_PASSWORD, как у паролей пользователей
+const minAdminPasswordLength = 8
+
+// ValidationError все найденные проблемы конфигурации, чтобы их можно было исправить за один раз
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "некорректная конфигурация:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate проверяет согласованность конфигурации, а в prod дополнительно отклоняет
+// значения по умолчанию для секретов и нешифрованное подключение к базе
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	switch c.Environment {
+	case EnvDev, EnvStaging, EnvProd:
+	default:
+		v.addf("APP_ENV: неизвестное окружение %q, ожидается dev, staging или prod", c.Environment)
+	}
+
+	v.port("SERVER_PORT", c.Server.Port)
+	v.port("GRPC_PORT", c.Server.GRPCPort)
+	if c.Server.Port != "" && c.Server.Port == c.Server.GRPCPort {
+		v.addf("SERVER_PORT и GRPC_PORT совпадают (%s)", c.Server.Port)
+	}
+	v.positive("SERVER_READ_TIMEOUT", c.Server.ReadTimeout)
+	v.positive("SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout)
+	v.positive("SERVER_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout)
+	if c.Server.DrainDelay < 0 {
+		v.addf("SERVER_DRAIN_DELAY не может быть отрицательным")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				v.addf("TRUSTED_PROXIES: %q не является IP-адресом или подсетью", proxy)
+			}
+		}
+	}
+	if c.Server.PublicURL != "" {
+		if u, err := url.Parse(c.Server.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf("PUBLIC_URL: %q не является адресом http или https", c.Server.PublicURL)
+		}
+	}
+
+	if c.Database.DSN == "" {
+		if c.Database.Host == "" {
+			v.addf("DB_HOST не задан")
+		}
+		if c.Database.DBName == "" {
+			v.addf("DB_NAME не задан")
+		}
+		v.port("DB_PORT", c.Database.Port)
+	}
+	if c.Database.MaxConns < 1 {
+		v.addf("DB_MAX_CONNS должен быть не меньше 1, получено %d", c.Database.MaxConns)
+	}
+	if c.Database.MinConns < 0 || c.Database.MinConns > c.Database.MaxConns {
+		v.addf("DB_MIN_CONNS должен быть от 0 до DB_MAX_CONNS (%d), получено %d", c.Database.MaxConns, c.Database.MinConns)
+	}
+	v.positive("DB_TIMEOUT", c.Database.Timeout)
+	v.positive("DB_HEALTH_CHECK_PERIOD", c.Database.HealthCheckPeriod)
+	if c.Database.MaxConnLifetime < 0 || c.Database.MaxConnIdleTime < 0 || c.Database.ConnectRetryTimeout < 0 {
+		v.addf("DB_MAX_CONN_LIFETIME, DB_MAX_CONN_IDLE_TIME и DB_CONNECT_RETRY_TIMEOUT не могут быть отрицательными")
+	}
+	if c.Database.QueryTimeout < 0 || c.Database.StatementTimeout < 0 || c.Database.IdleInTransactionTimeout < 0 {
+		v.addf("DB_QUERY_TIMEOUT, DB_STATEMENT_TIMEOUT и DB_IDLE_IN_TRANSACTION_TIMEOUT не могут быть отрицательными")
+	}
+	if c.Database.QueryTimeout > c.Server.WriteTimeout && c.Server.WriteTimeout > 0 {
+		v.addf("DB_QUERY_TIMEOUT (%s) больше SERVER_WRITE_TIMEOUT (%s)", c.Database.QueryTimeout, c.Server.WriteTimeout)
+	}
+	switch c.Database.StatementCacheMode {
+	case "cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol":
+	default:
+		v.addf("DB_STATEMENT_CACHE_MODE: неизвестный режим %q", c.Database.StatementCacheMode)
+	}
+	switch c.Database.sslMode() {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		v.addf("DB_SSLMODE: неизвестный режим %q", c.Database.sslMode())
+	}
+	if (c.Database.SSLCert == "") != (c.Database.SSLKey == "") {
+		v.addf("DB_SSLCERT и DB_SSLKEY задаются вместе")
+	}
+	if len(c.Database.Replicas) > 0 {
+		v.positive("DB_REPLICA_CHECK_INTERVAL", c.Database.ReplicaCheckInterval)
+		if c.Database.ReplicaStickiness < 0 {
+			v.addf("DB_REPLICA_STICKINESS не может быть отрицательным")
+		}
+	}
+
+	if c.Auth.JWTSecret == "" {
+		v.addf("JWT_SECRET не задан")
+	}
+	v.positive("JWT_EXPIRE_DELTA", c.Auth.TokenExpireDelta)
+	v.positive("JWT_IMPERSONATION_TTL", c.Auth.ImpersonationTTL)
+	if c.Auth.ImpersonationTTL > c.Auth.TokenExpireDelta && c.Auth.TokenExpireDelta > 0 {
+		v.addf("JWT_IMPERSONATION_TTL (%s) больше JWT_EXPIRE_DELTA (%s)", c.Auth.ImpersonationTTL, c.Auth.TokenExpireDelta)
+	}
+	v.positive("AUTH_2FA_CHALLENGE_TTL", c.Auth.TwoFactorChallengeTTL)
+
+	if c.Auth.OIDC.IssuerURL != "" {
+		if c.Auth.OIDC.ClientID == "" {
+			v.addf("OIDC_CLIENT_ID не задан при включенном OIDC")
+		}
+		if c.Auth.OIDC.RedirectURL == "" {
+			v.addf("OIDC_REDIRECT_URL не задан при включенном OIDC")
+		}
+		if c.Auth.OIDC.StateSecret == "" {
+			v.addf("OIDC_STATE_SECRET не задан при включенном OIDC")
+		} else if c.Auth.OIDC.StateSecret == c.Auth.JWTSecret {
+			v.addf("OIDC_STATE_SECRET совпадает с JWT_SECRET")
+		}
+		v.positive("OIDC_STATE_TTL", c.Auth.OIDC.StateTTL)
+	}
+
+	guard := c.Auth.LoginGuard
+	if guard.MaxFailures < 1 {
+		v.addf("LOGIN_MAX_FAILURES должен быть не меньше 1, получено %d", guard.MaxFailures)
+	}
+	if guard.MaxFailuresPerIP < guard.MaxFailures {
+		v.addf("LOGIN_MAX_FAILURES_PER_IP (%d) меньше LOGIN_MAX_FAILURES (%d)", guard.MaxFailuresPerIP, guard.MaxFailures)
+	}
+	v.positive("LOGIN_FAILURE_WINDOW", guard.FailureWindow)
+	v.positive("LOGIN_LOCKOUT_DURATION", guard.LockoutDuration)
+	if guard.BaseDelay < 0 {
+		v.addf("LOGIN_BASE_DELAY не может быть отрицательным")
+	}
+	if guard.MaxDelay < guard.BaseDelay {
+		v.addf("LOGIN_MAX_DELAY (%s) меньше LOGIN_BASE_DELAY (%s)", guard.MaxDelay, guard.BaseDelay)
+	}
+
+	if _, err := zerolog.ParseLevel(strings.ToLower(c.Logger.Level)); err != nil {
+		v.addf("LOG_LEVEL: неизвестный уровень %q", c.Logger.Level)
+	}
+	switch c.Logger.Format {
+	case LogFormatConsole, LogFormatJSON:
+	default:
+		v.addf("LOG_FORMAT: неизвестный формат %q, ожидается console или json", c.Logger.Format)
+	}
+	switch c.Logger.Output {
+	case LogOutputStdout:
+	case LogOutputFile:
+		if c.Logger.File.Path == "" {
+			v.addf("LOG_FILE не задан при LOG_OUTPUT=file")
+		}
+		if c.Logger.File.MaxSizeMB < 1 {
+			v.addf("LOG_FILE_MAX_SIZE_MB должен быть не меньше 1, получено %d", c.Logger.File.MaxSizeMB)
+		}
+		if c.Logger.File.MaxBackups < 0 || c.Logger.File.MaxAgeDays < 0 {
+			v.addf("LOG_FILE_MAX_BACKUPS и LOG_FILE_MAX_AGE_DAYS не могут быть отрицательными")
+		}
+	default:
+		v.addf("LOG_OUTPUT: неизвестный приемник %q, ожидается stdout или file", c.Logger.Output)
+	}
+	if sampling := c.Logger.Sampling; sampling.InfoEvery > 1 || sampling.DebugEvery > 1 {
+		v.positive("LOG_SAMPLE_PERIOD", sampling.Period)
+		if sampling.Burst < 0 {
+			v.addf("LOG_SAMPLE_BURST не может быть отрицательным, получено %d", sampling.Burst)
+		}
+	}
+	if c.Logger.Sampling.InfoEvery < 0 || c.Logger.Sampling.DebugEvery < 0 {
+		v.addf("LOG_SAMPLE_INFO_EVERY и LOG_SAMPLE_DEBUG_EVERY не могут быть отрицательными")
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.UserRate <= 0 || c.RateLimit.LoginRate <= 0 {
+			v.addf("RATE_LIMIT_USER_RPS и RATE_LIMIT_LOGIN_RPS должны быть положительными")
+		}
+		if c.RateLimit.UserBurst < 1 || c.RateLimit.LoginBurst < 1 {
+			v.addf("RATE_LIMIT_USER_BURST и RATE_LIMIT_LOGIN_BURST должны быть не меньше 1")
+		}
+	}
+
+	if c.Tracing.Enabled {
+		if c.Tracing.Endpoint == "" {
+			v.addf("OTEL_EXPORTER_OTLP_ENDPOINT не задан при включенной трассировке")
+		}
+		if c.Tracing.ServiceName == "" {
+			v.addf("OTEL_SERVICE_NAME не задан при включенной трассировке")
+		}
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.addf("TRACING_SAMPLE_RATIO должен быть от 0 до 1, получено %g", c.Tracing.SampleRatio)
+	}
+
+	if c.Secrets.VaultAddr != "" {
+		if c.Secrets.VaultPath == "" {
+			v.addf("VAULT_PATH не задан при включенном Vault")
+		}
+		if c.Secrets.VaultToken == "" && c.Secrets.VaultTokenFile == "" {
+			v.addf("VAULT_TOKEN или VAULT_TOKEN_FILE не задан при включенном Vault")
+		}
+		if c.Secrets.VaultKVVersion != 1 && c.Secrets.VaultKVVersion != 2 {
+			v.addf("VAULT_KV_VERSION должен быть 1 или 2, получено %d", c.Secrets.VaultKVVersion)
+		}
+		v.positive("SECRETS_REFRESH_INTERVAL", c.Secrets.RefreshInterval)
+	}
+
+	if c.Auth.AdminPassword != "" && len(c.Auth.AdminPassword) < minAdminPasswordLength {
+		v.addf("ADMIN_PASSWORD: длина пароля должна быть не меньше %d символов", minAdminPasswordLength)
+	}
+
+	if c.Environment == EnvProd {
+		if c.Auth.JWTSecret == defaultJWTSecret {
+			v.addf("JWT_SECRET: в prod нельзя использовать значение по умолчанию")
+		} else if len(c.Auth.JWTSecret) < minSecretLength {
+			v.addf("JWT_SECRET: в prod длина секрета должна быть не меньше %d байт", minSecretLength)
+		}
+		// пароль из DSN не проверяется: значение по умолчанию относится только к DB_PASSWORD
+		if c.Database.DSN == "" && (c.Database.Password == "" || c.Database.Password == defaultDBPassword) {
+			v.addf("DB_PASSWORD: в prod нельзя использовать пустой пароль или значение по умолчанию")
+		}
+		// allow и prefer молча переходят на нешифрованное соединение, если сервер не поддерживает TLS
+		switch c.Database.sslMode() {
+		case "require", "verify-ca", "verify-full":
+		default:
+			v.addf("DB_SSLMODE: в prod требуется require, verify-ca или verify-full, получено %q", c.Database.sslMode())
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) positive(name string, value time.Duration) {
+	if value <= 0 {
+		v.addf("%s должен быть положительным, получено %s", name, value)
+	}
+}
+
+func (v *validator) port(name, value string) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		v.addf("%s: некорректный порт %q", name, value)
+	}
+}
+
+// sslMode режим TLS из DSN, если он задан, иначе DB_SSLMODE. pgx по умолчанию использует prefer
+func (c DatabaseConfig) sslMode() string {
+	if c.DSN == "" {
+		return c.SSLMode
+	}
+
+	mode := "prefer"
+	if u, err := url.Parse(c.DSN); err == nil && u.Scheme != "" {
+		if value := u.Query().Get("sslmode"); value != "" {
+			mode = value
+		}
+		return mode
+	}
+	for _, field := range strings.Fields(c.DSN) {
+		if value, ok := strings.CutPrefix(field, "sslmode="); ok {
+			mode = value
+		}
+	}
+	return mode
+}
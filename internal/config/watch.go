@@ -0,0 +1,160 @@
+package config
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// Watcher перечитывает конфигурацию при изменении YAML файла и по SIGHUP. Новая
+// конфигурация проходит ту же проверку, что и при запуске; применяются только настройки,
+// которые безопасно менять на лету, об остальных пишется в лог
+type Watcher struct {
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(cfg *Config)
+	load        func() (*Config, error)
+	log         *zerolog.Logger
+}
+
+func NewWatcher(initial *Config, log *zerolog.Logger) *Watcher {
+	return &Watcher{
+		current: initial,
+		load:    LoadConfig,
+		log:     log,
+	}
+}
+
+// Current действующая конфигурация
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Subscribe вызывает fn после каждого применения новой конфигурации. Вызовы идут
+// последовательно, поэтому fn не должен вызывать Reload
+func (w *Watcher) Subscribe(fn func(cfg *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload загружает конфигурацию заново. При ошибке загрузки или проверки продолжает
+// действовать прежняя конфигурация
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, err := w.load()
+	if err != nil {
+		w.log.Error().Err(err).Msg("новая конфигурация отклонена, действует прежняя")
+		return err
+	}
+
+	applied := *w.current
+	applyReloadable(&applied, next)
+
+	if restart := changedFields(&applied, next); len(restart) > 0 {
+		w.log.Warn().Strs("settings", restart).Msg("изменения вступят в силу только после перезапуска")
+	}
+
+	changed := changedFields(w.current, &applied)
+	if len(changed) == 0 {
+		w.log.Info().Msg("конфигурация перечитана, изменений для применения нет")
+		return nil
+	}
+
+	w.current = &applied
+	for _, fn := range w.subscribers {
+		fn(w.current)
+	}
+	w.log.Info().Strs("settings", changed).Msg("конфигурация обновлена без перезапуска")
+	return nil
+}
+
+// Watch следит за файлом конфигурации и сигналом SIGHUP до отмены ctx
+func (w *Watcher) Watch(ctx context.Context) {
+	if file := w.Current().File; file != "" {
+		// отдельный экземпляр viper: глобальный пересоздается в LoadConfig
+		v := viper.New()
+		v.SetConfigFile(file)
+		v.OnConfigChange(func(event fsnotify.Event) {
+			if ctx.Err() != nil {
+				return
+			}
+			w.log.Info().Str("file", event.Name).Msg("файл конфигурации изменен")
+			_ = w.Reload()
+		})
+		v.WatchConfig()
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			w.log.Info().Msg("получен SIGHUP, конфигурация перечитывается")
+			_ = w.Reload()
+		}
+	}
+}
+
+// applyReloadable переносит в dst настройки, которые читаются при каждом использовании.
+// Секреты подхватываются через secrets.Store, кроме OIDC_CLIENT_SECRET: провайдер OIDC
+// получает его один раз при запуске
+func applyReloadable(dst, src *Config) {
+	dst.Logger.Level = src.Logger.Level
+
+	dst.RateLimit.Enabled = src.RateLimit.Enabled
+	dst.RateLimit.UserRate = src.RateLimit.UserRate
+	dst.RateLimit.UserBurst = src.RateLimit.UserBurst
+	dst.RateLimit.LoginRate = src.RateLimit.LoginRate
+	dst.RateLimit.LoginBurst = src.RateLimit.LoginBurst
+
+	dst.Auth.JWTSecret = src.Auth.JWTSecret
+	dst.Auth.SigningKeyFile = src.Auth.SigningKeyFile
+	dst.Auth.VerificationKeyFiles = src.Auth.VerificationKeyFiles
+	dst.Auth.OIDC.StateSecret = src.Auth.OIDC.StateSecret
+	dst.Database.Password = src.Database.Password
+	dst.RateLimit.RedisPassword = src.RateLimit.RedisPassword
+}
+
+// changedFields имена различающихся настроек, например RateLimit.UserRate. Значения
+// не возвращаются, чтобы секреты не попадали в лог
+func changedFields(a, b *Config) []string {
+	var changed []string
+	collectChanged("", reflect.ValueOf(*a), reflect.ValueOf(*b), &changed)
+	return changed
+}
+
+func collectChanged(prefix string, a, b reflect.Value, changed *[]string) {
+	for i := 0; i < a.NumField(); i++ {
+		name := a.Type().Field(i).Name
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		x, y := a.Field(i), b.Field(i)
+		switch x.Kind() {
+		case reflect.Struct:
+			collectChanged(name, x, y, changed)
+		case reflect.Func:
+			// функции вроде PasswordSource задаются в коде, а не в конфигурации
+		default:
+			if !reflect.DeepEqual(x.Interface(), y.Interface()) {
+				*changed = append(*changed, name)
+			}
+		}
+	}
+}
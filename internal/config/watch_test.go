@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func newTestWatcher(t *testing.T, content string) (*Watcher, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, path, content)
+	t.Setenv("CONFIG_FILE", path)
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	require.Equal(t, path, cfg.File)
+
+	log := zerolog.Nop()
+	return NewWatcher(cfg, &log), path
+}
+
+func TestWatcherReloadAppliesSafeSettings(t *testing.T) {
+	watcher, path := newTestWatcher(t, "LOG_LEVEL: info\nRATE_LIMIT_USER_RPS: 20\n")
+
+	var applied []*Config
+	watcher.Subscribe(func(cfg *Config) { applied = append(applied, cfg) })
+
+	writeConfigFile(t, path, "LOG_LEVEL: debug\nRATE_LIMIT_USER_RPS: 50\nSERVER_PORT: \"9090\"\n")
+	require.NoError(t, watcher.Reload())
+
+	require.Len(t, applied, 1)
+	assert.Equal(t, "debug", applied[0].Logger.Level)
+	assert.Equal(t, float64(50), applied[0].RateLimit.UserRate)
+	// порт меняется только после перезапуска
+	assert.Equal(t, "8080", applied[0].Server.Port)
+	assert.Same(t, applied[0], watcher.Current())
+}
+
+func TestWatcherReloadWithoutSafeChanges(t *testing.T) {
+	watcher, path := newTestWatcher(t, "LOG_LEVEL: info\n")
+
+	called := false
+	watcher.Subscribe(func(*Config) { called = true })
+
+	writeConfigFile(t, path, "LOG_LEVEL: info\nDB_MAX_CONNS: 50\n")
+	require.NoError(t, watcher.Reload())
+
+	assert.False(t, called)
+	assert.Equal(t, 10, watcher.Current().Database.MaxConns)
+}
+
+func TestWatcherReloadAppliesKeyFiles(t *testing.T) {
+	watcher, path := newTestWatcher(t, "JWT_SIGNING_KEY_FILE: /keys/old.pem\n")
+
+	var applied []*Config
+	watcher.Subscribe(func(cfg *Config) { applied = append(applied, cfg) })
+
+	writeConfigFile(t, path, "JWT_SIGNING_KEY_FILE: /keys/new.pem\nJWT_VERIFICATION_KEY_FILES: /keys/old.pem\n")
+	require.NoError(t, watcher.Reload())
+
+	require.Len(t, applied, 1)
+	assert.Equal(t, "/keys/new.pem", applied[0].Auth.SigningKeyFile)
+	assert.Equal(t, []string{"/keys/old.pem"}, applied[0].Auth.VerificationKeyFiles)
+}
+
+func TestWatcherReloadKeepsConfigOnValidationError(t *testing.T) {
+	watcher, path := newTestWatcher(t, "LOG_LEVEL: info\n")
+	initial := watcher.Current()
+
+	called := false
+	watcher.Subscribe(func(*Config) { called = true })
+
+	writeConfigFile(t, path, "LOG_LEVEL: debug\nRATE_LIMIT_USER_RPS: -1\n")
+	require.Error(t, watcher.Reload())
+
+	assert.False(t, called)
+	assert.Same(t, initial, watcher.Current())
+}
+
+func TestWatcherWatchFile(t *testing.T) {
+	watcher, path := newTestWatcher(t, "LOG_LEVEL: info\n")
+
+	levels := make(chan string, 10)
+	watcher.Subscribe(func(cfg *Config) { levels <- cfg.Logger.Level })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Watch(ctx)
+
+	// наблюдение за файлом запускается асинхронно, поэтому запись повторяется
+	deadline := time.After(5 * time.Second)
+	for {
+		writeConfigFile(t, path, "LOG_LEVEL: warn\n")
+		select {
+		case level := <-levels:
+			assert.Equal(t, "warn", level)
+			return
+		case <-time.After(200 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("изменение файла конфигурации не применено")
+		}
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	a := &Config{Server: ServerConfig{Port: "8080"}}
+	b := &Config{Server: ServerConfig{Port: "9090"}, Auth: AuthConfig{JWTSecret: "secret"}}
+	b.Database.PasswordSource = func() string { return "" }
+
+	assert.Equal(t, []string{"Server.Port", "Auth.JWTSecret"}, changedFields(a, b))
+}
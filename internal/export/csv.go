@@ -0,0 +1,51 @@
+package export
+
+import (
+	"encoding/csv"
+	"github.com/kkboranbay/task-service/internal/model"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{"id", "title", "description", "status", "due_date", "created_at", "updated_at"}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Begin() error {
+	return c.w.Write(csvHeader)
+}
+
+func (c *csvWriter) Write(task *model.Task) error {
+	dueDate := ""
+	if task.DueDate != nil {
+		dueDate = task.DueDate.UTC().Format(time.RFC3339)
+	}
+
+	err := c.w.Write([]string{
+		strconv.FormatInt(task.ID, 10),
+		task.Title,
+		task.Description,
+		string(task.Status),
+		dueDate,
+		task.CreatedAt.UTC().Format(time.RFC3339),
+		task.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) End() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,51 @@
+package export
+
+import (
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/model"
+	"io"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson"
+	FormatICS    Format = "ics"
+)
+
+// Writer пишет задачи в выходной поток по одной, не накапливая их в памяти
+type Writer interface {
+	Begin() error
+	Write(task *model.Task) error
+	End() error
+}
+
+func ContentType(format Format) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSON:
+		return "application/json; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson; charset=utf-8"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatJSON:
+		return newJSONWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatICS:
+		return NewICSWriter(w, ICSComponentTodo, "Задачи"), nil
+	}
+	return nil, fmt.Errorf("неподдерживаемый формат экспорта: %s", format)
+}
@@ -0,0 +1,141 @@
+package export
+
+import (
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/model"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type ICSComponent string
+
+const (
+	// ICSComponentTodo выгружает каждую задачу как VTODO со сроком в DUE
+	ICSComponentTodo ICSComponent = "VTODO"
+	// ICSComponentEvent выгружает задачи со сроком как VEVENT, их понимает большинство календарей
+	ICSComponentEvent ICSComponent = "VEVENT"
+)
+
+const (
+	icsTimeFormat  = "20060102T150405Z"
+	icsMaxLineSize = 75
+)
+
+var icsStatus = map[model.TaskStatus]string{
+	model.TaskStatusPending:    "NEEDS-ACTION",
+	model.TaskStatusInProgress: "IN-PROCESS",
+	model.TaskStatusCompleted:  "COMPLETED",
+}
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// ICSWriter пишет календарь iCalendar (RFC 5545)
+type ICSWriter struct {
+	w         io.Writer
+	component ICSComponent
+	name      string
+}
+
+func NewICSWriter(w io.Writer, component ICSComponent, name string) *ICSWriter {
+	return &ICSWriter{
+		w:         w,
+		component: component,
+		name:      name,
+	}
+}
+
+func (i *ICSWriter) Begin() error {
+	return i.lines(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//kkboranbay//task-service//RU",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:"+icsEscaper.Replace(i.name),
+	)
+}
+
+func (i *ICSWriter) Write(task *model.Task) error {
+	if i.component == ICSComponentEvent && task.DueDate == nil {
+		return nil
+	}
+
+	lines := []string{
+		"BEGIN:" + string(i.component),
+		fmt.Sprintf("UID:task-%d-%d@task-service", task.UserID, task.ID),
+		"DTSTAMP:" + icsTime(task.UpdatedAt),
+		"CREATED:" + icsTime(task.CreatedAt),
+		"LAST-MODIFIED:" + icsTime(task.UpdatedAt),
+		"SUMMARY:" + icsEscaper.Replace(task.Title),
+	}
+	if task.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+icsEscaper.Replace(task.Description))
+	}
+
+	switch i.component {
+	case ICSComponentTodo:
+		lines = append(lines, "STATUS:"+icsStatus[task.Status])
+		if task.DueDate != nil {
+			lines = append(lines, "DUE:"+icsTime(*task.DueDate))
+		}
+		if task.Status == model.TaskStatusCompleted {
+			lines = append(lines, "COMPLETED:"+icsTime(task.UpdatedAt))
+		}
+	case ICSComponentEvent:
+		// событие без DTEND длится ноль времени и отображается в момент срока задачи
+		lines = append(lines, "DTSTART:"+icsTime(*task.DueDate), "TRANSP:TRANSPARENT")
+	}
+
+	lines = append(lines, "END:"+string(i.component))
+	return i.lines(lines...)
+}
+
+func (i *ICSWriter) End() error {
+	return i.lines("END:VCALENDAR")
+}
+
+func (i *ICSWriter) lines(lines ...string) error {
+	var b strings.Builder
+	for _, line := range lines {
+		foldLine(&b, line)
+	}
+	_, err := io.WriteString(i.w, b.String())
+	return err
+}
+
+// foldLine переносит строки длиннее 75 октетов, не разрывая UTF-8 символы
+func foldLine(b *strings.Builder, line string) {
+	limit := icsMaxLineSize
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// пробел в начале строки продолжения тоже занимает октет
+		limit = icsMaxLineSize - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// ParseICSComponent разбирает имя компонента из query-параметра; пустое значение означает VTODO
+func ParseICSComponent(value string) (ICSComponent, error) {
+	switch strings.ToUpper(value) {
+	case "", "VTODO":
+		return ICSComponentTodo, nil
+	case "VEVENT":
+		return ICSComponentEvent, nil
+	}
+	return "", fmt.Errorf("неподдерживаемый компонент iCalendar: %s", value)
+}
+
+var _ Writer = (*ICSWriter)(nil)
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format(icsTimeFormat)
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"bytes"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestICSWriterTodo(t *testing.T) {
+	due := time.Date(2024, 3, 1, 9, 0, 0, 0, time.FixedZone("UTC+5", 5*3600))
+	task := testutils.TaskFixture(func(t *model.Task) {
+		t.Title = "Задача; с разделителями, и\nпереносом"
+		t.Description = strings.Repeat("очень длинное описание ", 10)
+		t.Status = model.TaskStatusInProgress
+		t.DueDate = &due
+	})
+
+	var buf bytes.Buffer
+	w := NewICSWriter(&buf, ICSComponentTodo, "Задачи")
+	require.NoError(t, w.Begin())
+	require.NoError(t, w.Write(task))
+	require.NoError(t, w.End())
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Contains(t, out, "STATUS:IN-PROCESS\r\n")
+	assert.Contains(t, out, "DUE:20240301T040000Z\r\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), icsMaxLineSize, "строка длиннее 75 октетов: %q", line)
+		assert.True(t, utf8.ValidString(line), "разорван UTF-8 символ: %q", line)
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	assert.Contains(t, unfolded, `SUMMARY:Задача\; с разделителями\, и\nпереносом`+"\r\n")
+	assert.Contains(t, unfolded, "DESCRIPTION:"+task.Description+"\r\n")
+}
+
+func TestICSWriterEventSkipsTasksWithoutDueDate(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewICSWriter(&buf, ICSComponentEvent, "Задачи")
+	require.NoError(t, w.Write(testutils.TaskFixture()))
+
+	assert.Empty(t, buf.String())
+}
+
+func TestParseICSComponent(t *testing.T) {
+	component, err := ParseICSComponent("")
+	require.NoError(t, err)
+	assert.Equal(t, ICSComponentTodo, component)
+
+	component, err = ParseICSComponent("vevent")
+	require.NoError(t, err)
+	assert.Equal(t, ICSComponentEvent, component)
+
+	_, err = ParseICSComponent("vjournal")
+	assert.Error(t, err)
+}
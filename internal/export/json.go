@@ -0,0 +1,64 @@
+package export
+
+import (
+	"encoding/json"
+	"github.com/kkboranbay/task-service/internal/model"
+	"io"
+)
+
+// jsonWriter пишет JSON-массив поэлементно
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{w: w}
+}
+
+func (j *jsonWriter) Begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonWriter) Write(task *model.Task) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.count++
+
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) End() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonWriter) Begin() error {
+	return nil
+}
+
+func (n *ndjsonWriter) Write(task *model.Task) error {
+	return n.enc.Encode(task)
+}
+
+func (n *ndjsonWriter) End() error {
+	return nil
+}
@@ -0,0 +1,167 @@
+// Package health реестр проверок зависимостей для проб /livez, /readyz и /startupz
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded отказал некритичный компонент: сервис продолжает принимать запросы
+	StatusDegraded    Status = "degraded"
+	StatusUnavailable Status = "unavailable"
+)
+
+// defaultTimeout используется для проверок без собственного таймаута
+const defaultTimeout = 2 * time.Second
+
+type CheckFunc func(ctx context.Context) error
+
+type Check struct {
+	Name    string
+	Check   CheckFunc
+	Timeout time.Duration
+	// Critical отказ критичного компонента снимает сервис с балансировки
+	Critical bool
+}
+
+type ComponentReport struct {
+	Name       string `json:"name"`
+	Status     Status `json:"status"`
+	Critical   bool   `json:"critical"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Status     Status            `json:"status"`
+	Reason     string            `json:"reason,omitempty"`
+	Components []ComponentReport `json:"components,omitempty"`
+}
+
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []Check
+	started  atomic.Bool
+	draining atomic.Bool
+	log      *zerolog.Logger
+}
+
+func NewRegistry(log *zerolog.Logger) *Registry {
+	return &Registry{log: log}
+}
+
+func (r *Registry) Register(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check)
+}
+
+// SetDraining переводит готовность в unavailable на время остановки сервера
+func (r *Registry) SetDraining() {
+	r.draining.Store(true)
+}
+
+// Live сообщает, что процесс жив; зависимости не проверяются, чтобы отказ базы
+// не приводил к перезапуску всех реплик
+func (r *Registry) Live() Report {
+	return Report{Status: StatusOK}
+}
+
+// Ready проверяет все зависимости; во время остановки сервер сразу не готов
+func (r *Registry) Ready(ctx context.Context) Report {
+	if r.draining.Load() {
+		return Report{Status: StatusUnavailable, Reason: "сервис останавливается"}
+	}
+	return r.Run(ctx)
+}
+
+// Startup успешен, когда критичные проверки прошли хотя бы раз; дальше результат не пересчитывается
+func (r *Registry) Startup(ctx context.Context) Report {
+	if r.started.Load() {
+		return Report{Status: StatusOK}
+	}
+
+	report := r.Run(ctx)
+	if report.Status != StatusUnavailable {
+		r.started.Store(true)
+		r.log.Info().Msg("запуск сервиса завершен, зависимости доступны")
+	}
+	return report
+}
+
+// Run выполняет проверки параллельно, каждую со своим таймаутом
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]Check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Components: make([]ComponentReport, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Components[i] = r.runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		if component.Status == StatusOK {
+			continue
+		}
+		if component.Critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (r *Registry) runCheck(ctx context.Context, check Check) ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check.Check(ctx)
+	}()
+
+	// проверка, не уважающая контекст, не должна задерживать ответ пробы
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("таймаут проверки: %w", ctx.Err())
+	}
+
+	component := ComponentReport{
+		Name:       check.Name,
+		Status:     StatusOK,
+		Critical:   check.Critical,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		component.Status = StatusUnavailable
+		component.Error = err.Error()
+		r.log.Warn().Err(err).Str("component", check.Name).Bool("critical", check.Critical).Msg("проверка зависимости не пройдена")
+	}
+	return component
+}
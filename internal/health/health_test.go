@@ -0,0 +1,108 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func ok(ctx context.Context) error { return nil }
+
+func failing(ctx context.Context) error { return errors.New("соединение отклонено") }
+
+func TestRegistryReady(t *testing.T) {
+	tests := []struct {
+		name     string
+		checks   []Check
+		expected Status
+	}{
+		{name: "no_checks", expected: StatusOK},
+		{
+			name:     "all_ok",
+			checks:   []Check{{Name: "database", Check: ok, Critical: true}, {Name: "redis", Check: ok}},
+			expected: StatusOK,
+		},
+		{
+			name:     "optional_failed",
+			checks:   []Check{{Name: "database", Check: ok, Critical: true}, {Name: "redis", Check: failing}},
+			expected: StatusDegraded,
+		},
+		{
+			name:     "critical_failed",
+			checks:   []Check{{Name: "database", Check: failing, Critical: true}, {Name: "redis", Check: failing}},
+			expected: StatusUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := zerolog.Nop()
+			registry := NewRegistry(&log)
+			for _, check := range tt.checks {
+				registry.Register(check)
+			}
+
+			report := registry.Ready(context.Background())
+			assert.Equal(t, tt.expected, report.Status)
+			require.Len(t, report.Components, len(tt.checks))
+			for i, check := range tt.checks {
+				assert.Equal(t, check.Name, report.Components[i].Name)
+			}
+		})
+	}
+}
+
+func TestRegistryCheckTimeout(t *testing.T) {
+	log := zerolog.Nop()
+	registry := NewRegistry(&log)
+	block := make(chan struct{})
+	defer close(block)
+	// проверка игнорирует контекст, но проба все равно отвечает по таймауту
+	registry.Register(Check{Name: "notifier", Timeout: 20 * time.Millisecond, Check: func(ctx context.Context) error {
+		<-block
+		return nil
+	}})
+
+	report := registry.Ready(context.Background())
+
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, StatusUnavailable, report.Components[0].Status)
+	assert.Contains(t, report.Components[0].Error, "таймаут проверки")
+}
+
+func TestRegistryDraining(t *testing.T) {
+	log := zerolog.Nop()
+	registry := NewRegistry(&log)
+	registry.Register(Check{Name: "database", Check: ok, Critical: true})
+
+	registry.SetDraining()
+
+	assert.Equal(t, StatusUnavailable, registry.Ready(context.Background()).Status)
+	assert.Equal(t, StatusOK, registry.Live().Status)
+}
+
+func TestRegistryStartup(t *testing.T) {
+	log := zerolog.Nop()
+	registry := NewRegistry(&log)
+	available := false
+	registry.Register(Check{Name: "database", Critical: true, Check: func(ctx context.Context) error {
+		if !available {
+			return errors.New("база еще не запущена")
+		}
+		return nil
+	}})
+
+	assert.Equal(t, StatusUnavailable, registry.Startup(context.Background()).Status)
+
+	available = true
+	assert.Equal(t, StatusOK, registry.Startup(context.Background()).Status)
+
+	// после успешного запуска отказ зависимости влияет только на готовность
+	available = false
+	assert.Equal(t, StatusOK, registry.Startup(context.Background()).Status)
+	assert.Equal(t, StatusUnavailable, registry.Ready(context.Background()).Status)
+}
@@ -0,0 +1,148 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/model"
+	"io"
+	"mime"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// maxLineSize ограничивает размер одной строки NDJSON
+const maxLineSize = 1 << 20
+
+var ErrMissingTitleColumn = errors.New("в заголовке CSV отсутствует колонка title")
+
+// DetectFormat определяет формат по явному параметру или по Content-Type запроса
+func DetectFormat(format, contentType string) (Format, error) {
+	if format != "" {
+		switch Format(format) {
+		case FormatCSV, FormatNDJSON:
+			return Format(format), nil
+		}
+		return "", fmt.Errorf("неподдерживаемый формат импорта: %s", format)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv":
+		return FormatCSV, nil
+	case "application/x-ndjson", "application/jsonl", "application/json":
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("не удалось определить формат импорта по Content-Type: %s", contentType)
+}
+
+// Parse читает задачи из файла. Ошибки отдельных строк возвращаются списком с номерами строк файла,
+// ошибка целиком возвращается только если файл нельзя разобрать вообще
+func Parse(format Format, r io.Reader) ([]model.ImportRow, []model.ImportRowError, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatNDJSON:
+		return parseNDJSON(r)
+	}
+	return nil, nil, fmt.Errorf("неподдерживаемый формат импорта: %s", format)
+}
+
+func parseCSV(r io.Reader) ([]model.ImportRow, []model.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка чтения заголовка CSV: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, nil, ErrMissingTitleColumn
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []model.ImportRow
+	var rowErrors []model.ImportRowError
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("ошибка чтения CSV: %w", err)
+			}
+			rowErrors = append(rowErrors, model.ImportRowError{Row: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		task := model.CreateTaskRequest{
+			Title:       field(record, "title"),
+			Description: field(record, "description"),
+			Status:      model.TaskStatus(field(record, "status")),
+		}
+		if dueDate := field(record, "due_date"); dueDate != "" {
+			t, err := time.Parse(time.RFC3339, dueDate)
+			if err != nil {
+				rowErrors = append(rowErrors, model.ImportRowError{Row: line, Error: "некорректный формат due_date, ожидается RFC3339"})
+				continue
+			}
+			task.DueDate = &t
+		}
+
+		rows = append(rows, model.ImportRow{Row: line, Task: task})
+	}
+
+	return rows, rowErrors, nil
+}
+
+func parseNDJSON(r io.Reader) ([]model.ImportRow, []model.ImportRowError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var rows []model.ImportRow
+	var rowErrors []model.ImportRowError
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var task model.CreateTaskRequest
+		if err := json.Unmarshal(data, &task); err != nil {
+			rowErrors = append(rowErrors, model.ImportRowError{Row: line, Error: "некорректный JSON: " + err.Error()})
+			continue
+		}
+		rows = append(rows, model.ImportRow{Row: line, Task: task})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("ошибка чтения NDJSON: %w", err)
+	}
+
+	return rows, rowErrors, nil
+}
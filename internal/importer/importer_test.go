@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		contentType string
+		want        Format
+		wantErr     bool
+	}{
+		{name: "explicit_format", format: "ndjson", contentType: "text/csv", want: FormatNDJSON},
+		{name: "csv_content_type", contentType: "text/csv; charset=utf-8", want: FormatCSV},
+		{name: "ndjson_content_type", contentType: "application/x-ndjson", want: FormatNDJSON},
+		{name: "unknown_format", format: "xml", wantErr: true},
+		{name: "unknown_content_type", contentType: "application/octet-stream", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.format, tt.contentType)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseCSVExportedFile(t *testing.T) {
+	// файл экспорта можно загрузить обратно: лишние колонки игнорируются
+	input := "\ufeffid,title,description,status,due_date,created_at,updated_at\n" +
+		"1,\"Многострочное\nописание\",desc,completed,,2024-01-01T00:00:00Z,2024-01-01T00:00:00Z\n" +
+		"2,Вторая,,pending,2024-03-01T09:00:00Z,2024-01-01T00:00:00Z,2024-01-01T00:00:00Z\n"
+
+	rows, rowErrors, err := Parse(FormatCSV, strings.NewReader(input))
+
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 2, rows[0].Row)
+	assert.Equal(t, "Многострочное\nописание", rows[0].Task.Title)
+	assert.Equal(t, model.TaskStatusCompleted, rows[0].Task.Status)
+	assert.Equal(t, 4, rows[1].Row)
+	require.NotNil(t, rows[1].Task.DueDate)
+}
+
+func TestParseCSVBrokenQuote(t *testing.T) {
+	input := "title,status\n\"broken,pending\n"
+
+	rows, rowErrors, err := Parse(FormatCSV, strings.NewReader(input))
+
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, 2, rowErrors[0].Row)
+}
@@ -0,0 +1,283 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Key ключ подписи или проверки JWT. ID вычисляется как отпечаток открытого ключа (RFC 7638),
+// поэтому одинаков на всех экземплярах сервиса без отдельной настройки kid
+type Key struct {
+	ID        string
+	Algorithm string
+	Public    crypto.PublicKey
+	private   crypto.Signer
+}
+
+func (k *Key) Method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+func (k *Key) Private() crypto.Signer {
+	return k.private
+}
+
+// KeySet ключ подписи и набор ключей проверки. Для ротации без простоя новый ключ сначала
+// добавляется в проверочные на всех экземплярах, затем становится ключом подписи, а старый
+// удаляется из проверочных после истечения выданных им токенов
+type KeySet struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+}
+
+// Load читает закрытый ключ подписи и дополнительные ключи проверки из PEM-файлов.
+// Файлы проверки могут содержать как открытые, так и закрытые ключи
+func Load(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	signing, err := loadKey(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("файл %s не содержит закрытого ключа для подписи", signingKeyFile)
+	}
+
+	set := &KeySet{
+		signing: signing,
+		keys:    map[string]*Key{signing.ID: signing},
+	}
+
+	for _, file := range verificationKeyFiles {
+		key, err := loadKey(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := set.keys[key.ID]; !ok {
+			set.keys[key.ID] = key
+		}
+	}
+
+	return set, nil
+}
+
+// Replace атомарно подменяет ключи набора ключами из other, например после перечитывания файлов
+func (s *KeySet) Replace(other *KeySet) {
+	other.mu.RLock()
+	signing, keys := other.signing, other.keys
+	other.mu.RUnlock()
+
+	s.mu.Lock()
+	s.signing = signing
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+func (s *KeySet) SigningKey() *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signing
+}
+
+func (s *KeySet) VerificationKey(kid string) (*Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// JWK открытый ключ в формате RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает все ключи проверки, отсортированные по kid
+func (s *KeySet) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := publicJWK(key.Public)
+		jwk.KeyID = key.ID
+		jwk.Use = "sig"
+		jwk.Algorithm = key.Algorithm
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].KeyID < jwks.Keys[j].KeyID })
+	return jwks
+}
+
+func loadKey(file string) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ключа %s: %w", file, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("файл %s не содержит PEM-блока", file)
+	}
+
+	key, err := parseKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора ключа %s: %w", file, err)
+	}
+	return key, nil
+}
+
+func parseKey(block *pem.Block) (*Key, error) {
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM-блока %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		parsed = signer.Public()
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < 2048 {
+			return nil, errors.New("длина RSA ключа должна быть не меньше 2048 бит")
+		}
+		key.Algorithm = jwt.SigningMethodRS256.Alg()
+	case ed25519.PublicKey:
+		key.Algorithm = jwt.SigningMethodEdDSA.Alg()
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %T, ожидается RSA или Ed25519", parsed)
+	}
+
+	key.Public = parsed
+	key.ID = thumbprint(publicJWK(parsed))
+	return key, nil
+}
+
+// ParseJWK восстанавливает открытый ключ из JWK стороннего издателя (RSA, EC P-256, Ed25519)
+func ParseJWK(jwk JWK) (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный модуль RSA ключа: %w", err)
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная экспонента RSA ключа: %w", err)
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if public.N.BitLen() < 2048 {
+			return nil, errors.New("длина RSA ключа должна быть не меньше 2048 бит")
+		}
+		return public, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", jwk.Curve)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная координата EC ключа: %w", err)
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная координата EC ключа: %w", err)
+		}
+		public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := public.ECDH(); err != nil {
+			return nil, errors.New("точка EC ключа не лежит на кривой")
+		}
+		return public, nil
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", jwk.Curve)
+		}
+		x, err := decode(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("некорректный Ed25519 ключ")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("неподдерживаемый тип ключа %q", jwk.KeyType)
+}
+
+func publicJWK(public crypto.PublicKey) JWK {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA",
+			N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: "OKP",
+			Curve:   "Ed25519",
+			X:       base64.RawURLEncoding.EncodeToString(key),
+		}
+	}
+	return JWK{}
+}
+
+// thumbprint отпечаток JWK по RFC 7638: SHA-256 от обязательных полей в лексикографическом порядке
+func thumbprint(jwk JWK) string {
+	var members interface{}
+	if jwk.KeyType == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaFile := writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	require.NoError(t, err)
+	edFile := writePEM(t, "ed25519.pem", "PRIVATE KEY", edDER)
+
+	edPublicDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+	edPublicFile := writePEM(t, "ed25519.pub", "PUBLIC KEY", edPublicDER)
+
+	set, err := Load(rsaFile, []string{edPublicFile})
+	require.NoError(t, err)
+
+	signing := set.SigningKey()
+	assert.Equal(t, "RS256", signing.Algorithm)
+	assert.NotNil(t, signing.Private())
+
+	jwks := set.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	for _, jwk := range jwks.Keys {
+		assert.Equal(t, "sig", jwk.Use)
+		key, ok := set.VerificationKey(jwk.KeyID)
+		require.True(t, ok)
+		assert.Equal(t, key.Algorithm, jwk.Algorithm)
+	}
+
+	// kid зависит только от открытого ключа: закрытый и открытый файлы дают один и тот же kid
+	edSet, err := Load(edFile, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", edSet.SigningKey().Algorithm)
+	_, ok := set.VerificationKey(edSet.SigningKey().ID)
+	assert.True(t, ok)
+
+	set.Replace(edSet)
+	assert.Equal(t, edSet.SigningKey().ID, set.SigningKey().ID)
+	_, ok = set.VerificationKey(signing.ID)
+	assert.False(t, ok)
+}
+
+func TestLoadErrors(t *testing.T) {
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edPublicDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	require.NoError(t, os.WriteFile(garbage, []byte("not a key"), 0o600))
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "missing_file", file: filepath.Join(t.TempDir(), "missing.pem")},
+		{name: "not_pem", file: garbage},
+		{name: "weak_rsa", file: writePEM(t, "small.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(smallKey))},
+		{name: "public_key_for_signing", file: writePEM(t, "ed25519.pub", "PUBLIC KEY", edPublicDER)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.file, nil)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// PoolCollector снимает pool.Stat() пула соединений при каждом опросе Prometheus
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns   *prometheus.Desc
+	idleConns       *prometheus.Desc
+	totalConns      *prometheus.Desc
+	maxConns        *prometheus.Desc
+	acquireCount    *prometheus.Desc
+	acquireWait     *prometheus.Desc
+	emptyAcquire    *prometheus.Desc
+	canceledAcquire *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	return &PoolCollector{
+		pool:            pool,
+		acquiredConns:   prometheus.NewDesc("db_pool_acquired_conns", "Number of connections currently in use", nil, nil),
+		idleConns:       prometheus.NewDesc("db_pool_idle_conns", "Number of idle connections in the pool", nil, nil),
+		totalConns:      prometheus.NewDesc("db_pool_total_conns", "Total number of connections in the pool", nil, nil),
+		maxConns:        prometheus.NewDesc("db_pool_max_conns", "Maximum size of the pool", nil, nil),
+		acquireCount:    prometheus.NewDesc("db_pool_acquire_total", "Total number of successful connection acquires", nil, nil),
+		acquireWait:     prometheus.NewDesc("db_pool_acquire_wait_seconds_total", "Total time spent waiting for a connection", nil, nil),
+		emptyAcquire:    prometheus.NewDesc("db_pool_empty_acquire_total", "Total number of acquires that had to wait for a connection", nil, nil),
+		canceledAcquire: prometheus.NewDesc("db_pool_canceled_acquire_total", "Total number of acquires canceled by context", nil, nil),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireWait
+	ch <- c.emptyAcquire
+	ch <- c.canceledAcquire
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
+
+// overdueCountTimeout меньше стандартного таймаута опроса Prometheus в 10 секунд
+const overdueCountTimeout = 5 * time.Second
+
+// OverdueCollector число просроченных незавершенных задач; считается запросом к базе
+// при каждом опросе, поэтому значение не расходится с данными
+type OverdueCollector struct {
+	count func(ctx context.Context) (int64, error)
+	log   *zerolog.Logger
+	desc  *prometheus.Desc
+}
+
+func NewOverdueCollector(count func(ctx context.Context) (int64, error), log *zerolog.Logger) *OverdueCollector {
+	return &OverdueCollector{
+		count: count,
+		log:   log,
+		desc:  prometheus.NewDesc("tasks_overdue", "Number of not completed tasks past their due date", nil, nil),
+	}
+}
+
+func (c *OverdueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *OverdueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), overdueCountTimeout)
+	defer cancel()
+
+	count, err := c.count(ctx)
+	if err != nil {
+		// без значения метрика пропадает из опроса, и это видно по алертам на absent()
+		c.log.Error().Err(err).Msg("ошибка подсчета просроченных задач")
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count))
+}
@@ -0,0 +1,188 @@
+// Package metrics метрики Prometheus сервиса на собственном реестре: тесты создают
+// отдельный экземпляр и не зависят от глобального состояния
+package metrics
+
+import (
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+// Metrics все метрики сервиса. Методы записи допускают nil-получатель, поэтому
+// компоненты без метрик (например, в тестах) передают nil
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestsTotal    *prometheus.CounterVec
+	httpRequestDuration  *prometheus.HistogramVec
+	httpRequestsInFlight prometheus.Gauge
+
+	rateLimitRejectedTotal *prometheus.CounterVec
+
+	tasksCreatedTotal    *prometheus.CounterVec
+	tasksCompletedTotal  prometheus.Counter
+	tasksDeletedTotal    *prometheus.CounterVec
+	taskTransitionsTotal *prometheus.CounterVec
+	dbQueryDuration      *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "Total number of HTTP requests",
+			},
+			[]string{"method", "path", "status"},
+		),
+		httpRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "HTTP request duration in seconds",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"method", "path", "status"},
+		),
+		httpRequestsInFlight: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "http_requests_in_flight",
+				Help: "Current number of HTTP requests being processed",
+			},
+		),
+
+		rateLimitRejectedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rate_limit_rejected_total",
+				Help: "Total number of requests rejected by rate limiter",
+			},
+			[]string{"scope"},
+		),
+
+		tasksCreatedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "tasks_created_total",
+				Help: "Total number of created tasks by initial status",
+			},
+			[]string{"status"},
+		),
+		tasksCompletedTotal: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "tasks_completed_total",
+				Help: "Total number of tasks moved to the completed status",
+			},
+		),
+		tasksDeletedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "tasks_deleted_total",
+				Help: "Total number of deleted tasks by status at deletion",
+			},
+			[]string{"status"},
+		),
+		taskTransitionsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "task_status_transitions_total",
+				Help: "Total number of task status changes",
+			},
+			[]string{"from", "to"},
+		),
+		dbQueryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "db_query_duration_seconds",
+				Help:    "Repository query duration in seconds",
+				Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+			},
+			[]string{"query"},
+		),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequestsTotal,
+		m.httpRequestDuration,
+		m.httpRequestsInFlight,
+		m.rateLimitRejectedTotal,
+		m.tasksCreatedTotal,
+		m.tasksCompletedTotal,
+		m.tasksDeletedTotal,
+		m.taskTransitionsTotal,
+		m.dbQueryDuration,
+	)
+
+	return m
+}
+
+// Registry реестр для подключения дополнительных коллекторов и проверок в тестах
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler отдает метрики реестра в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// HTTPRequestStarted учитывает запрос в обработке; возвращает функцию завершения
+func (m *Metrics) HTTPRequestStarted() func() {
+	if m == nil {
+		return func() {}
+	}
+	m.httpRequestsInFlight.Inc()
+	return m.httpRequestsInFlight.Dec
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, path, status string, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequestsTotal.WithLabelValues(method, path, status).Inc()
+	m.httpRequestDuration.WithLabelValues(method, path, status).Observe(duration.Seconds())
+}
+
+func (m *Metrics) RateLimitRejected(scope string) {
+	if m == nil {
+		return
+	}
+	m.rateLimitRejectedTotal.WithLabelValues(scope).Inc()
+}
+
+func (m *Metrics) TaskCreated(status model.TaskStatus) {
+	if m == nil {
+		return
+	}
+	m.tasksCreatedTotal.WithLabelValues(string(status)).Inc()
+	if status == model.TaskStatusCompleted {
+		m.tasksCompletedTotal.Inc()
+	}
+}
+
+func (m *Metrics) TaskDeleted(status model.TaskStatus) {
+	if m == nil {
+		return
+	}
+	m.tasksDeletedTotal.WithLabelValues(string(status)).Inc()
+}
+
+// TaskStatusChanged учитывает смену статуса; обновление без смены статуса не считается
+func (m *Metrics) TaskStatusChanged(from, to model.TaskStatus) {
+	if m == nil || from == to {
+		return
+	}
+	m.taskTransitionsTotal.WithLabelValues(string(from), string(to)).Inc()
+	if to == model.TaskStatusCompleted {
+		m.tasksCompletedTotal.Inc()
+	}
+}
+
+// ObserveQuery записывает длительность запроса с момента start; удобно вызывать через defer
+func (m *Metrics) ObserveQuery(query string, start time.Time) {
+	if m == nil {
+		return
+	}
+	m.dbQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+}
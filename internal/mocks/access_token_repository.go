@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockAccessTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccessTokenRepository) Create(ctx context.Context, token model.AccessToken, tokenHash string) (*model.AccessToken, error) {
+	args := m.Called(ctx, token, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AccessToken), args.Error(1)
+}
+
+func (m *MockAccessTokenRepository) ListByUser(ctx context.Context, userID int64) ([]model.AccessToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AccessToken), args.Error(1)
+}
+
+func (m *MockAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.AccessToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AccessToken), args.Error(1)
+}
+
+func (m *MockAccessTokenRepository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockAccessTokenRepository) Revoke(ctx context.Context, id, userID int64) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"context"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MockCalendarTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockCalendarTokenRepository) Upsert(ctx context.Context, userID int64, tokenHash string) (time.Time, error) {
+	args := m.Called(ctx, userID, tokenHash)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockCalendarTokenRepository) GetByUserID(ctx context.Context, userID int64) (*model.CalendarToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CalendarToken), args.Error(1)
+}
+
+func (m *MockCalendarTokenRepository) GetUserID(ctx context.Context, tokenHash string) (int64, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCalendarTokenRepository) Delete(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/kkboranbay/task-service/internal/model"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 type MockTaskRepository struct {
@@ -18,6 +19,11 @@ func (m *MockTaskRepository) Create(ctx context.Context, userID int64, task mode
 	return args.Get(0).(*model.Task), args.Error(1)
 }
 
+func (m *MockTaskRepository) BulkCreate(ctx context.Context, userID int64, tasks []model.CreateTaskRequest) (int64, error) {
+	args := m.Called(ctx, userID, tasks)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockTaskRepository) GetByID(ctx context.Context, id, userID int64) (*model.Task, error) {
 	args := m.Called(ctx, id, userID)
 	if args.Get(0) == nil {
@@ -26,14 +32,43 @@ func (m *MockTaskRepository) GetByID(ctx context.Context, id, userID int64) (*mo
 	return args.Get(0).(*model.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) List(ctx context.Context, userID int64, limit, offset int) (*model.TaskListResponse, error) {
-	args := m.Called(ctx, userID, limit, offset)
+func (m *MockTaskRepository) GetByIDs(ctx context.Context, ids []int64, userID int64) ([]model.Task, error) {
+	args := m.Called(ctx, ids, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) List(ctx context.Context, userID int64, filter model.TaskFilter, limit, offset int) (*model.TaskListResponse, error) {
+	args := m.Called(ctx, userID, filter, limit, offset)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.TaskListResponse), args.Error(1)
 }
 
+// Iterate вызывает fn для каждой задачи из первого аргумента Return, затем возвращает ошибку из второго
+func (m *MockTaskRepository) Iterate(ctx context.Context, userID int64, filter model.TaskFilter, fn func(task *model.Task) error) error {
+	args := m.Called(ctx, userID, filter, fn)
+	if tasks, ok := args.Get(0).([]model.Task); ok {
+		for i := range tasks {
+			if err := fn(&tasks[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockTaskRepository) Stats(ctx context.Context, userID int64) (*model.TaskStats, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TaskStats), args.Error(1)
+}
+
 func (m *MockTaskRepository) Update(ctx context.Context, id, userID int64, req model.UpdateTaskRequest) (*model.Task, error) {
 	args := m.Called(ctx, id, userID, req)
 	if args.Get(0) == nil {
@@ -46,3 +81,21 @@ func (m *MockTaskRepository) Delete(ctx context.Context, id, userID int64) error
 	args := m.Called(ctx, id, userID)
 	return args.Error(0)
 }
+
+func (m *MockTaskRepository) CountUpdatedBefore(ctx context.Context, userID int64, filter model.TaskFilter, before time.Time) (int64, error) {
+	args := m.Called(ctx, userID, filter, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTaskRepository) DeleteUpdatedBefore(ctx context.Context, userID int64, filter model.TaskFilter, before time.Time) ([]int64, error) {
+	args := m.Called(ctx, userID, filter, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountOverdue(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
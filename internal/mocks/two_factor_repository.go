@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTwoFactorRepository struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepository) Get(ctx context.Context, userID int64) (*model.TwoFactor, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TwoFactor), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) SavePending(ctx context.Context, userID int64, secret string) error {
+	args := m.Called(ctx, userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, step, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepository) ConsumeStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTwoFactorRepository) Delete(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
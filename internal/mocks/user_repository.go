@@ -0,0 +1,64 @@
+package mocks
+
+import (
+	"context"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, limit, offset int) (*model.UserListResponse, error) {
+	args := m.Called(ctx, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserListResponse), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByExternalID(ctx context.Context, issuer, subject string) (*model.User, error) {
+	args := m.Called(ctx, issuer, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user model.User) (*model.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateExternal(ctx context.Context, user model.User, issuer, subject string) (*model.User, error) {
+	args := m.Called(ctx, user, issuer, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetPasswordIfEmpty(ctx context.Context, id int64, passwordHash string) (bool, error) {
+	args := m.Called(ctx, id, passwordHash)
+	return args.Bool(0), args.Error(1)
+}
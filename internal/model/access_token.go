@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// AccessTokenPrefix отличает персональные токены от JWT в заголовке Authorization
+const AccessTokenPrefix = "tsk_"
+
+type AccessToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Role       Role       `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type CreateAccessTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateAccessTokenResponse содержит сам токен; он показывается только в ответе на создание
+type CreateAccessTokenResponse struct {
+	AccessToken
+	Token string `json:"token"`
+}
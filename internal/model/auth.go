@@ -8,4 +8,6 @@ type LoginRequest struct {
 type LoginResponse struct {
 	Token  string `json:"token"`
 	UserID int64  `json:"user_id"`
+	// RecoveryCodes выдаются, если вход завершил обязательную регистрацию 2FA
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
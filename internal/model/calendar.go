@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type CalendarToken struct {
+	UserID    int64     `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CalendarTokenResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -123,20 +123,75 @@ type ErrorResponseSwagger struct {
 	// Подробности ошибки (необязательное поле)
 	// @example "Поле 'title' обязательно для заполнения"
 	Details string `json:"details,omitempty" example:"Поле 'title' обязательно для заполнения"`
+
+	// ID запроса из заголовка X-Request-ID для поиска в журнале сервера
+	// @example "5f1c2a9e8b7d4c3a9e0f1b2c3d4e5f60"
+	RequestID string `json:"request_id,omitempty" example:"5f1c2a9e8b7d4c3a9e0f1b2c3d4e5f60"`
+
+	// Машиночитаемый код ошибки: db_timeout или db_unavailable
+	// @example "db_timeout"
+	ErrorCode string `json:"error_code,omitempty" example:"db_timeout"`
+}
+
+// HealthReport отчет проверки состояния
+// @Description Итоговый статус сервиса и состояние каждой зависимости
+type HealthReportSwagger struct {
+	// Статус сервиса: ok, degraded или unavailable
+	// @example "ok"
+	Status string `json:"status" example:"ok"`
+
+	// Причина недоступности, например остановка сервиса
+	// @example "сервис останавливается"
+	Reason string `json:"reason,omitempty" example:"сервис останавливается"`
+
+	// Результаты проверок зависимостей
+	Components []HealthComponentSwagger `json:"components,omitempty"`
 }
 
-// HealthResponse ответ health check
-// @Description Состояние сервиса
-type HealthResponseSwagger struct {
-	// Статус сервиса
+// HealthComponent состояние зависимости
+// @Description Результат проверки одного компонента
+type HealthComponentSwagger struct {
+	// Имя компонента
+	// @example "database"
+	Name string `json:"name" example:"database"`
+
+	// Статус компонента: ok или unavailable
 	// @example "ok"
 	Status string `json:"status" example:"ok"`
 
-	// Временная метка
+	// Отказ критичного компонента делает сервис неготовым
+	// @example true
+	Critical bool `json:"critical" example:"true"`
+
+	// Текст ошибки проверки
+	// @example "таймаут проверки: context deadline exceeded"
+	Error string `json:"error,omitempty" example:"таймаут проверки: context deadline exceeded"`
+
+	// Длительность проверки в миллисекундах
+	// @example 3
+	DurationMs int64 `json:"duration_ms" example:"3"`
+}
+
+// CalendarTokenResponse ссылка на подписку
+// @Description Секретная ссылка на iCalendar-ленту задач
+type CalendarTokenResponseSwagger struct {
+	// Секретный токен; показывается только при выпуске
+	// @example "q3JvZ1c0dF9zZWNyZXRfdG9rZW5fZXhhbXBsZQ"
+	Token string `json:"token" example:"q3JvZ1c0dF9zZWNyZXRfdG9rZW5fZXhhbXBsZQ"`
+
+	// Адрес ленты для подписки в календаре
+	// @example "https://taskservice.com/calendar/q3JvZ1c0dF9zZWNyZXRfdG9rZW5fZXhhbXBsZQ.ics"
+	URL string `json:"url" example:"https://taskservice.com/calendar/q3JvZ1c0dF9zZWNyZXRfdG9rZW5fZXhhbXBsZQ.ics"`
+
+	// Время выпуска токена
 	// @example "2024-01-15T10:30:00Z"
-	Timestamp time.Time `json:"timestamp" example:"2024-01-15T10:30:00Z"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+}
 
-	// Версия API
-	// @example "1.0.0"
-	Version string `json:"version" example:"1.0.0"`
+// CalendarToken сведения о текущей подписке
+// @Description Время выпуска действующего токена календаря
+type CalendarTokenSwagger struct {
+	// Время выпуска токена
+	// @example "2024-01-15T10:30:00Z"
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
 }
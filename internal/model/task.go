@@ -10,6 +10,10 @@ const (
 	TaskStatusCompleted  TaskStatus = "completed"
 )
 
+func (s TaskStatus) IsValid() bool {
+	return s == TaskStatusPending || s == TaskStatusInProgress || s == TaskStatusCompleted
+}
+
 type Task struct {
 	ID          int64      `json:"id"`
 	Title       string     `json:"title"`
@@ -22,7 +26,7 @@ type Task struct {
 }
 
 type CreateTaskRequest struct {
-	Title       string     `json:"title" binding:"required"`
+	Title       string     `json:"title" binding:"required,max=255"`
 	Description string     `json:"description"`
 	Status      TaskStatus `json:"status" binding:"required"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
@@ -35,12 +39,55 @@ type UpdateTaskRequest struct {
 	DueDate     *time.Time  `json:"due_date,omitempty"`
 }
 
+// TaskFilter условия отбора задач в списке; пустые поля не ограничивают выборку
+type TaskFilter struct {
+	Status    *TaskStatus `json:"status,omitempty"`
+	Search    string      `json:"search,omitempty"`
+	DueBefore *time.Time  `json:"due_before,omitempty"`
+	DueAfter  *time.Time  `json:"due_after,omitempty"`
+}
+
 type TaskListResponse struct {
 	Total int64  `json:"total"`
 	Tasks []Task `json:"tasks"`
 }
 
+// ImportRow задача из импортируемого файла с номером строки для отчета об ошибках
+type ImportRow struct {
+	Row  int
+	Task CreateTaskRequest
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// TaskStats сводка по задачам пользователя для построения ETag
+type TaskStats struct {
+	Count       int64
+	LastUpdated *time.Time
+}
+
 type ErrorResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// RequestID совпадает с заголовком X-Request-ID и полем request_id в журнале сервера
+	RequestID string `json:"request_id,omitempty"`
+	// ErrorCode машиночитаемый код ошибки, по которому клиент решает, повторять ли запрос
+	ErrorCode string `json:"error_code,omitempty"`
 }
+
+// Коды ошибок базы данных в ErrorResponse.ErrorCode
+const (
+	ErrorCodeDBTimeout     = "db_timeout"
+	ErrorCodeDBUnavailable = "db_unavailable"
+)
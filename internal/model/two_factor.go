@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// TwoFactor TOTP регистрация пользователя; EnabledAt пуст, пока регистрация не подтверждена
+type TwoFactor struct {
+	UserID       int64
+	Secret       string
+	LastUsedStep int64
+	CreatedAt    time.Time
+	EnabledAt    *time.Time
+}
+
+func (t *TwoFactor) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// TwoFactorEnrollment секрет и otpauth:// адрес для QR-кода; показываются один раз при регистрации
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallengeResponse ответ на вход по паролю, если нужен второй фактор.
+// EnrollmentRequired означает, что политика требует 2FA, а пользователь ее еще не настроил
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired  bool      `json:"two_factor_required"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+	ChallengeToken     string    `json:"challenge_token"`
+	ExpiresAt          time.Time `json:"expires_at"`
+}
+
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code код из приложения или один из кодов восстановления
+	Code string `json:"code" binding:"required"`
+}
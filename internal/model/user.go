@@ -0,0 +1,93 @@
+package model
+
+import "time"
+
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	RoleUser  Role = "user"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleUser:
+		return true
+	}
+	return false
+}
+
+// Scopes права токена. Чтение и изменение задач разделены, чтобы токены автоматизации
+// можно было выдавать только на чтение
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+	ScopeAdmin      = "admin"
+)
+
+// DefaultScopes права, которые получает токен пользователя с ролью role при входе
+func DefaultScopes(role Role) []string {
+	if role == RoleAdmin {
+		return []string{ScopeTasksRead, ScopeTasksWrite, ScopeAdmin}
+	}
+	return []string{ScopeTasksRead, ScopeTasksWrite}
+}
+
+type User struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Role         Role      `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ExternalIdentity учетная запись пользователя у внешнего OIDC издателя
+type ExternalIdentity struct {
+	Issuer            string
+	Subject           string
+	PreferredUsername string
+	Email             string
+}
+
+type UserListResponse struct {
+	Total int64  `json:"total"`
+	Users []User `json:"users"`
+}
+
+// AuditAction действие администратора или событие безопасности, которое записывается в журнал аудита
+type AuditAction string
+
+const (
+	AuditActionImpersonate  AuditAction = "impersonate"
+	AuditActionViewTasks    AuditAction = "view_tasks"
+	AuditActionUnlockLogin  AuditAction = "unlock_login"
+	AuditActionLoginLockout AuditAction = "login_lockout"
+	// действия оператора через taskctl
+	AuditActionCreateUser  AuditAction = "create_user"
+	AuditActionCreateToken AuditAction = "create_access_token"
+	AuditActionIssueJWT    AuditAction = "issue_jwt"
+	AuditActionPurgeTasks  AuditAction = "purge_tasks"
+)
+
+// SystemActorID ActorID записей аудита, сделанных сервисом, а не пользователем API
+const SystemActorID int64 = 0
+
+type AuditEntry struct {
+	ID           int64       `json:"id"`
+	ActorID      int64       `json:"actor_id"`
+	Action       AuditAction `json:"action"`
+	TargetUserID *int64      `json:"target_user_id,omitempty"`
+	Details      string      `json:"details,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+type AuditListResponse struct {
+	Total   int64        `json:"total"`
+	Entries []AuditEntry `json:"entries"`
+}
+
+type ImpersonateResponse struct {
+	Token     string    `json:"token"`
+	UserID    int64     `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const redirectURL = "http://localhost:8080/auth/oidc/callback"
+
+func newProvider(t *testing.T, idp *oidctest.Server) *Provider {
+	provider, err := NewProvider(context.Background(), config.OIDCConfig{
+		IssuerURL:    idp.URL,
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email"},
+	}, idp.Client())
+	require.NoError(t, err)
+	return provider
+}
+
+// authorize проходит /authorize провайдера и возвращает выданный код
+func authorize(t *testing.T, provider *Provider, state State) string {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(provider.AuthCodeURL(state.State, state.Nonce, state.Verifier))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, state.State, location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	idp := oidctest.NewServer("task-service", "secret")
+	defer idp.Close()
+	idp.SetUser(oidctest.User{Subject: "42", Email: "jane@example.com", PreferredUsername: "jane"})
+
+	provider := newProvider(t, idp)
+	state, err := NewState(time.Minute)
+	require.NoError(t, err)
+
+	code := authorize(t, provider, state)
+
+	rawIDToken, err := provider.Exchange(context.Background(), code, state.Verifier)
+	require.NoError(t, err)
+
+	claims, err := provider.Verify(context.Background(), rawIDToken, state.Nonce)
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.Subject)
+	assert.Equal(t, "jane", claims.PreferredUsername)
+	assert.Equal(t, "jane@example.com", claims.Email)
+
+	// код одноразовый
+	_, err = provider.Exchange(context.Background(), code, state.Verifier)
+	assert.Error(t, err)
+}
+
+func TestExchangeRequiresVerifier(t *testing.T) {
+	idp := oidctest.NewServer("task-service", "secret")
+	defer idp.Close()
+
+	provider := newProvider(t, idp)
+	state, err := NewState(time.Minute)
+	require.NoError(t, err)
+
+	code := authorize(t, provider, state)
+
+	_, err = provider.Exchange(context.Background(), code, "wrong-verifier")
+	assert.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	idp := oidctest.NewServer("task-service", "secret")
+	defer idp.Close()
+	provider := newProvider(t, idp)
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   idp.URL,
+			"sub":   "42",
+			"aud":   "task-service",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": "nonce",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(claims jwt.MapClaims)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(jwt.MapClaims) {}},
+		{name: "other_issuer", modify: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, wantErr: true},
+		{name: "other_audience", modify: func(c jwt.MapClaims) { c["aud"] = "other-client" }, wantErr: true},
+		{name: "expired", modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, wantErr: true},
+		{name: "no_expiration", modify: func(c jwt.MapClaims) { delete(c, "exp") }, wantErr: true},
+		{name: "wrong_nonce", modify: func(c jwt.MapClaims) { c["nonce"] = "replayed" }, wantErr: true},
+		{name: "no_subject", modify: func(c jwt.MapClaims) { delete(c, "sub") }, wantErr: true},
+		{
+			name:    "multiple_audiences_without_azp",
+			modify:  func(c jwt.MapClaims) { c["aud"] = []string{"task-service", "other-client"} },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.modify(claims)
+
+			_, err := provider.Verify(context.Background(), idp.IDToken(claims), "nonce")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("foreign_signature", func(t *testing.T) {
+		other := oidctest.NewServer("task-service", "secret")
+		defer other.Close()
+
+		claims := valid()
+		_, err := provider.Verify(context.Background(), other.IDToken(claims), "nonce")
+		assert.Error(t, err)
+	})
+}
+
+func TestState(t *testing.T) {
+	secret := []byte("secret")
+
+	state, err := NewState(time.Minute)
+	require.NoError(t, err)
+
+	opened, err := OpenState(secret, state.Seal(secret))
+	require.NoError(t, err)
+	assert.Equal(t, state.State, opened.State)
+	assert.Equal(t, state.Verifier, opened.Verifier)
+
+	_, err = OpenState([]byte("other"), state.Seal(secret))
+	assert.ErrorIs(t, err, ErrInvalidState)
+
+	_, err = OpenState(secret, "")
+	assert.ErrorIs(t, err, ErrInvalidState)
+
+	expired, err := NewState(-time.Second)
+	require.NoError(t, err)
+	_, err = OpenState(secret, expired.Seal(secret))
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
@@ -0,0 +1,84 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidState = errors.New("недействительное состояние входа")
+
+// State параметры незавершенного входа. Хранятся в подписанной cookie браузера, поэтому
+// callback может обработать любой экземпляр сервиса
+type State struct {
+	State     string    `json:"s"`
+	Nonce     string    `json:"n"`
+	Verifier  string    `json:"v"`
+	ExpiresAt time.Time `json:"e"`
+}
+
+// NewState создает случайные state, nonce и PKCE verifier
+func NewState(ttl time.Duration) (State, error) {
+	values := make([]string, 3)
+	for i := range values {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return State{}, err
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(buf)
+	}
+
+	return State{
+		State:     values[0],
+		Nonce:     values[1],
+		Verifier:  values[2],
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// Seal сериализует состояние и подписывает его HMAC-SHA256
+func (s State) Seal(secret []byte) string {
+	data, _ := json.Marshal(s)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + sign(secret, payload)
+}
+
+// OpenState проверяет подпись и срок действия состояния из cookie
+func OpenState(secret []byte, sealed string) (State, error) {
+	payload, signature, ok := strings.Cut(sealed, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(sign(secret, payload))) {
+		return State{}, ErrInvalidState
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return State{}, ErrInvalidState
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, ErrInvalidState
+	}
+	if time.Now().After(state.ExpiresAt) {
+		return State{}, ErrInvalidState
+	}
+
+	return state, nil
+}
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// codeChallenge PKCE challenge метода S256 (RFC 7636)
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
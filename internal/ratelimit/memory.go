@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval как часто из памяти удаляются корзины, которые успели полностью заполниться
+const sweepInterval = time.Minute
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: bucket{tokens: float64(limit.Burst), last: now}}
+		s.buckets[key] = b
+	}
+
+	result := b.take(now, limit)
+	b.full = now.Add(result.ResetAfter)
+	return result, nil
+}
+
+// memoryBucket корзина с моментом, когда она заполнится по своему лимиту
+type memoryBucket struct {
+	bucket
+	full time.Time
+}
+
+// sweep удаляет корзины, которые уже успели полностью заполниться: новая корзина для того же
+// ключа будет в том же состоянии. У каждой корзины свой лимит, поэтому срок считается при списании
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.After(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit описывает token bucket: корзина вмещает Burst запросов и пополняется со скоростью Rate в секунду
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Result итог попытки взять токен из корзины
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter через сколько появится следующий токен, если запрос отклонен
+	RetryAfter time.Duration
+	// ResetAfter через сколько корзина заполнится полностью
+	ResetAfter time.Duration
+}
+
+// Store хранит состояние корзин. Реализация в памяти подходит для одного экземпляра сервиса,
+// Redis-реализация разделяет лимиты между несколькими экземплярами
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucket состояние корзины на момент last
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take пополняет корзину на момент now и пытается списать один токен
+func (b *bucket) take(now time.Time, limit Limit) Result {
+	burst := float64(limit.Burst)
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*limit.Rate)
+	}
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(allowed, b.tokens, limit)
+}
+
+// newResult рассчитывает сведения для заголовков по остатку токенов после попытки
+func newResult(allowed bool, tokens float64, limit Limit) Result {
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(tokens),
+		ResetAfter: secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "user:1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.ResetAfter)
+
+	// другие ключи не зависят от исчерпанной корзины
+	result, err = store.Take(ctx, "user:2", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(1500 * time.Millisecond)
+	result, err = store.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// простаивающие корзины удаляются
+	now = now.Add(2 * sweepInterval)
+	_, err = store.Take(ctx, "user:3", limit)
+	require.NoError(t, err)
+	assert.Len(t, store.buckets, 1)
+}
+
+func TestMemoryStoreSweepKeepsSlowBuckets(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	slow := Limit{Rate: 1.0 / 600, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		_, err := store.Take(ctx, "login:10.0.0.1", slow)
+		require.NoError(t, err)
+	}
+
+	// очистку запускает ключ с быстрым лимитом, но медленная корзина еще не заполнилась
+	now = now.Add(2 * sweepInterval)
+	_, err := store.Take(ctx, "user:1", Limit{Rate: 10, Burst: 5})
+	require.NoError(t, err)
+
+	result, err := store.Take(ctx, "login:10.0.0.1", slow)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed, "исчерпанная корзина не должна возвращаться с полным запасом")
+}
+
+func TestRedisStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer client.Close()
+
+	store := NewRedisStore(client, "ratelimit:")
+	limit := Limit{Rate: 0.5, Burst: 2}
+	ctx := context.Background()
+
+	for i := 1; i >= 0; i-- {
+		result, err := store.Take(ctx, "login:10.0.0.1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(ctx, "login:10.0.0.1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Greater(t, result.RetryAfter, time.Duration(0))
+	assert.LessOrEqual(t, result.RetryAfter, 2*time.Second)
+
+	assert.True(t, server.Exists("ratelimit:login:10.0.0.1"))
+	assert.Greater(t, server.TTL("ratelimit:login:10.0.0.1"), time.Duration(0))
+
+	server.Close()
+	_, err = store.Take(ctx, "login:10.0.0.1", limit)
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"time"
+)
+
+type AccessTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAccessTokenRepository(pool *pgxpool.Pool) repository.AccessTokenRepository {
+	return &AccessTokenRepository{pool: pool}
+}
+
+func (r *AccessTokenRepository) Create(ctx context.Context, token model.AccessToken, tokenHash string) (*model.AccessToken, error) {
+	token.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO access_tokens (user_id, name, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	err := r.pool.QueryRow(
+		ctx,
+		query,
+		token.UserID,
+		token.Name,
+		tokenHash,
+		token.Scopes,
+		token.CreatedAt,
+		token.ExpiresAt,
+	).Scan(&token.ID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания токена доступа: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *AccessTokenRepository) ListByUser(ctx context.Context, userID int64) ([]model.AccessToken, error) {
+	query := `
+		SELECT id, user_id, name, scopes, created_at, expires_at, last_used_at
+		FROM access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY id
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения токенов доступа: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]model.AccessToken, 0)
+	for rows.Next() {
+		var token model.AccessToken
+		err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.Scopes,
+			&token.CreatedAt,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка обработки строк: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *AccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.AccessToken, error) {
+	query := `
+		SELECT t.id, t.user_id, u.role, t.name, t.scopes, t.created_at, t.expires_at, t.last_used_at
+		FROM access_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1 AND t.revoked_at IS NULL
+	`
+
+	var token model.AccessToken
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Role,
+		&token.Name,
+		&token.Scopes,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("ошибка поиска токена доступа: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *AccessTokenRepository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error {
+	query := `UPDATE access_tokens SET last_used_at = $1 WHERE id = $2`
+
+	if _, err := r.pool.Exec(ctx, query, usedAt, id); err != nil {
+		return fmt.Errorf("ошибка обновления времени использования токена: %w", err)
+	}
+	return nil
+}
+
+func (r *AccessTokenRepository) Revoke(ctx context.Context, id, userID int64) error {
+	query := `UPDATE access_tokens SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`
+
+	result, err := r.pool.Exec(ctx, query, time.Now(), id, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва токена доступа: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"time"
+)
+
+type AuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) repository.AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry model.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, target_user_id, details, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := r.pool.Exec(ctx, query, entry.ActorID, entry.Action, entry.TargetUserID, entry.Details, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка записи в журнал аудита: %w", err)
+	}
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, limit, offset int) (*model.AuditListResponse, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM audit_log`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("ошибка подсчета записей аудита: %w", err)
+	}
+
+	query := `
+		SELECT id, actor_id, action, target_user_id, details, created_at
+		FROM audit_log
+		ORDER BY id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения журнала аудита: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]model.AuditEntry, 0)
+	for rows.Next() {
+		var entry model.AuditEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.TargetUserID,
+			&entry.Details,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка обработки строк: %w", err)
+	}
+
+	return &model.AuditListResponse{
+		Total:   total,
+		Entries: entries,
+	}, nil
+}
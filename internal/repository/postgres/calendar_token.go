@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"time"
+)
+
+type CalendarTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCalendarTokenRepository(pool *pgxpool.Pool) repository.CalendarTokenRepository {
+	return &CalendarTokenRepository{pool: pool}
+}
+
+// Upsert сохраняет новый хэш токена; предыдущий токен пользователя перестает действовать
+func (r *CalendarTokenRepository) Upsert(ctx context.Context, userID int64, tokenHash string) (time.Time, error) {
+	query := `
+		INSERT INTO calendar_tokens (user_id, token_hash, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = EXCLUDED.created_at
+	`
+
+	now := time.Now()
+	if _, err := r.pool.Exec(ctx, query, userID, tokenHash, now); err != nil {
+		return time.Time{}, fmt.Errorf("ошибка сохранения токена календаря: %w", err)
+	}
+
+	return now, nil
+}
+
+func (r *CalendarTokenRepository) GetByUserID(ctx context.Context, userID int64) (*model.CalendarToken, error) {
+	query := `SELECT user_id, created_at FROM calendar_tokens WHERE user_id = $1`
+
+	var token model.CalendarToken
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&token.UserID, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения токена календаря: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *CalendarTokenRepository) GetUserID(ctx context.Context, tokenHash string) (int64, error) {
+	query := `SELECT user_id FROM calendar_tokens WHERE token_hash = $1`
+
+	var userID int64
+	err := r.pool.QueryRow(ctx, query, tokenHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, repository.ErrNotFound
+		}
+		return 0, fmt.Errorf("ошибка поиска токена календаря: %w", err)
+	}
+
+	return userID, nil
+}
+
+func (r *CalendarTokenRepository) Delete(ctx context.Context, userID int64) error {
+	query := `DELETE FROM calendar_tokens WHERE user_id = $1`
+
+	result, err := r.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления токена календаря: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kkboranbay/task-service/internal/repository"
+	pg "github.com/kkboranbay/task-service/pkg/postgres"
+)
+
+// queryCanceled код ошибки Postgres, когда запрос прерван по statement_timeout или отменен
+const queryCanceled = "57014"
+
+// dbError оборачивает ошибку запроса сообщением msg. Превышение времени помечается
+// repository.ErrQueryTimeout, остановка базы и обрыв соединения — repository.ErrDatabaseUnavailable,
+// чтобы обработчики отвечали 504 и 503. Отмена контекста клиентом к недоступности базы
+// не относится и остается context.Canceled
+func dbError(msg string, err error) error {
+	var pgErr *pgconn.PgError
+	isPgErr := errors.As(err, &pgErr)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+	case errors.Is(err, context.DeadlineExceeded), pgconn.Timeout(err), isPgErr && pgErr.Code == queryCanceled:
+		return fmt.Errorf("%s: %w: %w", msg, repository.ErrQueryTimeout, err)
+	// 57P*, ошибка подключения или соединение, оборвавшееся посреди запроса
+	case pg.IsConnectionFailure(err):
+		return fmt.Errorf("%s: %w: %w", msg, repository.ErrDatabaseUnavailable, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
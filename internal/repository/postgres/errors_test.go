@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestDBError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded), wantErr: repository.ErrQueryTimeout},
+		{name: "statement_timeout", err: &pgconn.PgError{Code: queryCanceled}, wantErr: repository.ErrQueryTimeout},
+		// клиент сам отменил запрос: база доступна
+		{name: "client_canceled", err: fmt.Errorf("query: %w", context.Canceled)},
+		{name: "admin_shutdown", err: &pgconn.PgError{Code: "57P01"}, wantErr: repository.ErrDatabaseUnavailable},
+		{name: "connect_error", err: &pgconn.ConnectError{Config: &pgconn.Config{}}, wantErr: repository.ErrDatabaseUnavailable},
+		{name: "connection_reset", err: fmt.Errorf("read: %w", &net.OpError{Op: "read", Err: syscall.ECONNRESET}), wantErr: repository.ErrDatabaseUnavailable},
+		{name: "unexpected_eof", err: fmt.Errorf("receive message: %w", io.ErrUnexpectedEOF), wantErr: repository.ErrDatabaseUnavailable},
+		{name: "syntax_error", err: &pgconn.PgError{Code: "42601"}},
+		{name: "no_rows", err: pgx.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dbError("ошибка получения задачи", tt.err)
+
+			assert.ErrorIs(t, err, tt.err, "исходная ошибка сохраняется")
+			assert.Contains(t, err.Error(), "ошибка получения задачи")
+			for _, sentinel := range []error{repository.ErrQueryTimeout, repository.ErrDatabaseUnavailable} {
+				assert.Equal(t, sentinel == tt.wantErr, errors.Is(err, sentinel), sentinel.Error())
+			}
+		})
+	}
+}
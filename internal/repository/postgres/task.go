@@ -6,24 +6,50 @@ import (
 	"fmt"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/metrics"
 	"github.com/kkboranbay/task-service/internal/model"
 	"github.com/kkboranbay/task-service/internal/repository"
+	pg "github.com/kkboranbay/task-service/pkg/postgres"
+	"github.com/rs/zerolog"
 	"strings"
 	"time"
 )
 
 type TaskRepository struct {
-	pool *pgxpool.Pool
+	pool         *pgxpool.Pool
+	replicas     *pg.Router
+	metrics      *metrics.Metrics
+	queryTimeout time.Duration
 }
 
-func NewTaskRepository(pool *pgxpool.Pool) repository.TaskRepository {
-	return &TaskRepository{pool: pool}
+var _ repository.TaskRepository = (*TaskRepository)(nil)
+
+// NewTaskRepository m может быть nil, тогда метрики не пишутся
+func NewTaskRepository(pool *pgxpool.Pool, m *metrics.Metrics) *TaskRepository {
+	return &TaskRepository{pool: pool, metrics: m}
+}
+
+// WithQueryTimeout ограничивает каждую операцию временем timeout, даже если контекст
+// запроса живет дольше; 0 снимает ограничение
+func (r *TaskRepository) WithQueryTimeout(timeout time.Duration) *TaskRepository {
+	r.queryTimeout = timeout
+	return r
+}
+
+// WithReplicas направляет GetByID и List на реплики; после изменений задач пользователя
+// его чтения на время ReplicaStickiness идут в основную базу
+func (r *TaskRepository) WithReplicas(router *pg.Router) *TaskRepository {
+	r.replicas = router
+	return r
 }
 
 func (r *TaskRepository) Create(ctx context.Context, userID int64, req model.CreateTaskRequest) (*model.Task, error) {
 	if strings.TrimSpace(req.Title) == "" {
 		return nil, fmt.Errorf("title cannot be empty")
 	}
+	defer r.metrics.ObserveQuery("create", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
 	status := req.Status
 	if status == "" {
@@ -60,13 +86,74 @@ func (r *TaskRepository) Create(ctx context.Context, userID int64, req model.Cre
 	).Scan(&task.ID)
 
 	if err != nil {
-		return nil, fmt.Errorf("ошибка создания задачи: %w", err)
+		return nil, dbError("ошибка создания задачи", err)
 	}
 
+	r.metrics.TaskCreated(task.Status)
+	r.written(userID)
+	debugLog(ctx).Int64("task_id", task.ID).Msg("задача записана в базу")
 	return &task, nil
 }
 
+// BulkCreate вставляет задачи одним COPY; вызывающий код должен заранее проверить их корректность
+func (r *TaskRepository) BulkCreate(ctx context.Context, userID int64, tasks []model.CreateTaskRequest) (int64, error) {
+	now := time.Now()
+	defer r.metrics.ObserveQuery("bulk_create", now)
+	// таймаут операции не применяется: COPY большого файла импорта законно дольше обычного
+	// запроса, а зависший COPY прервет statement_timeout базы
+
+	rows := make([][]interface{}, 0, len(tasks))
+	for _, task := range tasks {
+		status := task.Status
+		if status == "" {
+			status = model.TaskStatusPending
+		}
+		rows = append(rows, []interface{}{
+			task.Title,
+			task.Description,
+			string(status),
+			userID,
+			task.DueDate,
+			now,
+			now,
+		})
+	}
+
+	count, err := r.pool.CopyFrom(
+		ctx,
+		pgx.Identifier{"tasks"},
+		[]string{"title", "description", "status", "user_id", "due_date", "created_at", "updated_at"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return 0, dbError("ошибка массовой вставки задач", err)
+	}
+
+	// COPY атомарен: при успехе записаны все строки
+	for _, row := range rows {
+		r.metrics.TaskCreated(model.TaskStatus(row[2].(string)))
+	}
+
+	r.written(userID)
+	debugLog(ctx).Int64("count", count).Msg("задачи записаны в базу через COPY")
+	return count, nil
+}
+
 func (r *TaskRepository) GetByID(ctx context.Context, id, userID int64) (*model.Task, error) {
+	defer r.metrics.ObserveQuery("get_by_id", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var task *model.Task
+	err := r.read(ctx, userID, func(db *pgxpool.Pool) error {
+		var err error
+		task, err = getTask(ctx, db, id, userID)
+		return err
+	})
+	return task, err
+}
+
+func getTask(ctx context.Context, db *pgxpool.Pool, id, userID int64) (*model.Task, error) {
 	query := `
 		SELECT id, title, description, status, user_id, due_date, created_at, updated_at
 		FROM tasks
@@ -75,7 +162,7 @@ func (r *TaskRepository) GetByID(ctx context.Context, id, userID int64) (*model.
 
 	var task model.Task
 
-	err := r.pool.QueryRow(ctx, query, id, userID).Scan(
+	err := db.QueryRow(ctx, query, id, userID).Scan(
 		&task.ID,
 		&task.Title,
 		&task.Description,
@@ -90,31 +177,92 @@ func (r *TaskRepository) GetByID(ctx context.Context, id, userID int64) (*model.
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("задача не найдена")
 		}
-		return nil, fmt.Errorf("ошибка получения задачи: %w", err)
+		return nil, dbError("ошибка получения задачи", err)
 	}
 
 	return &task, nil
 }
 
-func (r *TaskRepository) List(ctx context.Context, userID int64, limit, offset int) (*model.TaskListResponse, error) {
-	countQuery := `SELECT count(*) FROM tasks WHERE user_id = $1`
+// GetByIDs возвращает задачи пользователя из списка ID одним запросом; отсутствующие ID пропускаются
+func (r *TaskRepository) GetByIDs(ctx context.Context, ids []int64, userID int64) ([]model.Task, error) {
+	defer r.metrics.ObserveQuery("get_by_ids", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, title, description, status, user_id, due_date, created_at, updated_at
+		FROM tasks
+		WHERE id = ANY($1) AND user_id = $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, ids, userID)
+	if err != nil {
+		return nil, dbError("ошибка получения задач", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]model.Task, 0, len(ids))
+	for rows.Next() {
+		var task model.Task
+		err := rows.Scan(
+			&task.ID,
+			&task.Title,
+			&task.Description,
+			&task.Status,
+			&task.UserID,
+			&task.DueDate,
+			&task.CreatedAt,
+			&task.UpdatedAt,
+		)
+
+		if err != nil {
+			return nil, dbError("ошибка сканирования строки", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, dbError("ошибка обработки строк", err)
+	}
+
+	return tasks, nil
+}
+
+func (r *TaskRepository) List(ctx context.Context, userID int64, filter model.TaskFilter, limit, offset int) (*model.TaskListResponse, error) {
+	defer r.metrics.ObserveQuery("list", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	var list *model.TaskListResponse
+	err := r.read(ctx, userID, func(db *pgxpool.Pool) error {
+		var err error
+		list, err = listTasks(ctx, db, userID, filter, limit, offset)
+		return err
+	})
+	return list, err
+}
+
+func listTasks(ctx context.Context, db *pgxpool.Pool, userID int64, filter model.TaskFilter, limit, offset int) (*model.TaskListResponse, error) {
+	where, args := buildFilter(userID, filter)
+
+	countQuery := `SELECT count(*) FROM tasks WHERE ` + where
 	var total int64
-	err := r.pool.QueryRow(ctx, countQuery, userID).Scan(&total)
+	err := db.QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка подсчета задач: %w", err)
+		return nil, dbError("ошибка подсчета задач", err)
 	}
 
-	query := `
+	query := fmt.Sprintf(`
 		SELECT id, title, description, status, user_id, due_date, created_at, updated_at
 		FROM tasks
-		WHERE user_id = $1
+		WHERE %s
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
 
-	rows, err := r.pool.Query(ctx, query, userID, limit, offset)
+	rows, err := db.Query(ctx, query, append(args, limit, offset)...)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка получения списка задач: %w", err)
+		return nil, dbError("ошибка получения списка задач", err)
 	}
 	defer rows.Close()
 
@@ -133,13 +281,13 @@ func (r *TaskRepository) List(ctx context.Context, userID int64, limit, offset i
 		)
 
 		if err != nil {
-			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+			return nil, dbError("ошибка сканирования строки", err)
 		}
 		tasks = append(tasks, task)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("ошибка обработки строк: %w", err)
+		return nil, dbError("ошибка обработки строк", err)
 	}
 
 	return &model.TaskListResponse{
@@ -148,11 +296,101 @@ func (r *TaskRepository) List(ctx context.Context, userID int64, limit, offset i
 	}, nil
 }
 
+// iterateBatchSize ограничивает число строк в памяти и время удержания соединения при Iterate
+const iterateBatchSize = 500
+
+// Iterate обходит все задачи пользователя по фильтру в порядке ID, читая их пачками по ключу,
+// чтобы не держать соединение из пула, пока fn пишет данные медленному клиенту
+func (r *TaskRepository) Iterate(ctx context.Context, userID int64, filter model.TaskFilter, fn func(task *model.Task) error) error {
+	where, args := buildFilter(userID, filter)
+	query := fmt.Sprintf(`
+		SELECT id, title, description, status, user_id, due_date, created_at, updated_at
+		FROM tasks
+		WHERE %s AND id > $%d
+		ORDER BY id
+		LIMIT $%d
+	`, where, len(args)+1, len(args)+2)
+
+	var lastID int64
+	for {
+		batch, err := r.iterateBatch(ctx, query, append(args, lastID, iterateBatchSize))
+		if err != nil {
+			return err
+		}
+
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < iterateBatchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+// iterateBatch читает одну пачку Iterate; таймаут операции действует на пачку, а не на весь
+// обход, который длится, пока клиент принимает данные
+func (r *TaskRepository) iterateBatch(ctx context.Context, query string, args []interface{}) ([]model.Task, error) {
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, dbError("ошибка получения задач", err)
+	}
+
+	batch, err := pgx.CollectRows(rows, scanTask)
+	if err != nil {
+		return nil, dbError("ошибка сканирования строки", err)
+	}
+	return batch, nil
+}
+
+// Stats возвращает количество задач пользователя и время последнего изменения для построения ETag
+func (r *TaskRepository) Stats(ctx context.Context, userID int64) (*model.TaskStats, error) {
+	defer r.metrics.ObserveQuery("stats", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT count(*), max(updated_at) FROM tasks WHERE user_id = $1`
+
+	var stats model.TaskStats
+	if err := r.pool.QueryRow(ctx, query, userID).Scan(&stats.Count, &stats.LastUpdated); err != nil {
+		return nil, dbError("ошибка получения статистики задач", err)
+	}
+
+	return &stats, nil
+}
+
+func scanTask(row pgx.CollectableRow) (model.Task, error) {
+	var task model.Task
+	err := row.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.Status,
+		&task.UserID,
+		&task.DueDate,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+	return task, err
+}
+
 func (r *TaskRepository) Update(ctx context.Context, id, userID int64, req model.UpdateTaskRequest) (*model.Task, error) {
-	task, err := r.GetByID(ctx, id, userID)
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	// текущее состояние читается из основной базы: на реплике оно может быть устаревшим
+	task, err := getTask(ctx, r.pool, id, userID)
 	if err != nil {
 		return nil, err
 	}
+	defer r.metrics.ObserveQuery("update", time.Now())
+	previousStatus := task.Status
 
 	if req.Title != nil {
 		task.Title = *req.Title
@@ -189,23 +427,191 @@ func (r *TaskRepository) Update(ctx context.Context, id, userID int64, req model
 	).Scan(&id)
 
 	if err != nil {
-		return nil, fmt.Errorf("ошибка обновления задачи: %w", err)
+		return nil, dbError("ошибка обновления задачи", err)
 	}
 
+	r.metrics.TaskStatusChanged(previousStatus, task.Status)
+	r.written(userID)
+	debugLog(ctx).Int64("task_id", id).Msg("задача обновлена в базе")
 	return task, nil
 }
 
 func (r *TaskRepository) Delete(ctx context.Context, id, userID int64) error {
-	query := `DELETE FROM tasks WHERE id = $1 AND user_id = $2`
+	defer r.metrics.ObserveQuery("delete", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
 
-	result, err := r.pool.Exec(ctx, query, id, userID)
-	if err != nil {
-		return fmt.Errorf("ошибка удаления задачи: %w", err)
-	}
+	query := `DELETE FROM tasks WHERE id = $1 AND user_id = $2 RETURNING status`
 
-	if result.RowsAffected() == 0 {
+	var status model.TaskStatus
+	err := r.pool.QueryRow(ctx, query, id, userID).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		debugLog(ctx).Int64("task_id", id).Msg("задача для удаления не найдена")
 		return fmt.Errorf("задача не найдена")
 	}
+	if err != nil {
+		return dbError("ошибка удаления задачи", err)
+	}
 
+	r.metrics.TaskDeleted(status)
+	r.written(userID)
+	debugLog(ctx).Int64("task_id", id).Msg("задача удалена из базы")
 	return nil
 }
+
+// purgeBatchSize сколько задач удаляет один запрос DeleteUpdatedBefore: каждая пачка
+// укладывается в таймаут операции и не держит блокировки на всех задачах пользователя
+const purgeBatchSize = 1000
+
+// updatedBefore условие фильтра с ограничением updated_at < before
+func updatedBefore(userID int64, filter model.TaskFilter, before time.Time) (string, []interface{}) {
+	where, args := buildFilter(userID, filter)
+	args = append(args, before)
+	return fmt.Sprintf("%s AND updated_at < $%d", where, len(args)), args
+}
+
+func (r *TaskRepository) CountUpdatedBefore(ctx context.Context, userID int64, filter model.TaskFilter, before time.Time) (int64, error) {
+	defer r.metrics.ObserveQuery("count_updated_before", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	where, args := updatedBefore(userID, filter, before)
+
+	var count int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM tasks WHERE `+where, args...).Scan(&count); err != nil {
+		return 0, dbError("ошибка подсчета задач для очистки", err)
+	}
+	return count, nil
+}
+
+func (r *TaskRepository) DeleteUpdatedBefore(ctx context.Context, userID int64, filter model.TaskFilter, before time.Time) ([]int64, error) {
+	where, args := updatedBefore(userID, filter, before)
+	query := fmt.Sprintf(`
+		DELETE FROM tasks WHERE id IN (
+			SELECT id FROM tasks WHERE %s ORDER BY id LIMIT %d
+		)
+		RETURNING id, status
+	`, where, purgeBatchSize)
+
+	var ids []int64
+	for {
+		deleted, err := r.deleteBatch(ctx, query, args)
+		ids = append(ids, deleted...)
+		if len(deleted) > 0 {
+			r.written(userID)
+		}
+		if err != nil {
+			return ids, err
+		}
+		if len(deleted) < purgeBatchSize {
+			debugLog(ctx).Int64("user_id", userID).Int("count", len(ids)).Msg("задачи удалены из базы")
+			return ids, nil
+		}
+	}
+}
+
+func (r *TaskRepository) deleteBatch(ctx context.Context, query string, args []interface{}) ([]int64, error) {
+	defer r.metrics.ObserveQuery("delete_updated_before", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, dbError("ошибка удаления задач", err)
+	}
+	defer rows.Close()
+
+	// при ошибке ID пачки не возвращаются; повторная очистка найдет только оставшиеся задачи
+	var ids []int64
+	var statuses []model.TaskStatus
+	for rows.Next() {
+		var id int64
+		var status model.TaskStatus
+		if err := rows.Scan(&id, &status); err != nil {
+			return nil, dbError("ошибка сканирования строки", err)
+		}
+		ids = append(ids, id)
+		statuses = append(statuses, status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dbError("ошибка обработки строк", err)
+	}
+
+	for _, status := range statuses {
+		r.metrics.TaskDeleted(status)
+	}
+	return ids, nil
+}
+
+// CountOverdue считает незавершенные задачи всех пользователей с прошедшим сроком
+func (r *TaskRepository) CountOverdue(ctx context.Context) (int64, error) {
+	defer r.metrics.ObserveQuery("count_overdue", time.Now())
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT count(*) FROM tasks WHERE due_date < now() AND status <> $1`
+
+	var count int64
+	if err := r.pool.QueryRow(ctx, query, model.TaskStatusCompleted).Scan(&count); err != nil {
+		return 0, dbError("ошибка подсчета просроченных задач", err)
+	}
+
+	return count, nil
+}
+
+// withTimeout контекст операции с таймаутом queryTimeout; более ранний дедлайн ctx сохраняется
+func (r *TaskRepository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
+// read выполняет чтение на реплике, если они подключены, иначе в основной базе
+func (r *TaskRepository) read(ctx context.Context, userID int64, fn func(db *pgxpool.Pool) error) error {
+	if r.replicas == nil {
+		return fn(r.pool)
+	}
+	return r.replicas.Read(ctx, userID, fn)
+}
+
+// written отмечает изменение задач пользователя для чтения своих записей
+func (r *TaskRepository) written(userID int64) {
+	if r.replicas != nil {
+		r.replicas.MarkWritten(userID)
+	}
+}
+
+// debugLog пишет в журнал запроса, который middleware кладет в контекст; вне запроса
+// записи отбрасываются, ошибки при этом все равно возвращаются вызывающему коду
+func debugLog(ctx context.Context) *zerolog.Event {
+	return zerolog.Ctx(ctx).Debug().Ctx(ctx)
+}
+
+// likeEscaper экранирует служебные символы LIKE, чтобы поиск шел по буквальной подстроке
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// buildFilter собирает условие WHERE и его аргументы; плейсхолдеры нумеруются с $1
+func buildFilter(userID int64, filter model.TaskFilter) (string, []interface{}) {
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+
+	if filter.Status != nil {
+		args = append(args, *filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Search != "" {
+		args = append(args, likeEscaper.Replace(filter.Search))
+		conditions = append(conditions, fmt.Sprintf(`(title ILIKE '%%' || $%d || '%%' ESCAPE '\' OR description ILIKE '%%' || $%d || '%%' ESCAPE '\')`, len(args), len(args)))
+	}
+	if filter.DueBefore != nil {
+		args = append(args, *filter.DueBefore)
+		conditions = append(conditions, fmt.Sprintf("due_date < $%d", len(args)))
+	}
+	if filter.DueAfter != nil {
+		args = append(args, *filter.DueAfter)
+		conditions = append(conditions, fmt.Sprintf("due_date >= $%d", len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -223,7 +223,7 @@ func (suite *TaskRepositoryTestSuite) TestList() {
 
 	for _, tt := range tests {
 		suite.Run(tt.name, func() {
-			result, err := suite.repo.List(suite.ctx, tt.userID, tt.limit, tt.offset)
+			result, err := suite.repo.List(suite.ctx, tt.userID, model.TaskFilter{}, tt.limit, tt.offset)
 
 			if tt.wantErr {
 				assert.Error(suite.T(), err)
@@ -244,6 +244,171 @@ func (suite *TaskRepositoryTestSuite) TestList() {
 	}
 }
 
+func (suite *TaskRepositoryTestSuite) TestListWithFilter() {
+	userID := int64(1)
+	dueSoon := time.Now().Add(24 * time.Hour)
+	dueLater := time.Now().Add(7 * 24 * time.Hour)
+
+	fixtures := []model.CreateTaskRequest{
+		{Title: "Купить молоко", Status: model.TaskStatusPending, DueDate: &dueSoon},
+		{Title: "Написать отчет", Description: "квартальный", Status: model.TaskStatusInProgress, DueDate: &dueLater},
+		{Title: "Позвонить", Status: model.TaskStatusCompleted},
+		{Title: "Скидка 100%", Status: model.TaskStatusPending},
+	}
+	for _, req := range fixtures {
+		_, err := suite.repo.Create(suite.ctx, userID, req)
+		require.NoError(suite.T(), err)
+	}
+
+	completed := model.TaskStatusCompleted
+	threeDays := time.Now().Add(3 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		filter    model.TaskFilter
+		wantTotal int64
+	}{
+		{
+			name:      "by_status",
+			filter:    model.TaskFilter{Status: &completed},
+			wantTotal: 1,
+		},
+		{
+			name:      "by_search_in_description",
+			filter:    model.TaskFilter{Search: "КВАРТАЛ"},
+			wantTotal: 1,
+		},
+		{
+			name:      "search_percent_is_literal",
+			filter:    model.TaskFilter{Search: "0%"},
+			wantTotal: 1,
+		},
+		{
+			name:      "search_underscore_is_literal",
+			filter:    model.TaskFilter{Search: "_"},
+			wantTotal: 0,
+		},
+		{
+			name:      "by_due_before",
+			filter:    model.TaskFilter{DueBefore: &threeDays},
+			wantTotal: 1,
+		},
+		{
+			name:      "by_due_after",
+			filter:    model.TaskFilter{DueAfter: &threeDays},
+			wantTotal: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			result, err := suite.repo.List(suite.ctx, userID, tt.filter, 10, 0)
+
+			require.NoError(suite.T(), err)
+			assert.Equal(suite.T(), tt.wantTotal, result.Total)
+			assert.Len(suite.T(), result.Tasks, int(tt.wantTotal))
+		})
+	}
+}
+
+func (suite *TaskRepositoryTestSuite) TestGetByIDs() {
+	first, err := suite.repo.Create(suite.ctx, 1, testutils.CreateTaskRequestFixture())
+	require.NoError(suite.T(), err)
+	second, err := suite.repo.Create(suite.ctx, 1, testutils.CreateTaskRequestFixture())
+	require.NoError(suite.T(), err)
+	foreign, err := suite.repo.Create(suite.ctx, 2, testutils.CreateTaskRequestFixture())
+	require.NoError(suite.T(), err)
+
+	tasks, err := suite.repo.GetByIDs(suite.ctx, []int64{first.ID, second.ID, foreign.ID, 999}, 1)
+
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), tasks, 2)
+	for _, task := range tasks {
+		assert.Equal(suite.T(), int64(1), task.UserID)
+	}
+}
+
+func (suite *TaskRepositoryTestSuite) TestIterate() {
+	userID := int64(1)
+	for i := 0; i < 3; i++ {
+		_, err := suite.repo.Create(suite.ctx, userID, testutils.CreateTaskRequestFixture(func(r *model.CreateTaskRequest) {
+			r.Title = fmt.Sprintf("Task %d", i)
+		}))
+		require.NoError(suite.T(), err)
+	}
+	_, err := suite.repo.Create(suite.ctx, 2, testutils.CreateTaskRequestFixture())
+	require.NoError(suite.T(), err)
+
+	var ids []int64
+	err = suite.repo.Iterate(suite.ctx, userID, model.TaskFilter{}, func(task *model.Task) error {
+		assert.Equal(suite.T(), userID, task.UserID)
+		ids = append(ids, task.ID)
+		return nil
+	})
+
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), ids, 3)
+	assert.IsIncreasing(suite.T(), ids)
+}
+
+func (suite *TaskRepositoryTestSuite) TestDeleteUpdatedBefore() {
+	userID := int64(1)
+	cutoff := time.Now().Add(-24 * time.Hour)
+	completed := model.TaskStatusCompleted
+	filter := model.TaskFilter{Status: &completed}
+
+	create := func(status model.TaskStatus, updatedAt time.Time) int64 {
+		task, err := suite.repo.Create(suite.ctx, userID, testutils.CreateTaskRequestFixture(func(r *model.CreateTaskRequest) {
+			r.Status = status
+		}))
+		require.NoError(suite.T(), err)
+		_, err = suite.testDB.Pool.Exec(suite.ctx, `UPDATE tasks SET updated_at = $1 WHERE id = $2`, updatedAt, task.ID)
+		require.NoError(suite.T(), err)
+		return task.ID
+	}
+	stale := create(model.TaskStatusCompleted, cutoff.Add(-time.Hour))
+	create(model.TaskStatusCompleted, time.Now())
+	create(model.TaskStatusPending, cutoff.Add(-time.Hour))
+
+	count, err := suite.repo.CountUpdatedBefore(suite.ctx, userID, filter, cutoff)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1), count)
+
+	ids, err := suite.repo.DeleteUpdatedBefore(suite.ctx, userID, filter, cutoff)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []int64{stale}, ids)
+
+	// повторная очистка не считает уже удаленные задачи ошибкой
+	ids, err = suite.repo.DeleteUpdatedBefore(suite.ctx, userID, filter, cutoff)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), ids)
+}
+
+func (suite *TaskRepositoryTestSuite) TestBulkCreate() {
+	userID := int64(1)
+	due := time.Now().Add(24 * time.Hour).Truncate(time.Microsecond)
+	tasks := []model.CreateTaskRequest{
+		{Title: "Первая", Status: model.TaskStatusInProgress, DueDate: &due},
+		{Title: "Вторая", Description: "без статуса"},
+	}
+
+	count, err := suite.repo.BulkCreate(suite.ctx, userID, tasks)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), count)
+
+	result, err := suite.repo.List(suite.ctx, userID, model.TaskFilter{}, 10, 0)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), result.Tasks, 2)
+
+	byTitle := make(map[string]model.Task)
+	for _, task := range result.Tasks {
+		byTitle[task.Title] = task
+	}
+	assert.Equal(suite.T(), model.TaskStatusInProgress, byTitle["Первая"].Status)
+	assert.True(suite.T(), due.Equal(*byTitle["Первая"].DueDate))
+	assert.Equal(suite.T(), model.TaskStatusPending, byTitle["Вторая"].Status)
+}
+
 func (suite *TaskRepositoryTestSuite) TestUpdate() {
 	userID := int64(1)
 	req := testutils.CreateTaskRequestFixture()
@@ -374,6 +539,13 @@ func (suite *TaskRepositoryTestSuite) TestDelete() {
 	}
 }
 
+func TestBuildFilterEscapesSearch(t *testing.T) {
+	where, args := buildFilter(1, model.TaskFilter{Search: `50%_off\`})
+
+	assert.Contains(t, where, `ESCAPE '\'`)
+	assert.Equal(t, []interface{}{int64(1), `50\%\_off\\`}, args)
+}
+
 func TestTaskRepositorySuite(t *testing.T) {
 	suite.Run(t, new(TaskRepositoryTestSuite))
 }
@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+)
+
+type TwoFactorRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTwoFactorRepository(pool *pgxpool.Pool) repository.TwoFactorRepository {
+	return &TwoFactorRepository{pool: pool}
+}
+
+func (r *TwoFactorRepository) Get(ctx context.Context, userID int64) (*model.TwoFactor, error) {
+	query := `SELECT user_id, secret, last_used_step, created_at, enabled_at FROM user_totp WHERE user_id = $1`
+
+	var twoFactor model.TwoFactor
+	err := r.pool.QueryRow(ctx, query, userID).Scan(
+		&twoFactor.UserID,
+		&twoFactor.Secret,
+		&twoFactor.LastUsedStep,
+		&twoFactor.CreatedAt,
+		&twoFactor.EnabledAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения настроек 2FA: %w", err)
+	}
+
+	return &twoFactor, nil
+}
+
+func (r *TwoFactorRepository) SavePending(ctx context.Context, userID int64, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE user_totp.enabled_at IS NULL
+	`
+
+	result, err := r.pool.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения секрета 2FA: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return repository.ErrAlreadyExists
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		result, err := tx.Exec(ctx,
+			`UPDATE user_totp SET enabled_at = NOW(), last_used_step = $2 WHERE user_id = $1 AND enabled_at IS NULL`,
+			userID, step,
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка включения 2FA: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return repository.ErrNotFound
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("ошибка удаления кодов восстановления: %w", err)
+		}
+
+		query := `INSERT INTO user_recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`
+		if _, err := tx.Exec(ctx, query, userID, recoveryCodeHashes); err != nil {
+			return fmt.Errorf("ошибка сохранения кодов восстановления: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *TwoFactorRepository) ConsumeStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	query := `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	result, err := r.pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("ошибка обновления периода TOTP: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *TwoFactorRepository) ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	query := `
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE id = (
+			SELECT id FROM user_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		)
+	`
+
+	result, err := r.pool.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("ошибка использования кода восстановления: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	query := `SELECT count(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	var count int
+	if err := r.pool.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчета кодов восстановления: %w", err)
+	}
+	return count, nil
+}
+
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID int64) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("ошибка удаления кодов восстановления: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+			return fmt.Errorf("ошибка отключения 2FA: %w", err)
+		}
+		return nil
+	})
+}
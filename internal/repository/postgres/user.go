@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+)
+
+// uniqueViolation код ошибки PostgreSQL при нарушении уникального индекса
+const uniqueViolation = "23505"
+
+type UserRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewUserRepository(pool *pgxpool.Pool) repository.UserRepository {
+	return &UserRepository{pool: pool}
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	query := `SELECT id, username, password_hash, role, created_at FROM users WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	query := `SELECT id, username, password_hash, role, created_at FROM users WHERE username = $1`
+	return r.getOne(ctx, query, username)
+}
+
+func (r *UserRepository) GetByExternalID(ctx context.Context, issuer, subject string) (*model.User, error) {
+	query := `SELECT id, username, password_hash, role, created_at FROM users WHERE oidc_issuer = $1 AND oidc_subject = $2`
+	return r.getOne(ctx, query, issuer, subject)
+}
+
+func (r *UserRepository) Create(ctx context.Context, user model.User) (*model.User, error) {
+	query := `
+		INSERT INTO users (username, password_hash, role)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, user.Username, user.PasswordHash, user.Role).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("ошибка создания пользователя: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) CreateExternal(ctx context.Context, user model.User, issuer, subject string) (*model.User, error) {
+	query := `
+		INSERT INTO users (username, password_hash, role, oidc_issuer, oidc_subject)
+		VALUES ($1, '', $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, user.Username, user.Role, issuer, subject).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return nil, repository.ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("ошибка создания пользователя: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) SetPasswordIfEmpty(ctx context.Context, id int64, passwordHash string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET password_hash = $2 WHERE id = $1 AND password_hash = ''`, id, passwordHash)
+	if err != nil {
+		return false, fmt.Errorf("ошибка установки пароля пользователя: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *UserRepository) getOne(ctx context.Context, query string, args ...interface{}) (*model.User, error) {
+	var user model.User
+	err := r.pool.QueryRow(ctx, query, args...).Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения пользователя: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) List(ctx context.Context, limit, offset int) (*model.UserListResponse, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, `SELECT count(*) FROM users`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("ошибка подсчета пользователей: %w", err)
+	}
+
+	query := `
+		SELECT id, username, password_hash, role, created_at
+		FROM users
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка пользователей: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка обработки строк: %w", err)
+	}
+
+	return &model.UserListResponse{
+		Total: total,
+		Users: users,
+	}, nil
+}
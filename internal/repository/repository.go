@@ -2,17 +2,97 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"github.com/kkboranbay/task-service/internal/model"
+	"time"
+)
+
+var (
+	ErrNotFound      = errors.New("запись не найдена")
+	ErrAlreadyExists = errors.New("запись уже существует")
+	// ErrQueryTimeout запрос не уложился в таймаут операции или statement_timeout базы
+	ErrQueryTimeout = errors.New("превышено время выполнения запроса к базе данных")
+	// ErrDatabaseUnavailable база останавливается или к ней нельзя подключиться
+	ErrDatabaseUnavailable = errors.New("база данных недоступна")
 )
 
 type TaskRepository interface {
 	Create(ctx context.Context, userID int64, task model.CreateTaskRequest) (*model.Task, error)
+	BulkCreate(ctx context.Context, userID int64, tasks []model.CreateTaskRequest) (int64, error)
 	GetByID(ctx context.Context, id, userID int64) (*model.Task, error)
-	List(ctx context.Context, userID int64, limit, offset int) (*model.TaskListResponse, error)
+	GetByIDs(ctx context.Context, ids []int64, userID int64) ([]model.Task, error)
+	List(ctx context.Context, userID int64, filter model.TaskFilter, limit, offset int) (*model.TaskListResponse, error)
+	Iterate(ctx context.Context, userID int64, filter model.TaskFilter, fn func(task *model.Task) error) error
+	Stats(ctx context.Context, userID int64) (*model.TaskStats, error)
 	Update(ctx context.Context, id, userID int64, task model.UpdateTaskRequest) (*model.Task, error)
 	Delete(ctx context.Context, id, userID int64) error
+	// CountUpdatedBefore число задач пользователя по фильтру, не изменявшихся с before
+	CountUpdatedBefore(ctx context.Context, userID int64, filter model.TaskFilter, before time.Time) (int64, error)
+	// DeleteUpdatedBefore удаляет задачи пользователя по фильтру, не изменявшиеся с before,
+	// и возвращает их ID; задачи, удаленные параллельно, просто не попадают в результат
+	DeleteUpdatedBefore(ctx context.Context, userID int64, filter model.TaskFilter, before time.Time) ([]int64, error)
+	// CountOverdue число незавершенных задач с прошедшим сроком по всем пользователям, для метрик
+	CountOverdue(ctx context.Context) (int64, error)
+}
+
+// CalendarTokenRepository хранит хэши секретных токенов календарной подписки, по одному на пользователя
+type CalendarTokenRepository interface {
+	Upsert(ctx context.Context, userID int64, tokenHash string) (time.Time, error)
+	GetByUserID(ctx context.Context, userID int64) (*model.CalendarToken, error)
+	GetUserID(ctx context.Context, tokenHash string) (int64, error)
+	Delete(ctx context.Context, userID int64) error
+}
+
+type UserRepository interface {
+	GetByID(ctx context.Context, id int64) (*model.User, error)
+	GetByUsername(ctx context.Context, username string) (*model.User, error)
+	List(ctx context.Context, limit, offset int) (*model.UserListResponse, error)
+	// GetByExternalID ищет пользователя, связанного с учетной записью внешнего издателя
+	GetByExternalID(ctx context.Context, issuer, subject string) (*model.User, error)
+	// Create создает пользователя с паролем; ErrAlreadyExists при занятом имени
+	Create(ctx context.Context, user model.User) (*model.User, error)
+	// CreateExternal создает пользователя без пароля; ErrAlreadyExists при занятом имени или уже связанной учетной записи
+	CreateExternal(ctx context.Context, user model.User, issuer, subject string) (*model.User, error)
+	// SetPasswordIfEmpty задает пароль пользователю без пароля; false, если пароль уже есть или пользователя нет
+	SetPasswordIfEmpty(ctx context.Context, id int64, passwordHash string) (bool, error)
+}
+
+// AuditRepository журнал действий администраторов
+type AuditRepository interface {
+	Create(ctx context.Context, entry model.AuditEntry) error
+	List(ctx context.Context, limit, offset int) (*model.AuditListResponse, error)
+}
+
+// AccessTokenRepository хранит хэши персональных токенов доступа
+type AccessTokenRepository interface {
+	Create(ctx context.Context, token model.AccessToken, tokenHash string) (*model.AccessToken, error)
+	ListByUser(ctx context.Context, userID int64) ([]model.AccessToken, error)
+	// GetByHash возвращает неотозванный токен вместе с ролью владельца
+	GetByHash(ctx context.Context, tokenHash string) (*model.AccessToken, error)
+	TouchLastUsed(ctx context.Context, id int64, usedAt time.Time) error
+	Revoke(ctx context.Context, id, userID int64) error
+}
+
+// TwoFactorRepository хранит TOTP секреты и хэши кодов восстановления
+type TwoFactorRepository interface {
+	Get(ctx context.Context, userID int64) (*model.TwoFactor, error)
+	// SavePending сохраняет секрет неподтвержденной регистрации; ErrAlreadyExists, если 2FA уже включена
+	SavePending(ctx context.Context, userID int64, secret string) error
+	// Enable подтверждает регистрацию и заменяет коды восстановления; ErrNotFound, если нет ожидающей регистрации
+	Enable(ctx context.Context, userID int64, step int64, recoveryCodeHashes []string) error
+	// ConsumeStep запоминает использованный период TOTP; false, если этот или более поздний период уже использован
+	ConsumeStep(ctx context.Context, userID int64, step int64) (bool, error)
+	// ConsumeRecoveryCode помечает код использованным; false, если кода нет или он уже использован
+	ConsumeRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID int64) (int, error)
+	Delete(ctx context.Context, userID int64) error
 }
 
 type Repository struct {
-	Task TaskRepository
+	Task          TaskRepository
+	CalendarToken CalendarTokenRepository
+	User          UserRepository
+	Audit         AuditRepository
+	AccessToken   AccessTokenRepository
+	TwoFactor     TwoFactorRepository
 }
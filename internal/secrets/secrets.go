@@ -0,0 +1,107 @@
+// Package secrets источники секретов вне переменных окружения: файлы, смонтированные
+// платформой, и внешние хранилища с периодическим обновлением ротируемых значений
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"github.com/rs/zerolog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider внешнее хранилище секретов. Ключи совпадают с именами переменных окружения,
+// которые они заменяют, например JWT_SECRET или DB_PASSWORD
+type Provider interface {
+	Secrets(ctx context.Context) (map[string]string, error)
+}
+
+// ReadFile читает секрет из файла; завершающий перевод строки, который добавляют редакторы
+// и echo, не считается частью секрета
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения секрета из %s: %w", path, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// Store текущие значения секретов. Компоненты читают секрет при каждом использовании,
+// поэтому ротация в хранилище подхватывается без перезапуска. Предыдущее значение
+// сохраняется, чтобы, например, выданные до ротации JWT оставались действительными
+type Store struct {
+	mu       sync.RWMutex
+	values   map[string]string
+	previous map[string]string
+}
+
+func NewStore(values map[string]string) *Store {
+	s := &Store{
+		values:   make(map[string]string, len(values)),
+		previous: make(map[string]string),
+	}
+	for key, value := range values {
+		s.values[key] = value
+	}
+	return s
+}
+
+func (s *Store) Get(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[key]
+}
+
+// Previous значение секрета до последней ротации или пустая строка
+func (s *Store) Previous(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.previous[key]
+}
+
+// Update применяет новые значения и возвращает отсортированные ключи, которые изменились.
+// Пустые значения игнорируются: удаление секрета из хранилища не должно обнулять его в сервисе
+func (s *Store) Update(values map[string]string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var changed []string
+	for key, value := range values {
+		if value == "" {
+			continue
+		}
+		if current, ok := s.values[key]; ok && current != value {
+			s.previous[key] = current
+			changed = append(changed, key)
+		}
+		s.values[key] = value
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Run перечитывает секреты из provider каждые interval до отмены ctx. Ошибки хранилища
+// только логируются: сервис продолжает работать с последними полученными значениями
+func (s *Store) Run(ctx context.Context, provider Provider, interval time.Duration, log *zerolog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			values, err := provider.Secrets(ctx)
+			if err != nil {
+				log.Warn().Err(err).Msg("ошибка обновления секретов, используются прежние значения")
+				continue
+			}
+			if changed := s.Update(values); len(changed) > 0 {
+				log.Info().Strs("keys", changed).Msg("секреты обновлены")
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeVault отвечает как KV v2 движок Vault, смонтированный в secret/
+func fakeVault(token string, data func() map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {"permission denied"}})
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/task-service" {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {}})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     data(),
+				"metadata": map[string]interface{}{"version": 3},
+			},
+		})
+	}))
+}
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte("s3cr3t \n"), 0o600))
+
+	value, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t ", value)
+
+	_, err = ReadFile(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestStoreUpdate(t *testing.T) {
+	store := NewStore(map[string]string{"JWT_SECRET": "old", "DB_PASSWORD": "db"})
+
+	changed := store.Update(map[string]string{"JWT_SECRET": "new", "DB_PASSWORD": "db", "OIDC_CLIENT_SECRET": ""})
+	assert.Equal(t, []string{"JWT_SECRET"}, changed)
+	assert.Equal(t, "new", store.Get("JWT_SECRET"))
+	assert.Equal(t, "old", store.Previous("JWT_SECRET"))
+	assert.Equal(t, "db", store.Get("DB_PASSWORD"))
+	assert.Empty(t, store.Previous("DB_PASSWORD"))
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := fakeVault("root", func() map[string]string {
+		return map[string]string{"JWT_SECRET": "from-vault"}
+	})
+	defer server.Close()
+
+	provider := NewVaultProvider(server.URL, "secret", "/task-service", 2, func() string { return "root" }, server.Client())
+	values, err := provider.Secrets(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "from-vault", values["JWT_SECRET"])
+
+	denied := NewVaultProvider(server.URL, "secret", "task-service", 2, func() string { return "wrong" }, server.Client())
+	_, err = denied.Secrets(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+}
+
+func TestVaultProviderKVv1(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/kv/task-service", r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"DB_PASSWORD": "pw", "ttl": 60},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewVaultProvider(server.URL, "kv", "task-service", 1, func() string { return "root" }, server.Client())
+	values, err := provider.Secrets(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DB_PASSWORD": "pw"}, values)
+}
+
+func TestStoreRunPicksUpRotation(t *testing.T) {
+	var version atomic.Int32
+	server := fakeVault("root", func() map[string]string {
+		if version.Load() == 0 {
+			return map[string]string{"JWT_SECRET": "first"}
+		}
+		return map[string]string{"JWT_SECRET": "second"}
+	})
+	defer server.Close()
+
+	provider := NewVaultProvider(server.URL, "secret", "task-service", 2, func() string { return "root" }, server.Client())
+	store := NewStore(map[string]string{"JWT_SECRET": "first"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := zerolog.Nop()
+	go store.Run(ctx, provider, 10*time.Millisecond, &logger)
+
+	version.Store(1)
+	assert.Eventually(t, func() bool { return store.Get("JWT_SECRET") == "second" }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "first", store.Previous("JWT_SECRET"))
+}
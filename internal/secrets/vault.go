@@ -0,0 +1,99 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// VaultProvider читает секреты из KV хранилища HashiCorp Vault
+type VaultProvider struct {
+	addr    string
+	token   func() string
+	path    string
+	version int
+	client  *http.Client
+}
+
+// NewVaultProvider хранилище с движком KV версии version (1 или 2), смонтированным в mount.
+// token вызывается на каждый запрос, чтобы подхватывать обновленный токен из файла
+func NewVaultProvider(addr, mount, path string, version int, token func() string, client *http.Client) *VaultProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	mount = strings.Trim(mount, "/")
+	path = strings.Trim(path, "/")
+	apiPath := mount + "/" + path
+	if version == 2 {
+		apiPath = mount + "/data/" + path
+	}
+
+	return &VaultProvider{
+		addr:    strings.TrimSuffix(addr, "/"),
+		token:   token,
+		path:    apiPath,
+		version: version,
+		client:  client,
+	}
+}
+
+func (p *VaultProvider) Secrets(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.addr+"/v1/"+p.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ответа Vault: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(body, &vaultErr)
+		return nil, fmt.Errorf("статус %d от Vault для %s: %s", resp.StatusCode, p.path, strings.Join(vaultErr.Errors, "; "))
+	}
+
+	// в KV v2 значения вложены в data.data рядом с метаданными версии
+	var raw map[string]interface{}
+	if p.version == 2 {
+		var v2 struct {
+			Data struct {
+				Data map[string]interface{} `json:"data"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &v2); err != nil {
+			return nil, fmt.Errorf("ошибка разбора ответа Vault: %w", err)
+		}
+		raw = v2.Data.Data
+	} else {
+		var v1 struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &v1); err != nil {
+			return nil, fmt.Errorf("ошибка разбора ответа Vault: %w", err)
+		}
+		raw = v1.Data
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		}
+	}
+	return values, nil
+}
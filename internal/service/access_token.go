@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/rs/zerolog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// accessTokenSize длина случайной части персонального токена в байтах
+const accessTokenSize = 32
+
+// lastUsedResolution время последнего использования обновляется не чаще, чтобы частые
+// запросы автоматизации не превращались в запись в базу на каждый вызов
+const lastUsedResolution = time.Minute
+
+var (
+	ErrAccessTokenNotFound = errors.New("токен доступа не найден")
+	ErrInvalidAccessToken  = errors.New("недействительный токен доступа")
+	ErrScopeNotAllowed     = errors.New("запрошены права, которых нет у пользователя")
+	ErrInvalidExpiration   = errors.New("срок действия токена должен быть в будущем")
+)
+
+type AccessTokenService struct {
+	repo repository.AccessTokenRepository
+	log  *zerolog.Logger
+	now  func() time.Time
+}
+
+func NewAccessTokenService(repo repository.AccessTokenRepository, log *zerolog.Logger) *AccessTokenService {
+	return &AccessTokenService{
+		repo: repo,
+		log:  log,
+		now:  time.Now,
+	}
+}
+
+// CreateToken выпускает токен с правами из req.Scopes, которые должны входить в allowedScopes вызывающего
+func (s *AccessTokenService) CreateToken(ctx context.Context, userID int64, allowedScopes []string, req model.CreateAccessTokenRequest) (*model.CreateAccessTokenResponse, error) {
+	for _, scope := range req.Scopes {
+		if !slices.Contains(allowedScopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotAllowed, scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return nil, ErrInvalidExpiration
+	}
+
+	raw := make([]byte, accessTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать токен: %w", err)
+	}
+	secret := model.AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	token, err := s.repo.Create(ctx, model.AccessToken{
+		UserID:    userID,
+		Name:      req.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		ExpiresAt: req.ExpiresAt,
+	}, hashToken(secret))
+	if err != nil {
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка создания токена доступа")
+		return nil, fmt.Errorf("не удалось создать токен доступа: %w", err)
+	}
+
+	s.log.Info().Int64("user_id", userID).Int64("token_id", token.ID).Strs("scopes", token.Scopes).Msg("создан токен доступа")
+	return &model.CreateAccessTokenResponse{
+		AccessToken: *token,
+		Token:       secret,
+	}, nil
+}
+
+func (s *AccessTokenService) ListTokens(ctx context.Context, userID int64) ([]model.AccessToken, error) {
+	tokens, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка получения токенов доступа")
+		return nil, fmt.Errorf("не удалось получить токены доступа: %w", err)
+	}
+	return tokens, nil
+}
+
+func (s *AccessTokenService) RevokeToken(ctx context.Context, id, userID int64) error {
+	if err := s.repo.Revoke(ctx, id, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrAccessTokenNotFound
+		}
+		s.log.Error().Err(err).Int64("token_id", id).Int64("user_id", userID).Msg("ошибка отзыва токена доступа")
+		return fmt.Errorf("не удалось отозвать токен доступа: %w", err)
+	}
+
+	s.log.Info().Int64("token_id", id).Int64("user_id", userID).Msg("токен доступа отозван")
+	return nil
+}
+
+// AuthenticateAccessToken проверяет персональный токен и отмечает время его использования
+func (s *AccessTokenService) AuthenticateAccessToken(ctx context.Context, secret string) (*model.AccessToken, error) {
+	if !strings.HasPrefix(secret, model.AccessTokenPrefix) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	token, err := s.repo.GetByHash(ctx, hashToken(secret))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidAccessToken
+		}
+		return nil, fmt.Errorf("не удалось проверить токен доступа: %w", err)
+	}
+
+	now := s.now()
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		// ошибка обновления не должна отклонять запрос с действительным токеном
+		if err := s.repo.TouchLastUsed(ctx, token.ID, now); err != nil {
+			s.log.Error().Err(err).Int64("token_id", token.ID).Msg("ошибка обновления времени использования токена")
+		}
+	}
+
+	return token, nil
+}
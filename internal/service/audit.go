@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/rs/zerolog"
+)
+
+// AuditService записывает действия администраторов в журнал и дублирует их в лог
+type AuditService struct {
+	repo repository.AuditRepository
+	log  *zerolog.Logger
+}
+
+func NewAuditService(repo repository.AuditRepository, log *zerolog.Logger) *AuditService {
+	return &AuditService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Record сохраняет запись аудита. Ошибка возвращается вызывающему: действие без записи в журнале выполняться не должно
+func (s *AuditService) Record(ctx context.Context, entry model.AuditEntry) error {
+	event := s.log.Info().
+		Str("event", "audit").
+		Int64("actor_id", entry.ActorID).
+		Str("action", string(entry.Action)).
+		Str("details", entry.Details)
+	if entry.TargetUserID != nil {
+		event = event.Int64("target_user_id", *entry.TargetUserID)
+	}
+	event.Msg("действие администратора")
+
+	if err := s.repo.Create(ctx, entry); err != nil {
+		s.log.Error().Err(err).Str("action", string(entry.Action)).Msg("ошибка записи в журнал аудита")
+		return fmt.Errorf("не удалось записать действие в журнал аудита: %w", err)
+	}
+	return nil
+}
+
+func (s *AuditService) List(ctx context.Context, page, pageSize int) (*model.AuditListResponse, error) {
+	page, pageSize = NormalizePage(page, pageSize)
+
+	resp, err := s.repo.List(ctx, pageSize, (page-1)*pageSize)
+	if err != nil {
+		s.log.Error().Err(err).Msg("ошибка получения журнала аудита")
+		return nil, fmt.Errorf("не удалось получить журнал аудита: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/rs/zerolog"
+	"time"
+)
+
+// calendarTokenSize длина секретной части URL подписки в байтах до кодирования
+const calendarTokenSize = 32
+
+var ErrCalendarTokenNotFound = errors.New("токен календаря не найден")
+
+// CalendarService управляет секретными ссылками на iCalendar-подписку пользователя
+type CalendarService struct {
+	tokens repository.CalendarTokenRepository
+	tasks  repository.TaskRepository
+	log    *zerolog.Logger
+}
+
+func NewCalendarService(tokens repository.CalendarTokenRepository, tasks repository.TaskRepository, log *zerolog.Logger) *CalendarService {
+	return &CalendarService{
+		tokens: tokens,
+		tasks:  tasks,
+		log:    log,
+	}
+}
+
+// RotateToken выпускает новый токен подписки; предыдущая ссылка сразу перестает работать.
+// Токен возвращается только здесь, в базе хранится лишь его хэш
+func (s *CalendarService) RotateToken(ctx context.Context, userID int64) (string, time.Time, error) {
+	raw := make([]byte, calendarTokenSize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, fmt.Errorf("не удалось сгенерировать токен: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	createdAt, err := s.tokens.Upsert(ctx, userID, hashToken(token))
+	if err != nil {
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка сохранения токена календаря")
+		return "", time.Time{}, fmt.Errorf("не удалось сохранить токен: %w", err)
+	}
+
+	s.log.Info().Int64("user_id", userID).Msg("выпущен новый токен календаря")
+	return token, createdAt, nil
+}
+
+func (s *CalendarService) GetToken(ctx context.Context, userID int64) (*model.CalendarToken, error) {
+	token, err := s.tokens.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCalendarTokenNotFound
+		}
+		return nil, fmt.Errorf("не удалось получить токен: %w", err)
+	}
+	return token, nil
+}
+
+func (s *CalendarService) RevokeToken(ctx context.Context, userID int64) error {
+	if err := s.tokens.Delete(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrCalendarTokenNotFound
+		}
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка отзыва токена календаря")
+		return fmt.Errorf("не удалось отозвать токен: %w", err)
+	}
+
+	s.log.Info().Int64("user_id", userID).Msg("токен календаря отозван")
+	return nil
+}
+
+// ResolveToken возвращает владельца токена подписки
+func (s *CalendarService) ResolveToken(ctx context.Context, token string) (int64, error) {
+	userID, err := s.tokens.GetUserID(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, ErrCalendarTokenNotFound
+		}
+		return 0, fmt.Errorf("не удалось проверить токен: %w", err)
+	}
+	return userID, nil
+}
+
+// FeedVersion возвращает ETag ленты и время последнего изменения задач; ETag меняется
+// при создании, изменении и удалении задач, поэтому клиенты могут опрашивать ленту условными запросами
+func (s *CalendarService) FeedVersion(ctx context.Context, userID int64) (string, time.Time, error) {
+	stats, err := s.tasks.Stats(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("не удалось получить версию ленты: %w", err)
+	}
+
+	var lastModified time.Time
+	if stats.LastUpdated != nil {
+		lastModified = *stats.LastUpdated
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%d:%d", userID, stats.Count, lastModified.UnixNano())))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, lastModified, nil
+}
+
+// WriteFeed передает в fn задачи пользователя для ленты календаря
+func (s *CalendarService) WriteFeed(ctx context.Context, userID int64, fn func(task *model.Task) error) error {
+	if err := s.tasks.Iterate(ctx, userID, model.TaskFilter{}, fn); err != nil {
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка построения ленты календаря")
+		return fmt.Errorf("не удалось построить ленту календаря: %w", err)
+	}
+	return nil
+}
+
+// hashToken хэш секретного токена для хранения в базе; токены случайные, поэтому соль не нужна
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"github.com/kkboranbay/task-service/internal/model"
+	"sync"
+)
+
+type TaskEventType string
+
+const (
+	TaskEventCreated TaskEventType = "created"
+	TaskEventUpdated TaskEventType = "updated"
+	TaskEventDeleted TaskEventType = "deleted"
+)
+
+// размер буфера канала подписчика; медленный подписчик теряет события, а не блокирует запросы
+const subscriberBufferSize = 64
+
+type TaskEvent struct {
+	Type TaskEventType
+	Task model.Task
+}
+
+// EventBroker рассылает события об изменении задач подписчикам внутри процесса
+type EventBroker struct {
+	mu          sync.RWMutex
+	subscribers map[int64]map[chan TaskEvent]struct{}
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		subscribers: make(map[int64]map[chan TaskEvent]struct{}),
+	}
+}
+
+// Subscribe возвращает канал событий пользователя и функцию отписки
+func (b *EventBroker) Subscribe(userID int64) (<-chan TaskEvent, func()) {
+	ch := make(chan TaskEvent, subscriberBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan TaskEvent]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[userID], ch)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+func (b *EventBroker) Publish(event TaskEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[event.Task.UserID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/kkboranbay/task-service/internal/model"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// validate проверяет теги binding так же, как gin при разборе JSON в хендлерах
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// ValidateCreateRequest применяет к запросу те же правила, что и REST API при создании задачи
+func ValidateCreateRequest(req model.CreateTaskRequest) error {
+	if err := validate.Struct(req); err != nil {
+		var fieldErrors validator.ValidationErrors
+		if !errors.As(err, &fieldErrors) {
+			return err
+		}
+		messages := make([]string, 0, len(fieldErrors))
+		for _, fe := range fieldErrors {
+			messages = append(messages, fmt.Sprintf("поле %s не прошло проверку %s", fe.Field(), fe.Tag()))
+		}
+		return errors.New(strings.Join(messages, "; "))
+	}
+
+	if strings.TrimSpace(req.Title) == "" {
+		return errors.New("отсутствует заголовок задачи")
+	}
+	if !req.Status.IsValid() {
+		return fmt.Errorf("некорректный статус задачи: %s", req.Status)
+	}
+
+	return nil
+}
+
+// ImportTasks проверяет каждую строку и вставляет корректные одним пакетом;
+// ошибки отдельных строк попадают в отчет и не прерывают импорт остальных
+func (s *TaskService) ImportTasks(ctx context.Context, userID int64, rows []model.ImportRow, rowErrors []model.ImportRowError, dryRun bool) (*model.ImportReport, error) {
+	report := &model.ImportReport{
+		DryRun: dryRun,
+		Total:  len(rows) + len(rowErrors),
+		Errors: append(make([]model.ImportRowError, 0, len(rowErrors)), rowErrors...),
+	}
+
+	valid := make([]model.CreateTaskRequest, 0, len(rows))
+	for _, row := range rows {
+		if err := ValidateCreateRequest(row.Task); err != nil {
+			report.Errors = append(report.Errors, model.ImportRowError{Row: row.Row, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, row.Task)
+	}
+	report.Failed = len(report.Errors)
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Row < report.Errors[j].Row
+	})
+
+	s.log.Info().
+		Int64("user_id", userID).
+		Int("total", report.Total).
+		Int("valid", len(valid)).
+		Bool("dry_run", dryRun).
+		Msg("импорт задач")
+
+	if dryRun || len(valid) == 0 {
+		return report, nil
+	}
+
+	imported, err := s.repo.BulkCreate(ctx, userID, valid)
+	if err != nil {
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка импорта задач")
+		return nil, fmt.Errorf("не удалось импортировать задачи: %w", err)
+	}
+	report.Imported = int(imported)
+
+	s.log.Info().Int64("user_id", userID).Int64("imported", imported).Msg("задачи успешно импортированы")
+	return report, nil
+}
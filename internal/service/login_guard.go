@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/rs/zerolog"
+	"sync"
+	"time"
+)
+
+// loginGuardSweepInterval как часто из памяти удаляются устаревшие счетчики попыток
+const loginGuardSweepInterval = time.Minute
+
+// pendingRetryAfter пауза для попытки, отклоненной из-за параллельных попыток того же ключа:
+// их результат станет известен после проверки пароля
+const pendingRetryAfter = time.Second
+
+// LoginBlockedError возвращается, когда попытку входа нужно отклонить до проверки пароля
+type LoginBlockedError struct {
+	// Locked отличает блокировку после превышения порога от прогрессивной задержки между попытками
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	if e.Locked {
+		return "вход временно заблокирован"
+	}
+	return "слишком частые попытки входа"
+}
+
+type loginAttempts struct {
+	failures     int
+	firstFailure time.Time
+	nextAllowed  time.Time
+	lockedUntil  time.Time
+	// inFlight попытки, прошедшие Check, но еще не завершенные Release
+	inFlight int
+}
+
+// loginLockout блокировка, которую нужно записать в журнал аудита после снятия мьютекса
+type loginLockout struct {
+	key         string
+	failures    int
+	lockedUntil time.Time
+}
+
+// LoginGuard считает неудачные попытки входа по имени пользователя и по IP: каждая следующая
+// ошибка удваивает паузу до следующей попытки, а после порога ключ блокируется на LockoutDuration
+type LoginGuard struct {
+	cfg   config.LoginGuardConfig
+	log   *zerolog.Logger
+	audit *AuditService
+	now   func() time.Time
+
+	mu        sync.Mutex
+	attempts  map[string]*loginAttempts
+	lastSweep time.Time
+}
+
+func NewLoginGuard(cfg config.LoginGuardConfig, log *zerolog.Logger) *LoginGuard {
+	return &LoginGuard{
+		cfg:      cfg,
+		log:      log,
+		now:      time.Now,
+		attempts: make(map[string]*loginAttempts),
+	}
+}
+
+// WithAudit записывает блокировки входа в журнал аудита
+func (g *LoginGuard) WithAudit(audit *AuditService) *LoginGuard {
+	g.audit = audit
+	return g
+}
+
+// Check возвращает *LoginBlockedError, если для пользователя или адреса действует задержка или блокировка.
+// Иначе попытка резервируется: параллельные попытки считаются возможными неудачами, чтобы их нельзя было
+// запустить разом до первой RecordFailure. После проверки учетных данных нужно вызвать Release
+func (g *LoginGuard) Check(username, ip string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.sweep(now)
+
+	keys := []struct {
+		key         string
+		maxFailures int
+	}{
+		{userKey(username), g.cfg.MaxFailures},
+		{ipKey(ip), g.cfg.MaxFailuresPerIP},
+	}
+
+	var blocked *LoginBlockedError
+	for _, k := range keys {
+		a, ok := g.attempts[k.key]
+		if !ok {
+			continue
+		}
+
+		// если все незавершенные попытки окажутся неудачными, следующей пришлось бы ждать или она
+		// превысила бы порог, поэтому до их завершения новая попытка не допускается
+		pending := a.failures + a.inFlight
+
+		var err *LoginBlockedError
+		switch {
+		case now.Before(a.lockedUntil):
+			err = &LoginBlockedError{Locked: true, RetryAfter: a.lockedUntil.Sub(now)}
+		case now.Before(a.nextAllowed):
+			err = &LoginBlockedError{RetryAfter: a.nextAllowed.Sub(now)}
+		case a.inFlight > 0 && (pending >= k.maxFailures || g.delay(pending) > 0):
+			err = &LoginBlockedError{RetryAfter: pendingRetryAfter}
+		default:
+			continue
+		}
+
+		if blocked == nil || err.RetryAfter > blocked.RetryAfter {
+			blocked = err
+		}
+	}
+
+	if blocked != nil {
+		return blocked
+	}
+
+	for _, k := range keys {
+		a, ok := g.attempts[k.key]
+		if !ok {
+			a = &loginAttempts{}
+			g.attempts[k.key] = a
+		}
+		a.inFlight++
+	}
+	return nil
+}
+
+// Release завершает попытку, зарезервированную Check
+func (g *LoginGuard) Release(username, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		a, ok := g.attempts[key]
+		if !ok || a.inFlight == 0 {
+			continue
+		}
+		a.inFlight--
+		if a.inFlight == 0 && a.failures == 0 {
+			delete(g.attempts, key)
+		}
+	}
+}
+
+// RecordFailure учитывает неудачную попытку входа
+func (g *LoginGuard) RecordFailure(ctx context.Context, username, ip string) {
+	g.mu.Lock()
+	now := g.now()
+	var lockouts []loginLockout
+	for _, k := range []struct {
+		key         string
+		maxFailures int
+	}{
+		{userKey(username), g.cfg.MaxFailures},
+		{ipKey(ip), g.cfg.MaxFailuresPerIP},
+	} {
+		if lockout, ok := g.recordFailure(k.key, k.maxFailures, now, username, ip); ok {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+	g.mu.Unlock()
+
+	// запись в базу идет без мьютекса, чтобы не задерживать проверки других попыток
+	for _, lockout := range lockouts {
+		g.recordLockout(ctx, lockout, ip)
+	}
+}
+
+// RecordSuccess сбрасывает счетчик пользователя. Счетчик IP не сбрасывается, чтобы успешный вход
+// в свою учетную запись не позволял продолжить перебор чужих
+func (g *LoginGuard) RecordSuccess(username string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := userKey(username)
+	if a, ok := g.attempts[key]; ok && a.inFlight > 0 {
+		// параллельные попытки еще не завершены, их резерв снимет Release
+		g.attempts[key] = &loginAttempts{inFlight: a.inFlight}
+		return
+	}
+	delete(g.attempts, key)
+}
+
+// HasFailures сообщает, есть ли у пользователя или, если передан, у IP неудачные попытки,
+// которые может снять Unlock
+func (g *LoginGuard) HasFailures(username, ip string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, key := range unlockKeys(username, ip) {
+		if _, ok := g.attempts[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Unlock снимает блокировку с пользователя и, если передан, с IP; возвращает false, если снимать было нечего
+func (g *LoginGuard) Unlock(username, ip string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	unlocked := false
+	for _, key := range unlockKeys(username, ip) {
+		if _, ok := g.attempts[key]; ok {
+			delete(g.attempts, key)
+			unlocked = true
+		}
+	}
+	return unlocked
+}
+
+func unlockKeys(username, ip string) []string {
+	keys := []string{userKey(username)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	return keys
+}
+
+// recordFailure возвращает блокировку, если эта неудача ее вызвала
+func (g *LoginGuard) recordFailure(key string, maxFailures int, now time.Time, username, ip string) (loginLockout, bool) {
+	a, ok := g.attempts[key]
+	if !ok || a.failures == 0 || now.Sub(a.firstFailure) > g.cfg.FailureWindow {
+		inFlight := 0
+		if ok {
+			inFlight = a.inFlight
+		}
+		a = &loginAttempts{firstFailure: now, inFlight: inFlight}
+		g.attempts[key] = a
+	}
+
+	a.failures++
+	a.nextAllowed = now.Add(g.delay(a.failures))
+
+	if a.failures < maxFailures || now.Before(a.lockedUntil) {
+		return loginLockout{}, false
+	}
+
+	a.lockedUntil = now.Add(g.cfg.LockoutDuration)
+	g.log.Warn().
+		Str("event", "login_lockout").
+		Str("key", key).
+		Str("username", username).
+		Str("ip", ip).
+		Int("failures", a.failures).
+		Time("locked_until", a.lockedUntil).
+		Msg("вход заблокирован после серии неудачных попыток")
+	return loginLockout{key: key, failures: a.failures, lockedUntil: a.lockedUntil}, true
+}
+
+// recordLockout записывает блокировку в журнал аудита. Ошибка записи уже залогирована AuditService
+// и не отменяет блокировку
+func (g *LoginGuard) recordLockout(ctx context.Context, lockout loginLockout, ip string) {
+	if g.audit == nil {
+		return
+	}
+
+	// запись не должна теряться, если клиент закрыл соединение после неудачной попытки
+	ctx = context.WithoutCancel(ctx)
+	_ = g.audit.Record(ctx, model.AuditEntry{
+		ActorID: model.SystemActorID,
+		Action:  model.AuditActionLoginLockout,
+		Details: fmt.Sprintf("key=%s ip=%s failures=%d locked_until=%s",
+			lockout.key, ip, lockout.failures, lockout.lockedUntil.UTC().Format(time.RFC3339)),
+	})
+}
+
+// delay пауза после failures неудачных попыток: первая ошибка без паузы, дальше BaseDelay, 2*BaseDelay и так до MaxDelay
+func (g *LoginGuard) delay(failures int) time.Duration {
+	if failures < 2 || g.cfg.BaseDelay <= 0 {
+		return 0
+	}
+
+	d := g.cfg.BaseDelay
+	for i := 2; i < failures && d < g.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > g.cfg.MaxDelay {
+		d = g.cfg.MaxDelay
+	}
+	return d
+}
+
+func (g *LoginGuard) sweep(now time.Time) {
+	if now.Sub(g.lastSweep) < loginGuardSweepInterval {
+		return
+	}
+	g.lastSweep = now
+
+	for key, a := range g.attempts {
+		if a.inFlight == 0 && now.Sub(a.firstFailure) > g.cfg.FailureWindow && !now.Before(a.lockedUntil) {
+			delete(g.attempts, key)
+		}
+	}
+}
+
+func userKey(username string) string {
+	return "user:" + username
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
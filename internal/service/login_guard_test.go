@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestLoginGuard(now *time.Time) *LoginGuard {
+	logger := zerolog.Nop()
+	guard := NewLoginGuard(config.LoginGuardConfig{
+		MaxFailures:      3,
+		MaxFailuresPerIP: 5,
+		FailureWindow:    15 * time.Minute,
+		LockoutDuration:  10 * time.Minute,
+		BaseDelay:        time.Second,
+		MaxDelay:         4 * time.Second,
+	}, &logger)
+	guard.now = func() time.Time { return *now }
+	return guard
+}
+
+// check проверяет попытку и сразу завершает ее, как обработчик после проверки пароля
+func check(guard *LoginGuard, username, ip string) error {
+	err := guard.Check(username, ip)
+	if err == nil {
+		guard.Release(username, ip)
+	}
+	return err
+}
+
+func blockedError(t *testing.T, err error) *LoginBlockedError {
+	var blocked *LoginBlockedError
+	require.True(t, errors.As(err, &blocked), "ожидалась ошибка блокировки, получено %v", err)
+	return blocked
+}
+
+func TestLoginGuardProgressiveDelay(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	guard.RecordFailure(context.Background(), "admin", "10.0.0.1")
+	assert.NoError(t, check(guard, "admin", "10.0.0.1"))
+
+	guard.RecordFailure(context.Background(), "admin", "10.0.0.1")
+	blocked := blockedError(t, check(guard, "admin", "10.0.0.1"))
+	assert.False(t, blocked.Locked)
+	assert.Equal(t, time.Second, blocked.RetryAfter)
+
+	// задержка привязана к имени пользователя, смена адреса ее не обходит
+	blockedError(t, check(guard, "admin", "10.0.0.2"))
+
+	now = now.Add(time.Second)
+	assert.NoError(t, check(guard, "admin", "10.0.0.1"))
+
+	guard.RecordSuccess("admin")
+	guard.RecordFailure(context.Background(), "admin", "10.0.0.3")
+	assert.NoError(t, check(guard, "admin", "10.0.0.3"))
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	for i := 0; i < 3; i++ {
+		guard.RecordFailure(context.Background(), "admin", "10.0.0.1")
+		now = now.Add(5 * time.Second)
+	}
+
+	blocked := blockedError(t, check(guard, "admin", "10.0.0.9"))
+	assert.True(t, blocked.Locked)
+	assert.Equal(t, 10*time.Minute-5*time.Second, blocked.RetryAfter)
+
+	assert.NoError(t, check(guard, "other", "10.0.0.9"))
+
+	now = now.Add(10 * time.Minute)
+	assert.NoError(t, check(guard, "admin", "10.0.0.9"))
+}
+
+func TestLoginGuardLocksIP(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	for _, username := range []string{"a", "b", "c", "d", "e"} {
+		guard.RecordFailure(context.Background(), username, "10.0.0.1")
+	}
+
+	blocked := blockedError(t, check(guard, "f", "10.0.0.1"))
+	assert.True(t, blocked.Locked)
+	assert.NoError(t, check(guard, "f", "10.0.0.2"))
+}
+
+func TestLoginGuardUnlock(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	for i := 0; i < 3; i++ {
+		guard.RecordFailure(context.Background(), "admin", "10.0.0.1")
+	}
+	blockedError(t, check(guard, "admin", "10.0.0.1"))
+
+	assert.True(t, guard.HasFailures("admin", ""))
+	assert.True(t, guard.Unlock("admin", ""))
+	assert.NoError(t, check(guard, "admin", "10.0.0.2"))
+	assert.False(t, guard.HasFailures("admin", ""))
+	assert.False(t, guard.Unlock("admin", ""))
+}
+
+func TestLoginGuardParallelAttempts(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	guard := newTestLoginGuard(&now)
+
+	// без задержки после первой неудачи допускаются две одновременные попытки, третья ждет их результата
+	require.NoError(t, guard.Check("admin", "10.0.0.1"))
+	require.NoError(t, guard.Check("admin", "10.0.0.2"))
+	blocked := blockedError(t, guard.Check("admin", "10.0.0.3"))
+	assert.False(t, blocked.Locked)
+
+	guard.RecordFailure(context.Background(), "admin", "10.0.0.1")
+	guard.Release("admin", "10.0.0.1")
+	blockedError(t, guard.Check("admin", "10.0.0.3"))
+
+	guard.RecordSuccess("admin")
+	guard.Release("admin", "10.0.0.2")
+	assert.NoError(t, check(guard, "admin", "10.0.0.3"))
+}
+
+func TestLoginGuardConcurrentFailuresRespectLimit(t *testing.T) {
+	logger := zerolog.Nop()
+	guard := NewLoginGuard(config.LoginGuardConfig{
+		MaxFailures:      3,
+		MaxFailuresPerIP: 100,
+		FailureWindow:    time.Minute,
+		LockoutDuration:  time.Minute,
+	}, &logger)
+
+	var (
+		wg      sync.WaitGroup
+		checked atomic.Int32
+		start   = make(chan struct{})
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if guard.Check("admin", "10.0.0.1") != nil {
+				return
+			}
+			checked.Add(1)
+			// проверка пароля идет дольше, чем остальные попытки проходят Check
+			time.Sleep(10 * time.Millisecond)
+			guard.RecordFailure(context.Background(), "admin", "10.0.0.1")
+			guard.Release("admin", "10.0.0.1")
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.LessOrEqual(t, int(checked.Load()), 3, "параллельные попытки не обходят MaxFailures")
+	assert.True(t, blockedError(t, guard.Check("admin", "10.0.0.1")).Locked)
+}
+
+func TestLoginGuardAuditsLockout(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	audit := new(mocks.MockAuditRepository)
+	logger := zerolog.Nop()
+	guard := newTestLoginGuard(&now).WithAudit(NewAuditService(audit, &logger))
+
+	audit.On("Create", mock.Anything, mock.MatchedBy(func(entry model.AuditEntry) bool {
+		return entry.Action == model.AuditActionLoginLockout &&
+			entry.ActorID == model.SystemActorID &&
+			strings.Contains(entry.Details, "key=user:admin")
+	})).Return(nil).Once()
+
+	for i := 0; i < 3; i++ {
+		guard.RecordFailure(context.Background(), "admin", "10.0.0.1")
+	}
+	audit.AssertExpectations(t)
+}
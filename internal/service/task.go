@@ -6,103 +6,220 @@ import (
 	"fmt"
 	"github.com/kkboranbay/task-service/internal/model"
 	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/kkboranbay/task-service/internal/tracing"
+	"github.com/kkboranbay/task-service/pkg/logger"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// Ошибки хранилища, на которые обработчики отвечают 504 и 503 вместо 500
+var (
+	ErrStorageTimeout     = repository.ErrQueryTimeout
+	ErrStorageUnavailable = repository.ErrDatabaseUnavailable
 )
 
 type TaskService struct {
-	repo repository.TaskRepository
-	log  *zerolog.Logger
+	repo   repository.TaskRepository
+	log    *zerolog.Logger
+	events *EventBroker
 }
 
 func NewTaskService(repo repository.TaskRepository, log *zerolog.Logger) *TaskService {
 	return &TaskService{
-		repo: repo,
-		log:  log,
+		repo:   repo,
+		log:    log,
+		events: NewEventBroker(),
 	}
 }
 
+// Subscribe подписывает на события изменения задач пользователя
+func (s *TaskService) Subscribe(userID int64) (<-chan TaskEvent, func()) {
+	return s.events.Subscribe(userID)
+}
+
 func (s *TaskService) CreateTask(ctx context.Context, userID int64, req model.CreateTaskRequest) (*model.Task, error) {
+	ctx, span := s.startSpan(ctx, "CreateTask", userID)
+	defer span.End()
+
 	if req.Title == "" {
 		return nil, errors.New("отсутствует заголовок задачи")
 	}
 
-	s.log.Info().Int64("user_id", userID).Str("title", req.Title).Msg("создание новой задачи")
+	s.logger(ctx).Info().Ctx(ctx).Int64("user_id", userID).Str("title", req.Title).Msg("создание новой задачи")
 
 	task, err := s.repo.Create(ctx, userID, req)
 	if err != nil {
-		s.log.Error().Err(err).Int64("user_id", userID).Str("title", req.Title).Msg("ошибка создания задачи")
+		tracing.RecordError(span, err)
+		logger.Error(s.logger(ctx), err).Ctx(ctx).Int64("user_id", userID).Str("title", req.Title).Msg("ошибка создания задачи")
 		return nil, fmt.Errorf("не удалось создать задачу: %w", err)
 	}
 
-	s.log.Info().Int64("task_id", task.ID).Int64("user_id", userID).Msg("задача успешно создана")
+	s.logger(ctx).Info().Ctx(ctx).Int64("task_id", task.ID).Int64("user_id", userID).Msg("задача успешно создана")
+	s.events.Publish(TaskEvent{Type: TaskEventCreated, Task: *task})
 	return task, nil
 }
 
 func (s *TaskService) GetTaskByID(ctx context.Context, id, userID int64) (*model.Task, error) {
-	s.log.Info().Int64("task_id", id).Int64("user_id", userID).Msg("получение задачи по ID")
+	ctx, span := s.startSpan(ctx, "GetTaskByID", userID)
+	defer span.End()
+	span.SetAttributes(attribute.Int64("task_id", id))
+
+	s.logger(ctx).Info().Ctx(ctx).Int64("task_id", id).Int64("user_id", userID).Msg("получение задачи по ID")
 
 	task, err := s.repo.GetByID(ctx, id, userID)
 	if err != nil {
-		s.log.Error().Err(err).Int64("task_id", id).Int64("user_id", userID).Msg("ошибка получения задачи")
+		tracing.RecordError(span, err)
+		logger.Error(s.logger(ctx), err).Ctx(ctx).Int64("task_id", id).Int64("user_id", userID).Msg("ошибка получения задачи")
 		return nil, fmt.Errorf("не удалось получить задачу: %w", err)
 	}
 
 	return task, nil
 }
 
-func (s *TaskService) GetTaskList(ctx context.Context, userID int64, page, pageSize int) (*model.TaskListResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 {
-		pageSize = 10
+// GetTasksByIDs возвращает задачи пользователя по списку ID одним обращением к репозиторию
+func (s *TaskService) GetTasksByIDs(ctx context.Context, ids []int64, userID int64) ([]model.Task, error) {
+	ctx, span := s.startSpan(ctx, "GetTasksByIDs", userID)
+	defer span.End()
+
+	s.logger(ctx).Info().Ctx(ctx).Int64("user_id", userID).Int("count", len(ids)).Msg("получение задач по списку ID")
+
+	tasks, err := s.repo.GetByIDs(ctx, ids, userID)
+	if err != nil {
+		tracing.RecordError(span, err)
+		logger.Error(s.logger(ctx), err).Ctx(ctx).Int64("user_id", userID).Msg("ошибка получения задач по списку ID")
+		return nil, fmt.Errorf("не удалось получить задачи: %w", err)
 	}
-	if pageSize > 100 {
-		pageSize = 100
+
+	return tasks, nil
+}
+
+func (s *TaskService) GetTaskList(ctx context.Context, userID int64, filter model.TaskFilter, page, pageSize int) (*model.TaskListResponse, error) {
+	ctx, span := s.startSpan(ctx, "GetTaskList", userID)
+	defer span.End()
+
+	if filter.Status != nil && !filter.Status.IsValid() {
+		return nil, errors.New("некорректный статус задачи")
 	}
 
+	page, pageSize = NormalizePage(page, pageSize)
 	offset := (page - 1) * pageSize
 
-	s.log.Info().Int64("user_id", userID).Int("page", page).Int("page_size", pageSize).Msg("получение списка задач")
+	s.logger(ctx).Info().Ctx(ctx).Int64("user_id", userID).Int("page", page).Int("page_size", pageSize).Msg("получение списка задач")
 
-	resp, err := s.repo.List(ctx, userID, pageSize, offset)
+	resp, err := s.repo.List(ctx, userID, filter, pageSize, offset)
 	if err != nil {
-		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка получения списка задач")
+		tracing.RecordError(span, err)
+		logger.Error(s.logger(ctx), err).Ctx(ctx).Int64("user_id", userID).Msg("ошибка получения списка задач")
 		return nil, fmt.Errorf("не удалось получить список задач: %w", err)
 	}
 
 	return resp, nil
 }
 
+// ExportTasks передает задачи пользователя по фильтру в fn по одной, не загружая весь список в память
+func (s *TaskService) ExportTasks(ctx context.Context, userID int64, filter model.TaskFilter, fn func(task *model.Task) error) error {
+	ctx, span := s.startSpan(ctx, "ExportTasks", userID)
+	defer span.End()
+
+	if filter.Status != nil && !filter.Status.IsValid() {
+		return errors.New("некорректный статус задачи")
+	}
+
+	s.logger(ctx).Info().Ctx(ctx).Int64("user_id", userID).Msg("экспорт задач")
+
+	if err := s.repo.Iterate(ctx, userID, filter, fn); err != nil {
+		tracing.RecordError(span, err)
+		logger.Error(s.logger(ctx), err).Ctx(ctx).Int64("user_id", userID).Msg("ошибка экспорта задач")
+		return fmt.Errorf("не удалось экспортировать задачи: %w", err)
+	}
+
+	return nil
+}
+
 func (s *TaskService) UpdateTask(ctx context.Context, id, userID int64, req model.UpdateTaskRequest) (*model.Task, error) {
-	s.log.Info().Int64("task_id", id).Int64("user_id", userID).Msg("обновление задачи")
+	ctx, span := s.startSpan(ctx, "UpdateTask", userID)
+	defer span.End()
+	span.SetAttributes(attribute.Int64("task_id", id))
 
-	if req.Status != nil {
-		status := *req.Status
-		if status != model.TaskStatusPending && status != model.TaskStatusInProgress && status != model.TaskStatusCompleted {
-			return nil, errors.New("некорректный статус задачи")
-		}
+	s.logger(ctx).Info().Ctx(ctx).Int64("task_id", id).Int64("user_id", userID).Msg("обновление задачи")
+
+	if req.Status != nil && !req.Status.IsValid() {
+		return nil, errors.New("некорректный статус задачи")
 	}
 
 	task, err := s.repo.Update(ctx, id, userID, req)
 	if err != nil {
-		s.log.Error().Err(err).Int64("task_id", id).Int64("user_id", userID).Msg("ошибка обновления задачи")
+		tracing.RecordError(span, err)
+		logger.Error(s.logger(ctx), err).Ctx(ctx).Int64("task_id", id).Int64("user_id", userID).Msg("ошибка обновления задачи")
 		return nil, fmt.Errorf("не удалось обновить задачу: %w", err)
 	}
 
-	s.log.Info().Int64("task_id", id).Int64("user_id", userID).Msg("задача успешно обновлена")
+	s.logger(ctx).Info().Ctx(ctx).Int64("task_id", id).Int64("user_id", userID).Msg("задача успешно обновлена")
+	s.events.Publish(TaskEvent{Type: TaskEventUpdated, Task: *task})
 	return task, nil
 }
 
 func (s *TaskService) DeleteTask(ctx context.Context, id, userID int64) error {
-	s.log.Info().Int64("task_id", id).Int64("user_id", userID).Msg("удаление задачи")
+	ctx, span := s.startSpan(ctx, "DeleteTask", userID)
+	defer span.End()
+	span.SetAttributes(attribute.Int64("task_id", id))
+
+	s.logger(ctx).Info().Ctx(ctx).Int64("task_id", id).Int64("user_id", userID).Msg("удаление задачи")
 
 	err := s.repo.Delete(ctx, id, userID)
 	if err != nil {
-		s.log.Error().Err(err).Int64("task_id", id).Int64("user_id", userID).Msg("ошибка удаления задачи")
+		tracing.RecordError(span, err)
+		logger.Error(s.logger(ctx), err).Ctx(ctx).Int64("task_id", id).Int64("user_id", userID).Msg("ошибка удаления задачи")
 		return fmt.Errorf("не удалось удалить задачу: %w", err)
 	}
 
-	s.log.Info().Int64("task_id", id).Int64("user_id", userID).Msg("задача успешно удалена")
+	s.logger(ctx).Info().Ctx(ctx).Int64("task_id", id).Int64("user_id", userID).Msg("задача успешно удалена")
+	s.events.Publish(TaskEvent{Type: TaskEventDeleted, Task: model.Task{ID: id, UserID: userID}})
 	return nil
 }
+
+// PurgeTasks удаляет задачи пользователя по фильтру, не изменявшиеся с before, и возвращает
+// их количество; при dryRun задачи только подсчитываются
+func (s *TaskService) PurgeTasks(ctx context.Context, userID int64, filter model.TaskFilter, before time.Time, dryRun bool) (int, error) {
+	ctx, span := s.startSpan(ctx, "PurgeTasks", userID)
+	defer span.End()
+
+	if filter.Status != nil && !filter.Status.IsValid() {
+		return 0, errors.New("некорректный статус задачи")
+	}
+
+	if dryRun {
+		count, err := s.repo.CountUpdatedBefore(ctx, userID, filter, before)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return 0, fmt.Errorf("не удалось подсчитать задачи для очистки: %w", err)
+		}
+		return int(count), nil
+	}
+
+	ids, err := s.repo.DeleteUpdatedBefore(ctx, userID, filter, before)
+	for _, id := range ids {
+		s.events.Publish(TaskEvent{Type: TaskEventDeleted, Task: model.Task{ID: id, UserID: userID}})
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		logger.Error(s.logger(ctx), err).Ctx(ctx).Int64("user_id", userID).Int("count", len(ids)).Msg("ошибка очистки задач")
+		return len(ids), fmt.Errorf("не удалось удалить задачи: %w", err)
+	}
+
+	s.logger(ctx).Info().Ctx(ctx).Int64("user_id", userID).Int("count", len(ids)).Time("before", before).Msg("очистка задач завершена")
+	return len(ids), nil
+}
+
+// logger журнал текущего запроса с его request_id; s.log используется вне HTTP запросов
+func (s *TaskService) logger(ctx context.Context) *zerolog.Logger {
+	return logger.FromContext(ctx, s.log)
+}
+
+// startSpan открывает спан метода сервиса; контекст со спаном уходит в репозиторий,
+// поэтому запросы к базе становятся дочерними спанами
+func (s *TaskService) startSpan(ctx context.Context, method string, userID int64) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "TaskService."+method, trace.WithAttributes(attribute.Int64("user_id", userID)))
+}
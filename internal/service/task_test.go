@@ -3,14 +3,23 @@ package service
 import (
 	"context"
 	"errors"
+	"github.com/kkboranbay/task-service/internal/config"
 	"github.com/kkboranbay/task-service/internal/mocks"
 	"github.com/kkboranbay/task-service/internal/model"
 	"github.com/kkboranbay/task-service/internal/testutils"
+	"github.com/kkboranbay/task-service/internal/tracing"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"testing"
+	"time"
 )
 
 type TaskServiceTestSuite struct {
@@ -42,7 +51,7 @@ func (suite *TaskServiceTestSuite) TestCreateTask() {
 			req:    testutils.CreateTaskRequestFixture(),
 			setupMock: func() {
 				expectedTask := testutils.TaskFixture()
-				suite.mockRepo.On("Create", suite.ctx, int64(1), mock.AnythingOfType("model.CreateTaskRequest")).
+				suite.mockRepo.On("Create", mock.Anything, int64(1), mock.AnythingOfType("model.CreateTaskRequest")).
 					Return(expectedTask, nil).Once()
 			},
 			wantErr: false,
@@ -62,7 +71,7 @@ func (suite *TaskServiceTestSuite) TestCreateTask() {
 			userID: 1,
 			req:    testutils.CreateTaskRequestFixture(),
 			setupMock: func() {
-				suite.mockRepo.On("Create", suite.ctx, int64(1), mock.AnythingOfType("model.CreateTaskRequest")).
+				suite.mockRepo.On("Create", mock.Anything, int64(1), mock.AnythingOfType("model.CreateTaskRequest")).
 					Return(nil, errors.New("database error")).Once()
 			},
 			wantErr:    true,
@@ -106,7 +115,7 @@ func (suite *TaskServiceTestSuite) TestGetTaskByID() {
 			userID: 1,
 			setupMock: func() {
 				expectedTask := testutils.TaskFixture()
-				suite.mockRepo.On("GetByID", suite.ctx, int64(1), int64(1)).
+				suite.mockRepo.On("GetByID", mock.Anything, int64(1), int64(1)).
 					Return(expectedTask, nil).Once()
 			},
 			wantErr: false,
@@ -116,7 +125,7 @@ func (suite *TaskServiceTestSuite) TestGetTaskByID() {
 			id:     999,
 			userID: 1,
 			setupMock: func() {
-				suite.mockRepo.On("GetByID", suite.ctx, int64(999), int64(1)).
+				suite.mockRepo.On("GetByID", mock.Anything, int64(999), int64(1)).
 					Return(nil, errors.New("task not found")).Once()
 			},
 			wantErr:    true,
@@ -163,7 +172,7 @@ func (suite *TaskServiceTestSuite) TestGetTaskList() {
 					Total: 1,
 					Tasks: []model.Task{*testutils.TaskFixture()},
 				}
-				suite.mockRepo.On("List", suite.ctx, int64(1), 10, 0).
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 10, 0).
 					Return(expectedResponse, nil).Once()
 			},
 		},
@@ -177,7 +186,7 @@ func (suite *TaskServiceTestSuite) TestGetTaskList() {
 					Tasks: []model.Task{*testutils.TaskFixture()},
 					Total: 10,
 				}
-				suite.mockRepo.On("List", suite.ctx, int64(1), 5, 5).
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 5, 5).
 					Return(expectedResponse, nil).Once()
 			},
 			wantErr: false,
@@ -189,7 +198,7 @@ func (suite *TaskServiceTestSuite) TestGetTaskList() {
 			pageSize: 10,
 			setupMock: func() {
 				expectedResponse := &model.TaskListResponse{Tasks: []model.Task{}, Total: 0}
-				suite.mockRepo.On("List", suite.ctx, int64(1), 10, 0).
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 10, 0).
 					Return(expectedResponse, nil).Once()
 			},
 			wantErr: false,
@@ -201,7 +210,7 @@ func (suite *TaskServiceTestSuite) TestGetTaskList() {
 			pageSize: 0,
 			setupMock: func() {
 				expectedResponse := &model.TaskListResponse{Tasks: []model.Task{}, Total: 0}
-				suite.mockRepo.On("List", suite.ctx, int64(1), 10, 0).
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 10, 0).
 					Return(expectedResponse, nil).Once()
 			},
 			wantErr: false,
@@ -213,7 +222,7 @@ func (suite *TaskServiceTestSuite) TestGetTaskList() {
 			pageSize: 200,
 			setupMock: func() {
 				expectedResponse := &model.TaskListResponse{Tasks: []model.Task{}}
-				suite.mockRepo.On("List", suite.ctx, int64(1), 100, 0).
+				suite.mockRepo.On("List", mock.Anything, int64(1), model.TaskFilter{}, 100, 0).
 					Return(expectedResponse, nil).Once()
 			},
 			wantErr: false,
@@ -224,7 +233,7 @@ func (suite *TaskServiceTestSuite) TestGetTaskList() {
 		suite.Run(tt.name, func() {
 			tt.setupMock()
 
-			result, err := suite.service.GetTaskList(suite.ctx, tt.userID, tt.page, tt.pageSize)
+			result, err := suite.service.GetTaskList(suite.ctx, tt.userID, model.TaskFilter{}, tt.page, tt.pageSize)
 
 			if tt.wantErr {
 				assert.Error(suite.T(), err)
@@ -256,7 +265,7 @@ func (suite *TaskServiceTestSuite) TestUpdateTask() {
 			req:    testutils.UpdateTaskRequestFixture(),
 			setupMock: func() {
 				expectedTask := testutils.TaskFixture()
-				suite.mockRepo.On("Update", suite.ctx, int64(1), int64(1), mock.AnythingOfType("model.UpdateTaskRequest")).
+				suite.mockRepo.On("Update", mock.Anything, int64(1), int64(1), mock.AnythingOfType("model.UpdateTaskRequest")).
 					Return(expectedTask, nil).Once()
 			},
 			wantErr: false,
@@ -279,7 +288,7 @@ func (suite *TaskServiceTestSuite) TestUpdateTask() {
 			userID: 1,
 			req:    testutils.UpdateTaskRequestFixture(),
 			setupMock: func() {
-				suite.mockRepo.On("Update", suite.ctx, int64(1), int64(1), mock.AnythingOfType("model.UpdateTaskRequest")).
+				suite.mockRepo.On("Update", mock.Anything, int64(1), int64(1), mock.AnythingOfType("model.UpdateTaskRequest")).
 					Return(nil, errors.New("database error")).Once()
 			},
 			wantErr:    true,
@@ -320,7 +329,7 @@ func (suite *TaskServiceTestSuite) TestDeleteTask() {
 			id:     1,
 			userID: 1,
 			setupMock: func() {
-				suite.mockRepo.On("Delete", suite.ctx, int64(1), int64(1)).
+				suite.mockRepo.On("Delete", mock.Anything, int64(1), int64(1)).
 					Return(nil).Once()
 			},
 			wantErr: false,
@@ -330,7 +339,7 @@ func (suite *TaskServiceTestSuite) TestDeleteTask() {
 			id:     1,
 			userID: 1,
 			setupMock: func() {
-				suite.mockRepo.On("Delete", suite.ctx, int64(1), int64(1)).
+				suite.mockRepo.On("Delete", mock.Anything, int64(1), int64(1)).
 					Return(errors.New("database error")).Once()
 			},
 			wantErr:    true,
@@ -356,6 +365,73 @@ func (suite *TaskServiceTestSuite) TestDeleteTask() {
 	}
 }
 
+func (suite *TaskServiceTestSuite) TestPurgeTasks() {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	completed := model.TaskStatusCompleted
+	filter := model.TaskFilter{Status: &completed}
+
+	suite.Run("dry_run", func() {
+		suite.mockRepo.On("CountUpdatedBefore", mock.Anything, int64(1), filter, cutoff).Return(int64(2), nil).Once()
+
+		count, err := suite.service.PurgeTasks(suite.ctx, 1, filter, cutoff, true)
+
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), 2, count)
+		suite.mockRepo.AssertNotCalled(suite.T(), "DeleteUpdatedBefore", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	suite.Run("deletes_stale_tasks", func() {
+		events, unsubscribe := suite.service.Subscribe(1)
+		defer unsubscribe()
+		suite.mockRepo.On("DeleteUpdatedBefore", mock.Anything, int64(1), filter, cutoff).Return([]int64{1, 3}, nil).Once()
+
+		count, err := suite.service.PurgeTasks(suite.ctx, 1, filter, cutoff, false)
+
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), 2, count)
+		for _, id := range []int64{1, 3} {
+			event := <-events
+			assert.Equal(suite.T(), TaskEventDeleted, event.Type)
+			assert.Equal(suite.T(), id, event.Task.ID)
+		}
+		suite.mockRepo.AssertExpectations(suite.T())
+	})
+
+	suite.Run("reports_deleted_before_error", func() {
+		suite.mockRepo.On("DeleteUpdatedBefore", mock.Anything, int64(1), filter, cutoff).
+			Return([]int64{1}, errors.New("database error")).Once()
+
+		count, err := suite.service.PurgeTasks(suite.ctx, 1, filter, cutoff, false)
+
+		assert.Error(suite.T(), err)
+		assert.Equal(suite.T(), 1, count)
+	})
+}
+
+func (suite *TaskServiceTestSuite) TestTracing() {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracing.NewTracerProvider(config.TracingConfig{ServiceName: "test", SampleRatio: 1}, sdktrace.WithSyncer(exporter)))
+	defer otel.SetTracerProvider(previous)
+
+	// запросы репозитория должны выполняться внутри спана метода сервиса
+	var repoSpan trace.SpanContext
+	suite.mockRepo.On("Delete", mock.Anything, int64(7), int64(1)).
+		Run(func(args mock.Arguments) {
+			repoSpan = trace.SpanContextFromContext(args.Get(0).(context.Context))
+		}).
+		Return(errors.New("database error")).Once()
+
+	err := suite.service.DeleteTask(suite.ctx, 7, 1)
+	require.Error(suite.T(), err)
+
+	spans := exporter.GetSpans()
+	require.Len(suite.T(), spans, 1)
+	assert.Equal(suite.T(), "TaskService.DeleteTask", spans[0].Name)
+	assert.Equal(suite.T(), codes.Error, spans[0].Status.Code)
+	assert.Equal(suite.T(), spans[0].SpanContext.SpanID(), repoSpan.SpanID())
+}
+
 func TestTaskServiceSuite(t *testing.T) {
 	suite.Run(t, new(TaskServiceTestSuite))
 }
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/kkboranbay/task-service/internal/totp"
+	"github.com/rs/zerolog"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("двухфакторная аутентификация уже включена")
+	ErrTwoFactorNotEnrolled = errors.New("двухфакторная аутентификация не настроена")
+	ErrTwoFactorRequired    = errors.New("двухфакторная аутентификация обязательна и не может быть отключена")
+	ErrInvalidTwoFactorCode = errors.New("неверный код подтверждения")
+)
+
+const recoveryCodeCount = 10
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TwoFactorService struct {
+	repo     repository.TwoFactorRepository
+	issuer   string
+	required bool
+	now      func() time.Time
+	log      *zerolog.Logger
+}
+
+func NewTwoFactorService(repo repository.TwoFactorRepository, cfg config.AuthConfig, log *zerolog.Logger) *TwoFactorService {
+	return &TwoFactorService{
+		repo:     repo,
+		issuer:   cfg.TwoFactorIssuer,
+		required: cfg.RequireTwoFactor,
+		now:      time.Now,
+		log:      log,
+	}
+}
+
+// Required политика, по которой каждый вход по паролю должен подтверждаться вторым фактором
+func (s *TwoFactorService) Required() bool {
+	return s.required
+}
+
+func (s *TwoFactorService) Enabled(ctx context.Context, userID int64) (bool, error) {
+	twoFactor, err := s.get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrTwoFactorNotEnrolled) {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.Enabled(), nil
+}
+
+func (s *TwoFactorService) Status(ctx context.Context, userID int64) (*model.TwoFactorStatus, error) {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.TwoFactorStatus{Enabled: enabled, Required: s.required}
+	if enabled {
+		if status.RecoveryCodesLeft, err = s.repo.CountRecoveryCodes(ctx, userID); err != nil {
+			s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка подсчета кодов восстановления")
+			return nil, fmt.Errorf("не удалось получить настройки 2FA: %w", err)
+		}
+	}
+	return status, nil
+}
+
+// Enroll создает новый секрет; 2FA включается только после подтверждения кодом из приложения
+func (s *TwoFactorService) Enroll(ctx context.Context, user *model.User) (*model.TwoFactorEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать секрет: %w", err)
+	}
+
+	if err := s.repo.SavePending(ctx, user.ID, secret); err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrTwoFactorEnabled
+		}
+		s.log.Error().Err(err).Int64("user_id", user.ID).Msg("ошибка сохранения секрета 2FA")
+		return nil, fmt.Errorf("не удалось начать настройку 2FA: %w", err)
+	}
+
+	return &model.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Confirm включает 2FA по первому коду из приложения и возвращает коды восстановления,
+// которые больше нигде не хранятся в открытом виде
+func (s *TwoFactorService) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	twoFactor, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.Enabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(twoFactor.Secret, code, s.now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось сгенерировать коды восстановления: %w", err)
+	}
+
+	if err := s.repo.Enable(ctx, userID, step, hashes); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTwoFactorNotEnrolled
+		}
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка включения 2FA")
+		return nil, fmt.Errorf("не удалось включить 2FA: %w", err)
+	}
+
+	s.log.Info().Int64("user_id", userID).Msg("двухфакторная аутентификация включена")
+	return codes, nil
+}
+
+// Verify принимает код из приложения или неиспользованный код восстановления.
+// Каждый код TOTP принимается только один раз
+func (s *TwoFactorService) Verify(ctx context.Context, userID int64, code string) error {
+	twoFactor, err := s.get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.Enabled() {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	if step, ok := totp.Validate(twoFactor.Secret, code, s.now()); ok {
+		fresh, err := s.repo.ConsumeStep(ctx, userID, step)
+		if err != nil {
+			s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка проверки кода 2FA")
+			return fmt.Errorf("не удалось проверить код: %w", err)
+		}
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.repo.ConsumeRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка проверки кода восстановления")
+		return fmt.Errorf("не удалось проверить код: %w", err)
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	s.log.Warn().Int64("user_id", userID).Msg("вход по коду восстановления")
+	return nil
+}
+
+// CompleteChallenge завершает второй шаг входа: проверяет код включенной 2FA или, если
+// регистрация только начата, подтверждает ее и возвращает коды восстановления
+func (s *TwoFactorService) CompleteChallenge(ctx context.Context, userID int64, code string) ([]string, error) {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, s.Verify(ctx, userID, code)
+	}
+	return s.Confirm(ctx, userID, code)
+}
+
+// Disable отключает 2FA после проверки действующего кода
+func (s *TwoFactorService) Disable(ctx context.Context, userID int64, code string) error {
+	if s.required {
+		return ErrTwoFactorRequired
+	}
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка отключения 2FA")
+		return fmt.Errorf("не удалось отключить 2FA: %w", err)
+	}
+
+	s.log.Info().Int64("user_id", userID).Msg("двухфакторная аутентификация отключена")
+	return nil
+}
+
+func (s *TwoFactorService) get(ctx context.Context, userID int64) (*model.TwoFactor, error) {
+	twoFactor, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTwoFactorNotEnrolled
+		}
+		s.log.Error().Err(err).Int64("user_id", userID).Msg("ошибка получения настроек 2FA")
+		return nil, fmt.Errorf("не удалось получить настройки 2FA: %w", err)
+	}
+	return twoFactor, nil
+}
+
+// generateRecoveryCodes коды вида xxxxx-xxxxx по 50 случайных бит; в базе хранятся только хэши
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/kkboranbay/task-service/internal/totp"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TwoFactorServiceTestSuite struct {
+	suite.Suite
+	service  *TwoFactorService
+	mockRepo *mocks.MockTwoFactorRepository
+	ctx      context.Context
+	now      time.Time
+	secret   string
+}
+
+func (suite *TwoFactorServiceTestSuite) SetupTest() {
+	suite.mockRepo = new(mocks.MockTwoFactorRepository)
+	logger := zerolog.Nop()
+	suite.service = NewTwoFactorService(suite.mockRepo, config.AuthConfig{TwoFactorIssuer: "Task Service"}, &logger)
+	suite.now = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	suite.service.now = func() time.Time { return suite.now }
+	suite.ctx = context.Background()
+
+	var err error
+	suite.secret, err = totp.GenerateSecret()
+	require.NoError(suite.T(), err)
+}
+
+func (suite *TwoFactorServiceTestSuite) code(offset int64) string {
+	code, err := totp.Code(suite.secret, totp.Step(suite.now)+offset)
+	require.NoError(suite.T(), err)
+	return code
+}
+
+func (suite *TwoFactorServiceTestSuite) enabled() *model.TwoFactor {
+	enabledAt := suite.now.Add(-time.Hour)
+	return &model.TwoFactor{UserID: 1, Secret: suite.secret, LastUsedStep: totp.Step(enabledAt), EnabledAt: &enabledAt}
+}
+
+func (suite *TwoFactorServiceTestSuite) TestEnroll() {
+	suite.mockRepo.On("SavePending", suite.ctx, int64(1), mock.AnythingOfType("string")).Return(nil).Once()
+
+	enrollment, err := suite.service.Enroll(suite.ctx, &model.User{ID: 1, Username: "jane"})
+	require.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), enrollment.Secret)
+	assert.True(suite.T(), strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/Task%20Service:jane?"))
+
+	suite.mockRepo.On("SavePending", suite.ctx, int64(2), mock.AnythingOfType("string")).Return(repository.ErrAlreadyExists).Once()
+	_, err = suite.service.Enroll(suite.ctx, &model.User{ID: 2, Username: "john"})
+	assert.ErrorIs(suite.T(), err, ErrTwoFactorEnabled)
+}
+
+func (suite *TwoFactorServiceTestSuite) TestConfirm() {
+	pending := &model.TwoFactor{UserID: 1, Secret: suite.secret}
+	suite.mockRepo.On("Get", suite.ctx, int64(1)).Return(pending, nil)
+
+	_, err := suite.service.Confirm(suite.ctx, 1, "000000")
+	assert.ErrorIs(suite.T(), err, ErrInvalidTwoFactorCode)
+
+	var hashes []string
+	suite.mockRepo.On("Enable", suite.ctx, int64(1), totp.Step(suite.now), mock.Anything).
+		Run(func(args mock.Arguments) { hashes = args.Get(3).([]string) }).
+		Return(nil).Once()
+
+	codes, err := suite.service.Confirm(suite.ctx, 1, suite.code(0))
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), codes, recoveryCodeCount)
+	require.Len(suite.T(), hashes, recoveryCodeCount)
+
+	// в базу попадают только хэши
+	for i, code := range codes {
+		assert.NotContains(suite.T(), hashes, code)
+		assert.Equal(suite.T(), hashToken(normalizeRecoveryCode(code)), hashes[i])
+	}
+}
+
+func (suite *TwoFactorServiceTestSuite) TestVerifyRejectsReplay() {
+	suite.mockRepo.On("Get", suite.ctx, int64(1)).Return(suite.enabled(), nil)
+	suite.mockRepo.On("ConsumeStep", suite.ctx, int64(1), totp.Step(suite.now)).Return(true, nil).Once()
+	suite.mockRepo.On("ConsumeStep", suite.ctx, int64(1), totp.Step(suite.now)).Return(false, nil).Once()
+
+	assert.NoError(suite.T(), suite.service.Verify(suite.ctx, 1, suite.code(0)))
+	assert.ErrorIs(suite.T(), suite.service.Verify(suite.ctx, 1, suite.code(0)), ErrInvalidTwoFactorCode)
+}
+
+func (suite *TwoFactorServiceTestSuite) TestVerifyRecoveryCode() {
+	suite.mockRepo.On("Get", suite.ctx, int64(1)).Return(suite.enabled(), nil)
+	suite.mockRepo.On("ConsumeRecoveryCode", suite.ctx, int64(1), hashToken("abcdefghij")).Return(true, nil).Once()
+	suite.mockRepo.On("ConsumeRecoveryCode", suite.ctx, int64(1), hashToken("abcdefghij")).Return(false, nil).Once()
+
+	assert.NoError(suite.T(), suite.service.Verify(suite.ctx, 1, "ABCDE-FGHIJ"))
+	assert.ErrorIs(suite.T(), suite.service.Verify(suite.ctx, 1, "abcde-fghij"), ErrInvalidTwoFactorCode)
+}
+
+func (suite *TwoFactorServiceTestSuite) TestVerifyNotEnrolled() {
+	suite.mockRepo.On("Get", suite.ctx, int64(1)).Return(nil, repository.ErrNotFound).Once()
+
+	assert.ErrorIs(suite.T(), suite.service.Verify(suite.ctx, 1, "123456"), ErrTwoFactorNotEnrolled)
+}
+
+func (suite *TwoFactorServiceTestSuite) TestDisable() {
+	suite.mockRepo.On("Get", suite.ctx, int64(1)).Return(suite.enabled(), nil)
+	suite.mockRepo.On("ConsumeStep", suite.ctx, int64(1), totp.Step(suite.now)).Return(true, nil).Once()
+	suite.mockRepo.On("Delete", suite.ctx, int64(1)).Return(nil).Once()
+
+	assert.NoError(suite.T(), suite.service.Disable(suite.ctx, 1, suite.code(0)))
+
+	suite.service.required = true
+	assert.ErrorIs(suite.T(), suite.service.Disable(suite.ctx, 1, suite.code(0)), ErrTwoFactorRequired)
+
+	suite.mockRepo.AssertExpectations(suite.T())
+}
+
+func TestTwoFactorServiceSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorServiceTestSuite))
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("неверные учетные данные")
+	ErrUserNotFound       = errors.New("пользователь не найден")
+	ErrUserExists         = errors.New("пользователь с таким именем уже существует")
+	ErrInvalidUser        = errors.New("неверные данные пользователя")
+)
+
+// minPasswordLength минимальная длина пароля локального пользователя
+const minPasswordLength = 8
+
+// bootstrapAdminID учетная запись admin из миграции 000003, создаваемая без пароля
+const bootstrapAdminID int64 = 1
+
+// dummyPasswordHash сравнивается с паролем, когда пользователя нет, чтобы время ответа
+// не выдавало существование учетной записи
+var dummyPasswordHash = []byte("$2a$10$GCC./P84VFBl33u4B0QLoOSSanof2vJgCMd8mT6SOJVsCecBGfF6.")
+
+type UserService struct {
+	repo repository.UserRepository
+	log  *zerolog.Logger
+}
+
+func NewUserService(repo repository.UserRepository, log *zerolog.Logger) *UserService {
+	return &UserService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Authenticate проверяет пароль пользователя и возвращает ErrInvalidCredentials при любой неудаче
+func (s *UserService) Authenticate(ctx context.Context, username, password string) (*model.User, error) {
+	user, err := s.repo.GetByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		s.log.Error().Err(err).Str("username", username).Msg("ошибка получения пользователя")
+		return nil, fmt.Errorf("не удалось проверить учетные данные: %w", err)
+	}
+
+	// у пользователей, созданных через OIDC, нет пароля
+	if user.PasswordHash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// CreateUser создает локального пользователя с паролем
+func (s *UserService) CreateUser(ctx context.Context, username, password string, role model.Role) (*model.User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || len(username) > 255 {
+		return nil, fmt.Errorf("%w: имя должно содержать от 1 до 255 символов", ErrInvalidUser)
+	}
+	if len(password) < minPasswordLength {
+		return nil, fmt.Errorf("%w: пароль короче %d символов", ErrInvalidUser, minPasswordLength)
+	}
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: неизвестная роль %q", ErrInvalidUser, role)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось захешировать пароль: %w", err)
+	}
+
+	user, err := s.repo.Create(ctx, model.User{Username: username, PasswordHash: string(hash), Role: role})
+	if err != nil {
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			return nil, ErrUserExists
+		}
+		s.log.Error().Err(err).Str("username", username).Msg("ошибка создания пользователя")
+		return nil, fmt.Errorf("не удалось создать пользователя: %w", err)
+	}
+
+	s.log.Info().Int64("user_id", user.ID).Str("username", user.Username).Str("role", string(user.Role)).Msg("создан пользователь")
+	return user, nil
+}
+
+// BootstrapAdmin задает пароль учетной записи admin, если его еще нет. Уже заданный пароль
+// не меняется, поэтому ADMIN_PASSWORD можно оставить в окружении после первого запуска
+func (s *UserService) BootstrapAdmin(ctx context.Context, password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: пароль короче %d символов", ErrInvalidUser, minPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("не удалось захешировать пароль: %w", err)
+	}
+
+	set, err := s.repo.SetPasswordIfEmpty(ctx, bootstrapAdminID, string(hash))
+	if err != nil {
+		s.log.Error().Err(err).Msg("ошибка установки пароля администратора")
+		return fmt.Errorf("не удалось установить пароль администратора: %w", err)
+	}
+	if set {
+		s.log.Info().Int64("user_id", bootstrapAdminID).Msg("пароль администратора установлен из ADMIN_PASSWORD")
+	}
+	return nil
+}
+
+// ProvisionExternalUser возвращает локального пользователя, связанного с учетной записью издателя,
+// и создает его с ролью user при первом входе. Существующие локальные учетные записи по имени
+// не связываются: совпадение имени у издателя не доказывает владение ими
+func (s *UserService) ProvisionExternalUser(ctx context.Context, identity model.ExternalIdentity) (*model.User, error) {
+	user, err := s.repo.GetByExternalID(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		s.log.Error().Err(err).Str("subject", identity.Subject).Msg("ошибка поиска внешнего пользователя")
+		return nil, fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+
+	for _, username := range externalUsernames(identity) {
+		user, err = s.repo.CreateExternal(ctx, model.User{Username: username, Role: model.RoleUser}, identity.Issuer, identity.Subject)
+		if err == nil {
+			s.log.Info().
+				Int64("user_id", user.ID).
+				Str("username", user.Username).
+				Str("issuer", identity.Issuer).
+				Msg("создан пользователь при первом входе через OIDC")
+			return user, nil
+		}
+		if !errors.Is(err, repository.ErrAlreadyExists) {
+			s.log.Error().Err(err).Str("subject", identity.Subject).Msg("ошибка создания внешнего пользователя")
+			return nil, fmt.Errorf("не удалось создать пользователя: %w", err)
+		}
+
+		// конфликт мог случиться из-за параллельного первого входа той же учетной записи
+		if user, err := s.repo.GetByExternalID(ctx, identity.Issuer, identity.Subject); err == nil {
+			return user, nil
+		}
+	}
+
+	return nil, fmt.Errorf("не удалось подобрать свободное имя для пользователя %s", identity.Subject)
+}
+
+// externalUsernames варианты имени нового пользователя; последний уникален для пары issuer/subject
+func externalUsernames(identity model.ExternalIdentity) []string {
+	sum := sha256.Sum256([]byte(identity.Issuer + "\x00" + identity.Subject))
+	fallback := "oidc-" + hex.EncodeToString(sum[:6])
+
+	var usernames []string
+	for _, candidate := range []string{identity.PreferredUsername, identity.Email} {
+		if candidate != "" && len(candidate) <= 255 {
+			usernames = append(usernames, candidate)
+		}
+	}
+	return append(usernames, fallback)
+}
+
+func (s *UserService) GetUser(ctx context.Context, id int64) (*model.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.log.Error().Err(err).Int64("user_id", id).Msg("ошибка получения пользователя")
+		return nil, fmt.Errorf("не удалось получить пользователя: %w", err)
+	}
+	return user, nil
+}
+
+func (s *UserService) ListUsers(ctx context.Context, page, pageSize int) (*model.UserListResponse, error) {
+	page, pageSize = NormalizePage(page, pageSize)
+
+	resp, err := s.repo.List(ctx, pageSize, (page-1)*pageSize)
+	if err != nil {
+		s.log.Error().Err(err).Msg("ошибка получения списка пользователей")
+		return nil, fmt.Errorf("не удалось получить список пользователей: %w", err)
+	}
+	return resp, nil
+}
+
+// NormalizePage приводит номер и размер страницы к допустимым значениям списков:
+// страницы нумеруются с 1, размер от 1 до 100, по умолчанию 10
+func NormalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	return page, pageSize
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/kkboranbay/task-service/internal/mocks"
+	"github.com/kkboranbay/task-service/internal/model"
+	"github.com/kkboranbay/task-service/internal/repository"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+)
+
+func TestAuthenticate(t *testing.T) {
+	admin := &model.User{
+		ID:           1,
+		Username:     "admin",
+		PasswordHash: string(dummyPasswordHash),
+		Role:         model.RoleAdmin,
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		repoUser *model.User
+		repoErr  error
+		wantErr  error
+	}{
+		{name: "valid_password", username: "admin", password: "admin", repoUser: admin},
+		{name: "wrong_password", username: "admin", password: "wrong", repoUser: admin, wantErr: ErrInvalidCredentials},
+		{name: "unknown_user", username: "ghost", password: "admin", repoErr: repository.ErrNotFound, wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.MockUserRepository)
+			logger := zerolog.Nop()
+			svc := NewUserService(repo, &logger)
+
+			if tt.repoUser != nil {
+				repo.On("GetByUsername", mock.Anything, tt.username).Return(tt.repoUser, nil).Once()
+			} else {
+				repo.On("GetByUsername", mock.Anything, tt.username).Return(nil, tt.repoErr).Once()
+			}
+
+			user, err := svc.Authenticate(context.Background(), tt.username, tt.password)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				assert.Nil(t, user)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, admin.ID, user.ID)
+		})
+	}
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	logger := zerolog.Nop()
+
+	t.Run("sets_password", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		svc := NewUserService(repo, &logger)
+
+		repo.On("SetPasswordIfEmpty", mock.Anything, bootstrapAdminID, mock.MatchedBy(func(hash string) bool {
+			return bcrypt.CompareHashAndPassword([]byte(hash), []byte("s3cret-admin")) == nil
+		})).Return(true, nil).Once()
+
+		require.NoError(t, svc.BootstrapAdmin(context.Background(), "s3cret-admin"))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("short_password", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		svc := NewUserService(repo, &logger)
+
+		err := svc.BootstrapAdmin(context.Background(), "admin")
+		assert.True(t, errors.Is(err, ErrInvalidUser))
+		repo.AssertNotCalled(t, "SetPasswordIfEmpty", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestProvisionExternalUser(t *testing.T) {
+	identity := model.ExternalIdentity{
+		Issuer:            "https://idp.example.com",
+		Subject:           "sub-1",
+		PreferredUsername: "admin",
+		Email:             "admin@example.com",
+	}
+	newUser := func(username string) model.User {
+		return model.User{Username: username, Role: model.RoleUser}
+	}
+
+	t.Run("existing_identity", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		logger := zerolog.Nop()
+		svc := NewUserService(repo, &logger)
+
+		repo.On("GetByExternalID", mock.Anything, identity.Issuer, identity.Subject).
+			Return(&model.User{ID: 7, Username: "admin-idp"}, nil).Once()
+
+		user, err := svc.ProvisionExternalUser(context.Background(), identity)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), user.ID)
+		repo.AssertNotCalled(t, "CreateExternal", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("taken_usernames_are_not_linked", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		logger := zerolog.Nop()
+		svc := NewUserService(repo, &logger)
+
+		repo.On("GetByExternalID", mock.Anything, identity.Issuer, identity.Subject).
+			Return(nil, repository.ErrNotFound)
+		repo.On("CreateExternal", mock.Anything, newUser("admin"), identity.Issuer, identity.Subject).
+			Return(nil, repository.ErrAlreadyExists).Once()
+		repo.On("CreateExternal", mock.Anything, newUser("admin@example.com"), identity.Issuer, identity.Subject).
+			Return(nil, repository.ErrAlreadyExists).Once()
+		repo.On("CreateExternal", mock.Anything, mock.MatchedBy(func(u model.User) bool {
+			return strings.HasPrefix(u.Username, "oidc-") && u.Role == model.RoleUser
+		}), identity.Issuer, identity.Subject).
+			Return(&model.User{ID: 8, Username: "oidc-1", Role: model.RoleUser}, nil).Once()
+
+		user, err := svc.ProvisionExternalUser(context.Background(), identity)
+		require.NoError(t, err)
+		assert.Equal(t, int64(8), user.ID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("concurrent_first_login", func(t *testing.T) {
+		repo := new(mocks.MockUserRepository)
+		logger := zerolog.Nop()
+		svc := NewUserService(repo, &logger)
+
+		repo.On("GetByExternalID", mock.Anything, identity.Issuer, identity.Subject).
+			Return(nil, repository.ErrNotFound).Once()
+		repo.On("CreateExternal", mock.Anything, newUser("admin"), identity.Issuer, identity.Subject).
+			Return(nil, repository.ErrAlreadyExists).Once()
+		repo.On("GetByExternalID", mock.Anything, identity.Issuer, identity.Subject).
+			Return(&model.User{ID: 9}, nil).Once()
+
+		user, err := svc.ProvisionExternalUser(context.Background(), identity)
+		require.NoError(t, err)
+		assert.Equal(t, int64(9), user.ID)
+	})
+}
+
+func TestAuthenticateExternalUserWithoutPassword(t *testing.T) {
+	repo := new(mocks.MockUserRepository)
+	logger := zerolog.Nop()
+	svc := NewUserService(repo, &logger)
+
+	repo.On("GetByUsername", mock.Anything, "jane").Return(&model.User{ID: 5, Username: "jane"}, nil).Once()
+
+	_, err := svc.Authenticate(context.Background(), "jane", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestCreateUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		role     model.Role
+		repoErr  error
+		wantErr  error
+	}{
+		{name: "created", username: " alice ", password: "correct-horse", role: model.RoleUser},
+		{name: "short_password", username: "alice", password: "short", role: model.RoleUser, wantErr: ErrInvalidUser},
+		{name: "empty_username", username: "  ", password: "correct-horse", role: model.RoleUser, wantErr: ErrInvalidUser},
+		{name: "unknown_role", username: "alice", password: "correct-horse", role: "owner", wantErr: ErrInvalidUser},
+		{name: "duplicate", username: "alice", password: "correct-horse", role: model.RoleAdmin, repoErr: repository.ErrAlreadyExists, wantErr: ErrUserExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.MockUserRepository)
+			logger := zerolog.Nop()
+			svc := NewUserService(repo, &logger)
+
+			var stored model.User
+			call := repo.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				stored = args.Get(1).(model.User)
+			}).Maybe()
+			if tt.repoErr != nil {
+				call.Return(nil, tt.repoErr)
+			} else {
+				call.Return(&model.User{ID: 42, Username: "alice", Role: tt.role}, nil)
+			}
+
+			user, err := svc.CreateUser(context.Background(), tt.username, tt.password, tt.role)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, user)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(42), user.ID)
+			assert.Equal(t, "alice", stored.Username)
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte(tt.password)))
+		})
+	}
+}
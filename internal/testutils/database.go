@@ -3,16 +3,12 @@ package testutils
 import (
 	"context"
 	"fmt"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kkboranbay/task-service/internal/config"
 	pg "github.com/kkboranbay/task-service/pkg/postgres"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"os"
-	"path/filepath"
-	"runtime"
 	"testing"
 	"time"
 )
@@ -52,7 +48,7 @@ func NewTestDB(t *testing.T) *TestDB {
 	testPool, err := pg.NewPool(ctx, cfg)
 	require.NoError(t, err, "Failed to connect to test database")
 
-	err = runMigrations(ctx, cfg)
+	err = runMigrations(ctx, testPool)
 	require.NoError(t, err, "Failed to run migrations")
 
 	return &TestDB{
@@ -100,35 +96,14 @@ func (tdb *TestDB) Truncate(t *testing.T) {
 	t.Helper()
 
 	ctx := context.Background()
-	_, err := tdb.Pool.Exec(ctx, "TRUNCATE TABLE tasks RESTART IDENTITY CASCADE")
+	_, err := tdb.Pool.Exec(ctx, "TRUNCATE TABLE tasks, calendar_tokens, audit_log, access_tokens, user_totp, user_recovery_codes RESTART IDENTITY CASCADE")
 	require.NoError(t, err, "Failed to truncate tables")
 }
 
-func runMigrations(ctx context.Context, cfg config.DatabaseConfig) error {
-	_, filename, _, _ := runtime.Caller(0) // путь до текущего файла (database.go)
-	basePath := filepath.Join(filepath.Dir(filename), "../../migrations")
-	sourceURL := "file://" + basePath
-
-	connStr := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
-	)
-
-	m, err := migrate.New(sourceURL, connStr)
-	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
-	}
-	defer m.Close()
-
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run migrations: %w", err)
-	}
-
-	return nil
+// runMigrations применяет встроенные миграции тем же кодом, что и сервис
+func runMigrations(ctx context.Context, pool *pgxpool.Pool) error {
+	log := zerolog.Nop()
+	return pg.NewMigrator(pool, &log).Up(ctx)
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
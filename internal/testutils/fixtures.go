@@ -51,10 +51,13 @@ func UpdateTaskRequestFixture(overrides ...func(*model.UpdateTaskRequest)) model
 	return req
 }
 
+// AdminPassword пароль, который тесты задают учетной записи admin через BootstrapAdmin
+const AdminPassword = "admin-password"
+
 func LoginRequestFixture(overrides ...func(*model.LoginRequest)) model.LoginRequest {
 	req := model.LoginRequest{
 		Username: "admin",
-		Password: "admin",
+		Password: AdminPassword,
 	}
 
 	for _, override := range overrides {
@@ -0,0 +1,79 @@
+// Package tracing распределенная трассировка OpenTelemetry: настройка экспорта по OTLP,
+// спаны запросов к базе и trace_id в записях журнала
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName имя, под которым сервис создает собственные спаны
+const instrumentationName = "github.com/kkboranbay/task-service"
+
+// Setup устанавливает глобальные провайдер трассировок и W3C propagator. Возвращает
+// функцию, которая выгружает накопленные спаны при остановке сервиса
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания OTLP экспортера: %w", err)
+	}
+
+	provider := NewTracerProvider(cfg, sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewTracerProvider провайдер с именем сервиса и долей сэмплирования из конфигурации.
+// Решение вышестоящего сервиса о сэмплировании соблюдается. В тестах экспортер
+// подключается через sdktrace.WithSyncer(tracetest.NewInMemoryExporter())
+func NewTracerProvider(cfg config.TracingConfig, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName))),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}, opts...)
+	return sdktrace.NewTracerProvider(opts...)
+}
+
+// Tracer берется из глобального провайдера при каждом вызове, чтобы подхватить провайдер,
+// установленный после создания компонентов
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// RecordError отмечает спан как завершившийся ошибкой
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// LogHook добавляет trace_id и span_id в записи журнала, созданные с контекстом через Ctx(ctx)
+var LogHook = zerolog.HookFunc(func(e *zerolog.Event, _ zerolog.Level, _ string) {
+	spanContext := trace.SpanContextFromContext(e.GetCtx())
+	if !spanContext.IsValid() {
+		return
+	}
+	e.Str("trace_id", spanContext.TraceID().String()).Str("span_id", spanContext.SpanID().String())
+})
@@ -0,0 +1,104 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupTestProvider(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	_, err := Setup(context.Background(), config.TracingConfig{})
+	require.NoError(t, err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewTracerProvider(config.TracingConfig{ServiceName: "test", SampleRatio: 1}, sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func TestHTTPTraceparentPropagation(t *testing.T) {
+	exporter := setupTestProvider(t)
+	gin.SetMode(gin.TestMode)
+
+	var logs bytes.Buffer
+	log := zerolog.New(&logs).Hook(LogHook)
+
+	router := gin.New()
+	router.Use(otelgin.Middleware("test"))
+	router.GET("/api/v1/tasks/:id", func(c *gin.Context) {
+		_, span := Tracer().Start(c.Request.Context(), "TaskService.GetTaskByID")
+		defer span.End()
+		log.Info().Ctx(c.Request.Context()).Msg("запрос")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	service, server := spans[0], spans[1]
+	assert.Equal(t, "/api/v1/tasks/:id", server.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.Equal(t, server.SpanContext.SpanID(), service.Parent.SpanID())
+
+	assert.Contains(t, logs.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+	assert.Contains(t, logs.String(), `"span_id":"`+server.SpanContext.SpanID().String()+`"`)
+}
+
+func TestLogHookWithoutSpan(t *testing.T) {
+	var logs bytes.Buffer
+	log := zerolog.New(&logs).Hook(LogHook)
+
+	log.Info().Msg("без контекста")
+	log.Info().Ctx(context.Background()).Msg("без спана")
+
+	assert.NotContains(t, logs.String(), "trace_id")
+}
+
+func TestQueryTracer(t *testing.T) {
+	exporter := setupTestProvider(t)
+	tracer := QueryTracer{}
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+		SQL:  "\n\t\tSELECT id, title FROM tasks WHERE id = $1",
+		Args: []interface{}{int64(42)},
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "delete from tasks"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("connection reset")})
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "SELECT", spans[0].Name)
+	assert.Contains(t, spans[0].Attributes, semconv.DBSystemPostgreSQL)
+	assert.Contains(t, spans[0].Attributes, semconv.DBQueryText("\n\t\tSELECT id, title FROM tasks WHERE id = $1"))
+	assert.Equal(t, codes.Unset, spans[0].Status.Code)
+
+	assert.Equal(t, "DELETE", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+}
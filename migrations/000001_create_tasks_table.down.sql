@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS tasks;
+DROP TYPE IF EXISTS task_status;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    username VARCHAR(255) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'user',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- учетная запись admin, которую раньше проверял AuthHandler; user_id 1 сохраняется за ней,
+-- чтобы существующие задачи остались у владельца. Пароля нет: он задается при запуске
+-- сервиса из ADMIN_PASSWORD, до этого войти под admin нельзя
+INSERT INTO users (id, username, password_hash, role)
+VALUES (1, 'admin', '', 'admin')
+ON CONFLICT (id) DO NOTHING;
+
+SELECT setval('users_id_seq', GREATEST((SELECT MAX(id) FROM users), 1));
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    target_user_id BIGINT,
+    details TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
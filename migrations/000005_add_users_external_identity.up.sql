@@ -0,0 +1,7 @@
+-- пользователи, созданные при первом входе через OIDC, связываются с учетной записью издателя
+-- по паре (issuer, subject); пароля у них нет
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_identity ON users(oidc_issuer, oidc_subject)
+    WHERE oidc_issuer IS NOT NULL;
@@ -0,0 +1,2 @@
+-- общеизвестный пароль не восстанавливается
+SELECT 1;
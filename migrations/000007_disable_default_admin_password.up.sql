@@ -0,0 +1,5 @@
+-- базы, где 000003 уже выдала admin общеизвестный пароль admin: пароль сбрасывается,
+-- и сервис установит новый из ADMIN_PASSWORD при следующем запуске
+UPDATE users
+SET password_hash = ''
+WHERE id = 1 AND password_hash = '$2a$10$GCC./P84VFBl33u4B0QLoOSSanof2vJgCMd8mT6SOJVsCecBGfF6.';
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestLatest(t *testing.T) {
+	ups, err := fs.Glob(FS, "*.up.sql")
+	require.NoError(t, err)
+
+	// номера миграций идут подряд с 1, поэтому последняя равна их количеству
+	latest, err := Latest()
+	require.NoError(t, err)
+	assert.Equal(t, uint(len(ups)), latest)
+}
+
+func TestEveryMigrationHasDown(t *testing.T) {
+	ups, err := fs.Glob(FS, "*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, ups)
+
+	for _, up := range ups {
+		down := strings.TrimSuffix(up, ".up.sql") + ".down.sql"
+		_, err := fs.Stat(FS, down)
+		assert.NoError(t, err, "нет отката для %s", up)
+	}
+}
@@ -1,10 +1,15 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/tracing"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -19,7 +24,36 @@ func SetupLogger(cfg config.LoggerConfig) {
 	}
 	zerolog.SetGlobalLevel(level)
 
-	output := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	log.Logger = New(cfg)
+}
+
+// New логгер с форматом, приемником, сэмплированием и скрытием секретов из конфигурации;
+// глобальные настройки zerolog не меняет
+func New(cfg config.LoggerConfig) zerolog.Logger {
+	var output io.Writer = os.Stdout
+	if cfg.Output == config.LogOutputFile {
+		output = &lumberjack.Logger{
+			Filename:   cfg.File.Path,
+			MaxSize:    cfg.File.MaxSizeMB,
+			MaxBackups: cfg.File.MaxBackups,
+			MaxAge:     cfg.File.MaxAgeDays,
+			Compress:   cfg.File.Compress,
+		}
+	}
+	if cfg.Format != config.LogFormatJSON {
+		output = consoleWriter(output)
+	}
+
+	logger := zerolog.New(redactWriter{next: output}).Hook(tracing.LogHook).With().Timestamp().Caller().Logger()
+	if sampler := newSampler(cfg.Sampling); sampler != nil {
+		logger = logger.Sample(sampler)
+	}
+	return logger
+}
+
+// consoleWriter человекочитаемый формат для локального запуска
+func consoleWriter(out io.Writer) zerolog.ConsoleWriter {
+	output := zerolog.ConsoleWriter{Out: out, TimeFormat: time.RFC3339}
 
 	output.FormatLevel = func(i interface{}) string {
 		return strings.ToUpper(fmt.Sprintf("| %-6s|", i))
@@ -34,7 +68,27 @@ func SetupLogger(cfg config.LoggerConfig) {
 		return fmt.Sprintf("%s", i)
 	}
 
-	log.Logger = zerolog.New(output).With().Timestamp().Caller().Logger()
+	return output
+}
+
+// newSampler сэмплирует только debug и info; nil, если сэмплирование выключено
+func newSampler(cfg config.LogSamplingConfig) zerolog.Sampler {
+	levelSampler := func(every int) zerolog.Sampler {
+		if every <= 1 {
+			return nil
+		}
+		return &zerolog.BurstSampler{
+			Burst:       uint32(cfg.Burst),
+			Period:      cfg.Period,
+			NextSampler: &zerolog.BasicSampler{N: uint32(every)},
+		}
+	}
+
+	info, debug := levelSampler(cfg.InfoEvery), levelSampler(cfg.DebugEvery)
+	if info == nil && debug == nil {
+		return nil
+	}
+	return zerolog.LevelSampler{InfoSampler: info, DebugSampler: debug}
 }
 
 // глобальный логгер для удобства
@@ -42,6 +96,23 @@ func L() *zerolog.Logger {
 	return &log.Logger
 }
 
+// FromContext логгер запроса, сохраненный в контексте middleware, или fallback вне запроса
+func FromContext(ctx context.Context, fallback *zerolog.Logger) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return fallback
+}
+
+// Error событие об ошибке err. Отмена запроса клиентом (context.Canceled) сбоем сервиса
+// не считается и пишется с уровнем info
+func Error(l *zerolog.Logger, err error) *zerolog.Event {
+	if errors.Is(err, context.Canceled) {
+		return l.Info().Err(err)
+	}
+	return l.Error().Err(err)
+}
+
 // логгер с добавленным полем
 func WithField(key string, value interface{}) *zerolog.Logger {
 	logger := log.With().Interface(key, value).Logger()
@@ -63,3 +134,13 @@ func WithError(err error) *zerolog.Logger {
 	logger := log.With().Err(err).Logger()
 	return &logger
 }
+
+// SetLevel меняет уровень логирования на лету; неизвестный уровень игнорируется
+func SetLevel(level string) error {
+	parsed, err := zerolog.ParseLevel(strings.ToLower(level))
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(parsed)
+	return nil
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/kkboranbay/task-service/internal/model"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// redacted заменяет значение скрытого поля
+const redacted = "[REDACTED]"
+
+// sensitiveFields поля, значения которых не пишутся в журнал ни при каких условиях
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"password_hash": true,
+	"secret":        true,
+	"client_secret": true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"authorization": true,
+	"cookie":        true,
+	"api_key":       true,
+	"x-api-key":     true,
+	"recovery_code": true,
+	"code_verifier": true,
+}
+
+// secretPattern JWT и персональные токены доступа внутри строк, например в тексте ошибки
+var secretPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*|` + model.AccessTokenPrefix + `[A-Za-z0-9_-]+`)
+
+// redactWriter скрывает секреты в записях zerolog до форматирования и записи. Записи
+// без подозрительных полей и подстрок передаются без разбора
+type redactWriter struct {
+	next io.Writer
+}
+
+func (w redactWriter) Write(p []byte) (int, error) {
+	if !mayContainSecret(p) {
+		return w.next.Write(p)
+	}
+
+	var entry map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(p))
+	decoder.UseNumber()
+	if err := decoder.Decode(&entry); err != nil {
+		// не JSON: zerolog так не пишет, но секреты в строке все равно скрываются
+		return w.write(p, secretPattern.ReplaceAll(p, []byte(redacted)))
+	}
+
+	for key, value := range entry {
+		entry[key] = redactValue(key, value)
+	}
+
+	redactedEntry, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	return w.write(p, append(redactedEntry, '\n'))
+}
+
+// write сообщает zerolog длину исходной записи, иначе он сочтет запись неполной
+func (w redactWriter) write(original, redactedEntry []byte) (int, error) {
+	if _, err := w.next.Write(redactedEntry); err != nil {
+		return 0, err
+	}
+	return len(original), nil
+}
+
+func redactValue(key string, value interface{}) interface{} {
+	if sensitiveFields[strings.ToLower(key)] {
+		return redacted
+	}
+
+	switch v := value.(type) {
+	case string:
+		return secretPattern.ReplaceAllString(v, redacted)
+	case map[string]interface{}:
+		for nestedKey, nestedValue := range v {
+			v[nestedKey] = redactValue(nestedKey, nestedValue)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue("", item)
+		}
+	}
+	return value
+}
+
+func mayContainSecret(p []byte) bool {
+	if secretPattern.Match(p) {
+		return true
+	}
+	lower := bytes.ToLower(p)
+	for field := range sensitiveFields {
+		if bytes.Contains(lower, []byte(`"`+field+`":`)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	migratepg "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/kkboranbay/task-service/migrations"
+	"github.com/rs/zerolog"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// migrationLockID ключ advisory lock, под которым реплики применяют миграции по очереди
+const migrationLockID int64 = 4_270_318_561
+
+// undefinedTable код ошибки Postgres для отсутствующей таблицы: миграции еще не применялись
+const undefinedTable = "42P01"
+
+// MigrationStatus версия схемы в базе и последняя встроенная в сервис миграция
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+}
+
+// Migrator применяет миграции, встроенные в бинарный файл, с настройками подключения пула сервиса
+type Migrator struct {
+	pool *pgxpool.Pool
+	log  *zerolog.Logger
+}
+
+func NewMigrator(pool *pgxpool.Pool, log *zerolog.Logger) *Migrator {
+	return &Migrator{pool: pool, log: log}
+}
+
+// Up применяет все новые миграции
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(mg *migrate.Migrate) error {
+		if err := mg.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("ошибка применения миграций: %w", err)
+		}
+		return nil
+	})
+}
+
+// Down откатывает steps последних миграций
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("число откатываемых миграций должно быть положительным, получено %d", steps)
+	}
+	return m.withLock(ctx, func(mg *migrate.Migrate) error {
+		if err := mg.Steps(-steps); err != nil {
+			return fmt.Errorf("ошибка отката миграций: %w", err)
+		}
+		return nil
+	})
+}
+
+// Force записывает версию без выполнения SQL и снимает признак dirty после ручного исправления схемы
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	return m.withLock(ctx, func(mg *migrate.Migrate) error {
+		if err := mg.Force(version); err != nil {
+			return fmt.Errorf("ошибка установки версии схемы: %w", err)
+		}
+		return nil
+	})
+}
+
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	latest, err := migrations.Latest()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Latest: latest}
+	err = m.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&status.Version, &status.Dirty)
+
+	var pgErr *pgconn.PgError
+	switch {
+	case err == nil, errors.Is(err, pgx.ErrNoRows):
+		return status, nil
+	case errors.As(err, &pgErr) && pgErr.Code == undefinedTable:
+		return status, nil
+	default:
+		return nil, fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+}
+
+// MigrateUsage синтаксис подкоманды migrate
+const MigrateUsage = "migrate up | down [N] | status | force VERSION"
+
+// Run выполняет подкоманду migrate из аргументов командной строки; down без числа
+// откатывает одну миграцию, status пишет версию схемы в out
+func (m *Migrator) Run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("не указана команда: %s", MigrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("неверное число миграций %q: %w", args[1], err)
+			}
+			steps = n
+		}
+		return m.Down(ctx, steps)
+	case "force":
+		if len(args) < 2 {
+			return fmt.Errorf("не указана версия: %s", MigrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("неверная версия %q: %w", args[1], err)
+		}
+		return m.Force(ctx, version)
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "version: %d\ndirty: %t\nlatest: %d\n", status.Version, status.Dirty, status.Latest)
+		return err
+	default:
+		return fmt.Errorf("неизвестная команда %q: %s", args[0], MigrateUsage)
+	}
+}
+
+// CheckSchema схема не должна отставать от сервиса или быть в незавершенной миграции. Более
+// новая схема допустима: во время выкладки старые реплики работают со схемой новой версии
+func (m *Migrator) CheckSchema(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("миграция %d применена не полностью", status.Version)
+	}
+	if status.Version < status.Latest {
+		return fmt.Errorf("версия схемы %d, ожидается %d", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// withLock держит advisory lock на отдельном соединении, пока migrate работает через свое.
+// Ожидание блокировки не ограничено statement_timeout: другая реплика может мигрировать долго
+func (m *Migrator) withLock(ctx context.Context, fn func(mg *migrate.Migrate) error) error {
+	db := m.openDB()
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("ошибка получения соединения для миграций: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("ошибка блокировки миграций: %w", err)
+	}
+	defer func() {
+		// блокировка привязана к сессии, поэтому освобождается даже при отмене ctx
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+			m.log.Error().Err(err).Msg("ошибка снятия блокировки миграций")
+		}
+	}()
+
+	mg, err := m.newMigrate()
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	return fn(mg)
+}
+
+func (m *Migrator) newMigrate() (*migrate.Migrate, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения встроенных миграций: %w", err)
+	}
+
+	db := m.openDB()
+	driver, err := migratepg.WithInstance(db, &migratepg.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ошибка подключения migrate к базе данных: %w", err)
+	}
+
+	mg, err := migrate.NewWithInstance("iofs", source, "postgres", driver)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка инициализации migrate: %w", err)
+	}
+	mg.Log = migrateLogger{log: m.log}
+	return mg, nil
+}
+
+// openDB соединения для migrate с настройками пула, но без statement_timeout: миграции больших
+// таблиц выполняются дольше обычных запросов. migrate закрывает их в Close
+func (m *Migrator) openDB() *sql.DB {
+	poolConfig := m.pool.Config()
+	connConfig := poolConfig.ConnConfig
+	connConfig.RuntimeParams["statement_timeout"] = "0"
+
+	var opts []stdlib.OptionOpenDB
+	if poolConfig.BeforeConnect != nil {
+		opts = append(opts, stdlib.OptionBeforeConnect(poolConfig.BeforeConnect))
+	}
+	return stdlib.OpenDB(*connConfig, opts...)
+}
+
+// migrateLogger пишет сообщения migrate о применяемых миграциях в журнал сервиса
+type migrateLogger struct {
+	log *zerolog.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...interface{}) {
+	l.log.Info().Msg(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l migrateLogger) Verbose() bool {
+	return false
+}
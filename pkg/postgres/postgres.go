@@ -3,44 +3,164 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/kkboranbay/task-service/internal/tracing"
+	"github.com/rs/zerolog"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
 )
 
+// Границы ожидания между попытками подключения при запуске
+const (
+	connectRetryMinDelay = 500 * time.Millisecond
+	connectRetryMaxDelay = 5 * time.Second
+)
+
+// NewPool открывает пул и ждет, пока база начнет принимать соединения, не дольше
+// cfg.ConnectRetryTimeout; при нулевом значении делается одна попытка
 func NewPool(ctx context.Context, cfg config.DatabaseConfig) (*pgxpool.Pool, error) {
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=%s&pool_max_conns=%d",
-		cfg.User,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
-		cfg.SSLMode,
-		cfg.MaxConns,
-	)
-
-	poolConfig, err := pgxpool.ParseConfig(dsn)
+	poolConfig, err := pgxpool.ParseConfig(DSN(cfg))
 	if err != nil {
 		return nil, fmt.Errorf("ошибка парсинга конфигурации пула соединений: %w", err)
 	}
 
-	poolConfig.ConnConfig.ConnectTimeout = cfg.Timeout
+	if err := configurePool(poolConfig, cfg); err != nil {
+		return nil, err
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания пула соединений: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
-	defer cancel()
-
-	if err := pool.Ping(ctx); err != nil {
+	if err := waitForDatabase(ctx, pool, cfg); err != nil {
+		pool.Close()
 		return nil, fmt.Errorf("ошибка подключения к базе данных: %w", err)
 	}
 
 	return pool, nil
 }
 
+// DSN строка подключения: cfg.DSN как есть или URL из отдельных полей. Значения кодируются
+// net/url, поэтому пароль может содержать @, :, / и другие служебные символы
+func DSN(cfg config.DatabaseConfig) string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	if cfg.SSLRootCert != "" {
+		query.Set("sslrootcert", cfg.SSLRootCert)
+	}
+	if cfg.SSLCert != "" {
+		query.Set("sslcert", cfg.SSLCert)
+		query.Set("sslkey", cfg.SSLKey)
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:     "/" + cfg.DBName,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// queryExecModes значения DB_STATEMENT_CACHE_MODE
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// configurePool общие настройки пулов основной базы и реплик. Нулевые значения оставляют
+// значения pgx по умолчанию
+func configurePool(poolConfig *pgxpool.Config, cfg config.DatabaseConfig) error {
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = int32(cfg.MaxConns)
+	}
+	poolConfig.MinConns = int32(cfg.MinConns)
+	if cfg.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	if cfg.StatementCacheMode != "" {
+		mode, ok := queryExecModes[cfg.StatementCacheMode]
+		if !ok {
+			return fmt.Errorf("неизвестный режим выполнения запросов %q", cfg.StatementCacheMode)
+		}
+		poolConfig.ConnConfig.DefaultQueryExecMode = mode
+	}
+	// параметры из DSN важнее: по application_name администратор базы различает экземпляры
+	// в pg_stat_activity, а таймауты сессии можно переопределить для отдельной базы
+	setRuntimeParam(poolConfig, "application_name", cfg.ApplicationName)
+	if cfg.StatementTimeout > 0 {
+		setRuntimeParam(poolConfig, "statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+	if cfg.IdleInTransactionTimeout > 0 {
+		setRuntimeParam(poolConfig, "idle_in_transaction_session_timeout", strconv.FormatInt(cfg.IdleInTransactionTimeout.Milliseconds(), 10))
+	}
+
+	poolConfig.ConnConfig.ConnectTimeout = cfg.Timeout
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer{}
+	// пароль из DB_DSN важнее DB_PASSWORD; PasswordSource подставляет ротируемый пароль,
+	// только если строка подключения собрана из полей или пароля в ней нет
+	if cfg.PasswordSource != nil && (cfg.DSN == "" || poolConfig.ConnConfig.Password == "") {
+		poolConfig.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			connConfig.Password = cfg.PasswordSource()
+			return nil
+		}
+	}
+	return nil
+}
+
+func setRuntimeParam(poolConfig *pgxpool.Config, name, value string) {
+	if _, ok := poolConfig.ConnConfig.RuntimeParams[name]; !ok && value != "" {
+		poolConfig.ConnConfig.RuntimeParams[name] = value
+	}
+}
+
+// waitForDatabase повторяет проверку соединения с растущей паузой, пока база запускается
+func waitForDatabase(ctx context.Context, pool *pgxpool.Pool, cfg config.DatabaseConfig) error {
+	deadline := time.Now().Add(cfg.ConnectRetryTimeout)
+	delay := connectRetryMinDelay
+
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		err := pool.Ping(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().Add(delay).After(deadline) {
+			return err
+		}
+		zerolog.Ctx(ctx).Warn().Err(err).Int("attempt", attempt).Dur("retry_in", delay).Msg("база данных недоступна, повторное подключение")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, connectRetryMaxDelay)
+	}
+}
+
 func Close(pool *pgxpool.Pool) {
 	if pool != nil {
 		pool.Close()
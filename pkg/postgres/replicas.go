@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/rs/zerolog"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// stickyPruneSize размер lastWrite, после которого из него удаляются устаревшие записи
+const stickyPruneSize = 1024
+
+// recoveryConflict код ошибки, с которым реплика отменяет запрос, мешающий применению WAL
+const recoveryConflict = "40001"
+
+type replica struct {
+	name    string
+	pool    *pgxpool.Pool
+	healthy atomic.Bool
+}
+
+// Router направляет чтения на доступные реплики по кругу, а изменения и чтения сразу после
+// них в основную базу. Без настроенных реплик все запросы идут в основную базу
+type Router struct {
+	primary    *pgxpool.Pool
+	replicas   []*replica
+	next       atomic.Uint64
+	stickiness time.Duration
+	timeout    time.Duration
+	log        *zerolog.Logger
+
+	mu sync.Mutex
+	// lastWrite время последнего изменения по ключу, обычно ID пользователя. Хранится в памяти
+	// экземпляра: запрос, попавший на другой экземпляр сервиса, может прочитать реплику
+	lastWrite map[int64]time.Time
+}
+
+// NewRouter открывает пулы реплик из cfg.Replicas. Недоступная при запуске реплика не мешает
+// старту: она помечается нездоровой и возвращается в работу после успешной проверки
+func NewRouter(ctx context.Context, primary *pgxpool.Pool, cfg config.DatabaseConfig, log *zerolog.Logger) (*Router, error) {
+	r := &Router{
+		primary:    primary,
+		stickiness: cfg.ReplicaStickiness,
+		timeout:    cfg.Timeout,
+		log:        log,
+		lastWrite:  make(map[int64]time.Time),
+	}
+
+	for _, dsn := range cfg.Replicas {
+		poolConfig, err := pgxpool.ParseConfig(dsn)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("ошибка парсинга DSN реплики: %w", err)
+		}
+		replicaCfg := cfg
+		if poolConfig.ConnConfig.Password != "" {
+			// пароль из DSN реплики важнее пароля основной базы
+			replicaCfg.PasswordSource = nil
+		}
+		if err := configurePool(poolConfig, replicaCfg); err != nil {
+			r.Close()
+			return nil, err
+		}
+
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("ошибка создания пула реплики: %w", err)
+		}
+
+		rep := &replica{
+			name: fmt.Sprintf("%s:%d", poolConfig.ConnConfig.Host, poolConfig.ConnConfig.Port),
+			pool: pool,
+		}
+		r.replicas = append(r.replicas, rep)
+		if err := r.check(ctx, rep); err != nil {
+			log.Warn().Err(err).Str("replica", rep.name).Msg("реплика недоступна при запуске")
+		}
+	}
+
+	return r, nil
+}
+
+// Primary основная база для изменений и запросов, которым нужны последние данные
+func (r *Router) Primary() *pgxpool.Pool {
+	return r.primary
+}
+
+// Read выполняет чтение для ключа key на реплике. Если реплика не отвечает, она помечается
+// нездоровой, а чтение повторяется в основной базе; так же повторяется запрос, отмененный
+// репликой из-за конфликта с восстановлением
+func (r *Router) Read(ctx context.Context, key int64, fn func(db *pgxpool.Pool) error) error {
+	rep := r.pick(key)
+	if rep == nil {
+		return fn(r.primary)
+	}
+
+	err := fn(rep.pool)
+	if err == nil || ctx.Err() != nil || errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	var pgErr *pgconn.PgError
+	switch {
+	case IsConnectionFailure(err):
+		r.markDown(rep, err)
+	case errors.As(err, &pgErr) && pgErr.Code == recoveryConflict:
+		// конфликт с применением WAL не делает реплику нездоровой, повторяется только этот запрос
+	default:
+		return err
+	}
+	return fn(r.primary)
+}
+
+// MarkWritten направляет чтения по key в основную базу на время ReplicaStickiness
+func (r *Router) MarkWritten(key int64) {
+	if len(r.replicas) == 0 || r.stickiness <= 0 {
+		return
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastWrite[key] = now
+	if len(r.lastWrite) > stickyPruneSize {
+		for k, at := range r.lastWrite {
+			if now.Sub(at) >= r.stickiness {
+				delete(r.lastWrite, k)
+			}
+		}
+	}
+}
+
+// HealthyReplicas число реплик, на которые сейчас направляются чтения
+func (r *Router) HealthyReplicas() int {
+	count := 0
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			count++
+		}
+	}
+	return count
+}
+
+// Check проверка для /readyz: отказ всех реплик не останавливает сервис, но перегружает основную базу
+func (r *Router) Check(ctx context.Context) error {
+	if len(r.replicas) > 0 && r.HealthyReplicas() == 0 {
+		return errors.New("нет доступных реплик, чтения идут в основную базу")
+	}
+	return nil
+}
+
+// Run проверяет реплики каждые interval до отмены ctx
+func (r *Router) Run(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, rep := range r.replicas {
+				_ = r.check(ctx, rep)
+			}
+		}
+	}
+}
+
+// Close закрывает пулы реплик; основной пул закрывает его владелец
+func (r *Router) Close() {
+	for _, rep := range r.replicas {
+		rep.pool.Close()
+	}
+}
+
+// pick следующая здоровая реплика или nil, если чтение должно идти в основную базу
+func (r *Router) pick(key int64) *replica {
+	if len(r.replicas) == 0 || r.sticky(key) {
+		return nil
+	}
+
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		rep := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+func (r *Router) sticky(key int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	at, ok := r.lastWrite[key]
+	if !ok {
+		return false
+	}
+	if time.Since(at) >= r.stickiness {
+		delete(r.lastWrite, key)
+		return false
+	}
+	return true
+}
+
+func (r *Router) check(ctx context.Context, rep *replica) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if err := rep.pool.Ping(ctx); err != nil {
+		r.markDown(rep, err)
+		return err
+	}
+	if !rep.healthy.Swap(true) {
+		r.log.Info().Str("replica", rep.name).Msg("реплика доступна, чтения направляются на нее")
+	}
+	return nil
+}
+
+func (r *Router) markDown(rep *replica, err error) {
+	if rep.healthy.Swap(false) {
+		r.log.Warn().Err(err).Str("replica", rep.name).Msg("реплика недоступна, чтения идут в основную базу")
+	}
+}
+
+// IsConnectionFailure база не отвечает, соединение оборвалось или сервер отказывается
+// принимать запросы; такие ошибки не связаны с самим запросом
+func IsConnectionFailure(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// 57P* — сервер останавливается или еще не принимает соединения
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && strings.HasPrefix(pgErr.Code, "57P")
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kkboranbay/task-service/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+	"time"
+)
+
+// unreachableDSN пул на закрытом порту: pgxpool подключается лениво, поэтому создание
+// пула проходит, а проверка реплики при запуске не удается
+const unreachableDSN = "postgres://app@127.0.0.1:1/taskdb"
+
+func newTestRouter(t *testing.T, replicas int) *Router {
+	t.Helper()
+	log := zerolog.Nop()
+
+	primary, err := pgxpool.New(context.Background(), unreachableDSN)
+	require.NoError(t, err)
+	t.Cleanup(primary.Close)
+
+	cfg := config.DatabaseConfig{
+		MaxConns:          2,
+		Timeout:           100 * time.Millisecond,
+		ReplicaStickiness: time.Minute,
+	}
+	for i := 0; i < replicas; i++ {
+		cfg.Replicas = append(cfg.Replicas, unreachableDSN)
+	}
+
+	router, err := NewRouter(context.Background(), primary, cfg, &log)
+	require.NoError(t, err)
+	t.Cleanup(router.Close)
+	return router
+}
+
+// target возвращает функцию чтения, которая запоминает пул и возвращает ошибку, если это реплика
+func target(router *Router, used **pgxpool.Pool, replicaErr error) func(db *pgxpool.Pool) error {
+	return func(db *pgxpool.Pool) error {
+		*used = db
+		if db != router.Primary() {
+			return replicaErr
+		}
+		return nil
+	}
+}
+
+func TestRouterWithoutReplicasReadsPrimary(t *testing.T) {
+	router := newTestRouter(t, 0)
+
+	var used *pgxpool.Pool
+	require.NoError(t, router.Read(context.Background(), 1, target(router, &used, nil)))
+	assert.Same(t, router.Primary(), used)
+	assert.NoError(t, router.Check(context.Background()))
+}
+
+func TestRouterFallsBackWhenReplicasUnavailable(t *testing.T) {
+	router := newTestRouter(t, 2)
+	assert.Equal(t, 0, router.HealthyReplicas(), "реплика на закрытом порту не проходит проверку при запуске")
+
+	var used *pgxpool.Pool
+	require.NoError(t, router.Read(context.Background(), 1, target(router, &used, nil)))
+	assert.Same(t, router.Primary(), used)
+	assert.Error(t, router.Check(context.Background()))
+}
+
+func TestRouterRead(t *testing.T) {
+	connErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	tests := []struct {
+		name        string
+		replicaErr  error
+		wantPrimary bool
+		wantHealthy int
+	}{
+		{name: "replica_success", wantHealthy: 1},
+		{name: "no_rows_not_retried", replicaErr: pgx.ErrNoRows, wantHealthy: 1},
+		{name: "query_error_not_retried", replicaErr: &pgconn.PgError{Code: "42601"}, wantHealthy: 1},
+		{name: "connection_failure", replicaErr: connErr, wantPrimary: true, wantHealthy: 0},
+		{name: "shutdown", replicaErr: &pgconn.PgError{Code: "57P01"}, wantPrimary: true, wantHealthy: 0},
+		{name: "recovery_conflict", replicaErr: &pgconn.PgError{Code: recoveryConflict}, wantPrimary: true, wantHealthy: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t, 1)
+			router.replicas[0].healthy.Store(true)
+
+			var used *pgxpool.Pool
+			err := router.Read(context.Background(), 1, target(router, &used, tt.replicaErr))
+
+			if tt.wantPrimary {
+				assert.NoError(t, err)
+				assert.Same(t, router.Primary(), used)
+			} else {
+				assert.Equal(t, tt.replicaErr, err)
+				assert.Same(t, router.replicas[0].pool, used)
+			}
+			assert.Equal(t, tt.wantHealthy, router.HealthyReplicas())
+		})
+	}
+}
+
+func TestRouterReadYourWrites(t *testing.T) {
+	router := newTestRouter(t, 1)
+	router.replicas[0].healthy.Store(true)
+
+	router.MarkWritten(1)
+
+	var used *pgxpool.Pool
+	require.NoError(t, router.Read(context.Background(), 1, target(router, &used, nil)))
+	assert.Same(t, router.Primary(), used, "после изменения пользователь читает основную базу")
+
+	require.NoError(t, router.Read(context.Background(), 2, target(router, &used, nil)))
+	assert.Same(t, router.replicas[0].pool, used, "другие пользователи читают реплику")
+
+	router.stickiness = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	require.NoError(t, router.Read(context.Background(), 1, target(router, &used, nil)))
+	assert.Same(t, router.replicas[0].pool, used, "после окончания окна чтения возвращаются на реплику")
+}
+
+func TestRouterRoundRobin(t *testing.T) {
+	router := newTestRouter(t, 3)
+	for _, rep := range router.replicas {
+		rep.healthy.Store(true)
+	}
+	router.replicas[1].healthy.Store(false)
+
+	seen := make(map[*pgxpool.Pool]int)
+	for i := 0; i < 6; i++ {
+		var used *pgxpool.Pool
+		require.NoError(t, router.Read(context.Background(), 1, target(router, &used, nil)))
+		seen[used]++
+	}
+
+	assert.Zero(t, seen[router.replicas[1].pool], "нездоровая реплика пропускается")
+	assert.Positive(t, seen[router.replicas[0].pool])
+	assert.Positive(t, seen[router.replicas[2].pool])
+}
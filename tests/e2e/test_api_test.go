@@ -2,6 +2,7 @@ package e2e
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
@@ -44,6 +45,12 @@ func (suite *E2ETestSuite) SetupSuite() {
 		Auth: config.AuthConfig{
 			JWTSecret:        "test-secret-key",
 			TokenExpireDelta: 24 * time.Hour,
+			LoginGuard: config.LoginGuardConfig{
+				MaxFailures:      5,
+				MaxFailuresPerIP: 20,
+				FailureWindow:    15 * time.Minute,
+				LockoutDuration:  15 * time.Minute,
+			},
 		},
 		Logger: config.LoggerConfig{
 			Level: "error",
@@ -53,14 +60,18 @@ func (suite *E2ETestSuite) SetupSuite() {
 	logger.SetupLogger(cfg.Logger)
 	log := logger.L()
 
-	taskRepo := postgres.NewTaskRepository(suite.testDB.Pool)
+	taskRepo := postgres.NewTaskRepository(suite.testDB.Pool, nil)
 	taskService := service.NewTaskService(taskRepo, log)
 
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
 	jwtMiddleware := middleware.NewJWTMiddleware(cfg.Auth, log)
-	authHandler := handler.NewAuthHandler(jwtMiddleware, log)
+	loginGuard := service.NewLoginGuard(cfg.Auth.LoginGuard, log)
+	userService := service.NewUserService(postgres.NewUserRepository(suite.testDB.Pool), log)
+	require.NoError(suite.T(), userService.BootstrapAdmin(context.Background(), testutils.AdminPassword))
+	twoFactorService := service.NewTwoFactorService(postgres.NewTwoFactorRepository(suite.testDB.Pool), cfg.Auth, log)
+	authHandler := handler.NewAuthHandler(jwtMiddleware, userService, twoFactorService, loginGuard, log)
 	authHandler.Register(router)
 
 	apiGroup := router.Group("/api/v1")